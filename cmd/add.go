@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	addFlags = struct {
+		Note    string
+		Date    string
+		Heading string
+	}{}
+
+	add = &cobra.Command{
+		Use:   "add [task]",
+		Short: "Add a task or a line of text to an entry",
+		Long: `Add a task or a line of text to an entry, creating the entry from the template if it does not exist yet.
+
+  jot add "- [ ] ship release"
+  jot add --heading Work review PRs
+  jot add --note "free text" --date 2021-06-22
+  jot add --note "- not a task"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var lines []string
+			if len(args) > 0 {
+				lines = append(lines, taskLine(strings.Join(args, " ")))
+			}
+			if addFlags.Note != "" {
+				lines = append(lines, addFlags.Note)
+			}
+			if len(lines) == 0 {
+				return fmt.Errorf("nothing to add, provide a task or --note")
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			day, err := parseDay(addFlags.Date)
+			if err != nil {
+				return err
+			}
+
+			store, err := openNotes(cfg)
+			if err != nil {
+				return err
+			}
+
+			e, err := ensureEntry(store, cfg, day)
+			if err != nil {
+				return err
+			}
+
+			for _, l := range lines {
+				e.Content = v1.InsertUnderHeading(e.Content, addFlags.Heading, l)
+			}
+
+			if _, err := store.CreateOrUpdateNote(e); err != nil {
+				return err
+			}
+
+			for _, l := range lines {
+				fmt.Printf("%s: %s\n", store.StoragePathDoc(e.Identifier()), l)
+			}
+			return nil
+		},
+	}
+)
+
+// taskLine turns text into a markdown task, unless it already is a list item
+func taskLine(text string) string {
+	if strings.HasPrefix(text, "- ") || strings.HasPrefix(text, "* ") {
+		return text
+	}
+	return "- [ ] " + text
+}
+
+// listItemArgs moves the arguments of add that are markdown list items (i.e.
+// "- [ ] task") after the "--" terminator, so they are not mistaken for
+// shorthand flags. The values of flags are left where they are.
+func listItemArgs(args []string) []string {
+	var flagArgs, positional []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if strings.HasPrefix(a, "- ") {
+			positional = append(positional, a)
+			continue
+		}
+		flagArgs = append(flagArgs, a)
+		if takesValue(add, a) && i+1 < len(args) {
+			i++
+			flagArgs = append(flagArgs, args[i])
+		}
+	}
+
+	if len(positional) == 0 {
+		return args
+	}
+	return append(append(flagArgs, "--"), positional...)
+}
+
+// takesValue tells whether arg is a flag of cmd that is given its value in the
+// next argument
+func takesValue(cmd *cobra.Command, arg string) bool {
+	if !strings.HasPrefix(arg, "-") || strings.Contains(arg, "=") {
+		return false
+	}
+	for _, flags := range []*pflag.FlagSet{cmd.Flags(), cmd.InheritedFlags()} {
+		f := flags.Lookup(strings.TrimPrefix(arg, "--"))
+		if !strings.HasPrefix(arg, "--") {
+			if len(arg) != 2 {
+				// -dtoday has its value already
+				return false
+			}
+			f = flags.ShorthandLookup(arg[1:])
+		}
+		if f != nil {
+			return f.NoOptDefVal == ""
+		}
+	}
+	return false
+}
+
+func init() {
+	add.Flags().StringVarP(&addFlags.Note, "note", "n", "", "free text line to add")
+	add.Flags().StringVarP(&addFlags.Date, "date", "d", "", "day of the entry to add to (YYYY-MM-DD, today, yesterday)")
+	add.Flags().StringVar(&addFlags.Heading, "heading", "", "heading to add under, created if missing")
+	root.AddCommand(add)
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/byxorna/jot/pkg/model"
+	"github.com/byxorna/jot/pkg/types/v1"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// capturePreview is how many of the lines captured are printed back
+const capturePreview = 5
+
+var (
+	captureFlags = struct {
+		Fenced  string
+		Date    string
+		Heading string
+	}{}
+
+	capture = &cobra.Command{
+		Use:   "capture",
+		Short: "Capture stdin into an entry",
+		Long: `Capture whatever is piped into stdin into an entry, creating the entry from the template
+if it does not exist yet. Piping into jot without a subcommand does the same.
+
+  echo "- [ ] call vendor" | jot
+  kubectl get pods | jot capture --fenced=bash --heading Work`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			content, err := model.ReadStdin()
+			if err != nil {
+				return fmt.Errorf("nothing to capture on stdin")
+			}
+			return captureContent(content)
+		},
+	}
+)
+
+// stdinPiped returns whether something is piped into jot, or redirected from a
+// file that is not empty. Stdin that is a terminal, or like /dev/null in cron
+// jobs, is not captured.
+func stdinPiped() bool {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeNamedPipe != 0 || (stat.Mode().IsRegular() && stat.Size() > 0)
+}
+
+// captureContent inserts content into an entry according to captureFlags
+func captureContent(content string) error {
+	content = strings.TrimRight(content, "\n")
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("nothing to capture on stdin")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	day, err := parseDay(captureFlags.Date)
+	if err != nil {
+		return err
+	}
+
+	store, err := openNotes(cfg)
+	if err != nil {
+		return err
+	}
+
+	e, err := ensureEntry(store, cfg, day)
+	if err != nil {
+		return err
+	}
+
+	block := content
+	if captureFlags.Fenced != "" {
+		block = fmt.Sprintf("\n### Captured %s\n\n```%s\n%s\n```", time.Now().Format("15:04:05"), captureFlags.Fenced, content)
+	}
+	e.Content = v1.InsertUnderHeading(e.Content, captureFlags.Heading, block)
+
+	if _, err := store.CreateOrUpdateNote(e); err != nil {
+		return err
+	}
+
+	// what was captured is shown, so stdin captured by accident is noticed
+	lines := strings.Split(content, "\n")
+	fmt.Printf("%s: captured %d lines\n", store.StoragePathDoc(e.Identifier()), len(lines))
+	for i, l := range lines {
+		if i == capturePreview {
+			fmt.Printf("  ... %d more\n", len(lines)-i)
+			break
+		}
+		fmt.Printf("  %s\n", l)
+	}
+	return nil
+}
+
+func init() {
+	capture.Flags().StringVar(&captureFlags.Fenced, "fenced", "", "wrap the capture in a code fence of this language, under a timestamp heading")
+	capture.Flags().Lookup("fenced").NoOptDefVal = "text"
+	capture.Flags().StringVarP(&captureFlags.Date, "date", "d", "", "day of the entry to capture into (YYYY-MM-DD, today, yesterday)")
+	capture.Flags().StringVar(&captureFlags.Heading, "heading", "", "heading to capture under, created if missing")
+	root.AddCommand(capture)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/byxorna/jot/pkg/plugins/fs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorFlags = struct {
+		Fix bool
+	}{}
+
+	doctor = &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the notes directory for broken or conflicting notes",
+		Long: `Check the notes directory for notes that cannot be loaded, misnamed files, duplicate IDs,
+creation times that disagree with the filename, and stray files. With --fix, problems are
+repaired where it is unambiguous how to do so.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			settings, err := notebookSettings(cfg)
+			if err != nil {
+				return err
+			}
+
+			problems, err := fs.Diagnose(cfg.Directory, settings)
+			if err != nil {
+				return err
+			}
+
+			remaining := 0
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			for _, p := range problems {
+				status := "unfixable"
+				if p.Fixable() {
+					status = "fixable"
+					if doctorFlags.Fix {
+						if err := p.Fix(); err != nil {
+							status = fmt.Sprintf("fix failed: %v", err)
+						} else {
+							status = "fixed"
+						}
+					}
+				}
+				if status != "fixed" {
+					remaining++
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Kind, p.File, p.Message, status)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			if remaining > 0 {
+				return fmt.Errorf("%d problems found in %s", remaining, cfg.Directory)
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	doctor.Flags().BoolVar(&doctorFlags.Fix, "fix", false, "repair problems where it is unambiguous how to do so")
+	root.AddCommand(doctor)
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/byxorna/jot/pkg/plugins/fs"
+	"github.com/byxorna/jot/pkg/types/v1"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	// passphraseEnv unlocks notebooks without asking, like in scripts
+	passphraseEnv = "JOT_PASSPHRASE"
+)
+
+var (
+	encryptFlags = struct {
+		All      bool
+		Metadata bool
+	}{}
+
+	encrypt = &cobra.Command{
+		Use:   "encrypt [date]",
+		Short: "Seal an entry, or every note, with the passphrase of the notebook",
+		Long: `Sealed notes are encrypted at rest with a key derived from the passphrase of their notebook,
+which is asked for the first time a note is sealed. The passphrase is asked for once per
+session to read them, or taken from $` + passphraseEnv + `.
+
+To seal every note of a notebook, including new ones, set encrypted: true in the settings
+of its section, or encrypted: metadata to also seal titles, tags and labels.
+
+  jot encrypt today
+  jot encrypt --all --metadata`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mode := fs.SealContent
+			if encryptFlags.Metadata {
+				mode = fs.SealMetadata
+			}
+			return sealNotes(args, encryptFlags.All, mode)
+		},
+	}
+
+	decryptFlags = struct {
+		All bool
+	}{}
+
+	decrypt = &cobra.Command{
+		Use:   "decrypt [date]",
+		Short: "Store a sealed entry, or every note, in plain text again",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sealNotes(args, decryptFlags.All, "false")
+		},
+	}
+)
+
+// sealNotes labels the entry for the date in args, or every note, to be
+// sealed the given way, which is "false" to store them in plain text
+func sealNotes(args []string, all bool, mode string) error {
+	var date string
+	if len(args) > 0 {
+		date = args[0]
+	}
+	if all && date != "" {
+		return fmt.Errorf("provide either a date or --all")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	store, err := openNotes(cfg)
+	if err != nil {
+		return err
+	}
+	settings, err := notebookSettings(cfg)
+	if err != nil {
+		return err
+	}
+
+	notes := []*v1.Note{}
+	if all {
+		if notes, err = store.ListAll(); err != nil {
+			return err
+		}
+	} else {
+		day, err := parseDay(date)
+		if err != nil {
+			return err
+		}
+		e, err := store.GetByDay(day)
+		if err != nil {
+			return fmt.Errorf("no entry found for %s: %w", day.Format(dayFormat), err)
+		}
+		notes = append(notes, e)
+	}
+
+	if mode != "false" && !store.HasPassphrase() {
+		passphrase, err := readPassphrase(fmt.Sprintf("New passphrase for %s: ", store.StoragePath()))
+		if err != nil {
+			return err
+		}
+		again, err := readPassphrase("Repeat the passphrase: ")
+		if err != nil {
+			return err
+		}
+		if passphrase != again {
+			return fmt.Errorf("passphrases do not match")
+		}
+		if err := store.SetPassphrase(passphrase); err != nil {
+			return err
+		}
+	}
+
+	for _, n := range notes {
+		n, err := store.GetByID(n.Metadata.ID, false)
+		if err != nil {
+			return err
+		}
+		if mode != "false" {
+			// revisions kept before the note was sealed, by an older jot, are
+			// still in plain text
+			if err := store.SealHistory(n.Metadata.ID); err != nil {
+				return err
+			}
+		}
+		if n.Metadata.Labels[fs.LabelEncrypted] == mode {
+			continue
+		}
+
+		sealed := *n
+		sealed.Metadata.Labels = map[string]string{}
+		for k, v := range n.Metadata.Labels {
+			sealed.Metadata.Labels[k] = v
+		}
+		sealed.Metadata.Labels[fs.LabelEncrypted] = mode
+		if mode == "false" && settings[fs.SettingEncrypted] == "" {
+			// plain text is the default of the notebook
+			delete(sealed.Metadata.Labels, fs.LabelEncrypted)
+		}
+		if _, err := store.CreateOrUpdateNote(&sealed); err != nil {
+			return err
+		}
+		if mode == "false" {
+			fmt.Printf("%s: decrypted\n", store.StoragePathDoc(n.Identifier()))
+		} else {
+			fmt.Printf("%s: encrypted\n", store.StoragePathDoc(n.Identifier()))
+		}
+	}
+	return nil
+}
+
+// unlockNotes asks for the passphrase of a locked notebook, unless it is in
+// $JOT_PASSPHRASE. Without a terminal to ask on, the notebook stays locked and
+// only its sealed notes cannot be read.
+func unlockNotes(store *fs.Store) error {
+	if !store.Locked() {
+		return nil
+	}
+	if _, ok := os.LookupEnv(passphraseEnv); !ok && !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil
+	}
+	passphrase, err := readPassphrase(fmt.Sprintf("Passphrase for %s: ", store.StoragePath()))
+	if err != nil {
+		return err
+	}
+	return store.UnlockWith(passphrase)
+}
+
+// readPassphrase asks for a passphrase on the terminal, without echoing it
+func readPassphrase(prompt string) (string, error) {
+	if passphrase, ok := os.LookupEnv(passphraseEnv); ok {
+		return passphrase, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("no terminal to ask for the passphrase on, set $%s", passphraseEnv)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	b, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the passphrase: %w", err)
+	}
+	return string(b), nil
+}
+
+func init() {
+	encrypt.Flags().BoolVarP(&encryptFlags.All, "all", "a", false, "seal every note of the notebook")
+	encrypt.Flags().BoolVar(&encryptFlags.Metadata, "metadata", false, "also seal the title, tags and labels")
+	decrypt.Flags().BoolVarP(&decryptFlags.All, "all", "a", false, "store every note of the notebook in plain text")
+	root.AddCommand(encrypt)
+	root.AddCommand(decrypt)
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/byxorna/jot/pkg/model"
+	"github.com/byxorna/jot/pkg/text"
+	"github.com/byxorna/jot/pkg/types/v1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyFlags = struct {
+		Diff    int
+		Against int
+		Restore int
+	}{}
+
+	history = &cobra.Command{
+		Use:   "history [date]",
+		Short: "List, compare and restore the revisions of an entry",
+		Long: `Every change to a note is recorded as a revision in the .history directory of its notebook.
+Revisions are numbered from the newest, which is 1.
+
+  jot history yesterday
+  jot history --diff 2              # changes made in revision 2
+  jot history --diff 1 --against 5  # changes from revision 5 to 1
+  jot history --restore 3`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var date string
+			if len(args) > 0 {
+				date = args[0]
+			}
+
+			store, e, err := loadEntry(date)
+			if err != nil {
+				return err
+			}
+			revisions, err := store.Revisions(e.Metadata.ID)
+			if err != nil {
+				return err
+			}
+
+			revision := func(flag string, n int) error {
+				if n < 1 || n > len(revisions) {
+					return fmt.Errorf("no revision %d for --%s, expected a number from 1 to %d", n, flag, len(revisions))
+				}
+				return nil
+			}
+
+			switch {
+			case historyFlags.Restore != 0:
+				if err := revision("restore", historyFlags.Restore); err != nil {
+					return err
+				}
+				r := revisions[historyFlags.Restore-1]
+				restored, err := store.RestoreRevision(e.Metadata.ID, r)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s: restored to %s", store.StoragePathDoc(e.Identifier()), r.Time.Local().Format("2006-01-02 15:04:05"))
+				if delta := model.TaskDelta(e.Content, restored.Content); delta != "" {
+					fmt.Printf(" (%s)", delta)
+				}
+				fmt.Println()
+				return nil
+
+			case historyFlags.Diff != 0:
+				if err := revision("diff", historyFlags.Diff); err != nil {
+					return err
+				}
+				old := ""
+				switch {
+				case historyFlags.Against != 0:
+					if err := revision("against", historyFlags.Against); err != nil {
+						return err
+					}
+					old = revisions[historyFlags.Against-1].Note.Content
+				case historyFlags.Diff < len(revisions):
+					old = revisions[historyFlags.Diff].Note.Content
+				}
+				fmt.Print(text.Unified(old, revisions[historyFlags.Diff-1].Note.Content, 3))
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "REVISION\tMODIFIED\tTASKS\tCHANGE")
+			for i, r := range revisions {
+				old := ""
+				if i+1 < len(revisions) {
+					old = revisions[i+1].Note.Content
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i+1, r.Time.Local().Format("2006-01-02 15:04:05"), r.Note.NoteTaskStatus(v1.TaskStyleDiscrete), model.TaskDelta(old, r.Note.Content))
+			}
+			return w.Flush()
+		},
+	}
+)
+
+func init() {
+	history.Flags().IntVarP(&historyFlags.Diff, "diff", "d", 0, "show the changes made in a revision")
+	history.Flags().IntVar(&historyFlags.Against, "against", 0, "compare --diff to this revision instead of the one before")
+	history.Flags().IntVarP(&historyFlags.Restore, "restore", "r", 0, "restore the entry to a revision")
+	root.AddCommand(history)
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/spf13/cobra"
+)
+
+const (
+	sortCreated  = "created"
+	sortModified = "modified"
+
+	// summaryWidth is how much of the summary of a document is shown by
+	// jot ls --wide
+	summaryWidth = 60
+)
+
+var (
+	lsFlags = struct {
+		Output   string
+		Sort     string
+		Archived bool
+		Wide     bool
+	}{}
+
+	ls = &cobra.Command{
+		Use:   "ls [section]",
+		Short: "List the documents in every section, or a single section",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch lsFlags.Sort {
+			case sortCreated, sortModified:
+			default:
+				return fmt.Errorf("unsupported sort %q, expected %s or %s", lsFlags.Sort, sortCreated, sortModified)
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			sections, err := openSections(cmd.Context(), cfg, args...)
+			if err != nil {
+				return err
+			}
+
+			listings := []docListing{}
+			for _, sec := range sections {
+				ctx, cancel := context.WithTimeout(cmd.Context(), sec.Timeout())
+				docs, err := sec.List(ctx)
+				if lsFlags.Archived {
+					docs, err = db.Searchable(ctx, sec.Backend())
+				}
+				cancel()
+				if err != nil {
+					return fmt.Errorf("unable to list %s: %w", sec.Identifier(), err)
+				}
+
+				if lsFlags.Sort == sortModified {
+					sort.Stable(db.DocsByModified(docs))
+				} else {
+					sort.Stable(db.DocsByCreated(docs))
+				}
+
+				for _, d := range docs {
+					listings = append(listings, newDocListing(sec.Identifier(), d))
+				}
+			}
+
+			if lsFlags.Output != outputTable {
+				return encode(os.Stdout, lsFlags.Output, listings)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			header := "SECTION\tID\tCREATED\tMODIFIED\tTASKS\tTITLE\tTAGS\tLABELS"
+			if lsFlags.Wide {
+				header += "\tSUMMARY"
+			}
+			fmt.Fprintln(w, header)
+			for _, l := range listings {
+				row := []string{
+					l.Section,
+					l.ID,
+					l.Created.Local().Format("2006-01-02 15:04"),
+					l.modifiedColumn(),
+					l.tasksColumn(),
+					l.Title,
+					strings.Join(l.Tags, ","),
+					l.labelsColumn(),
+				}
+				if lsFlags.Wide {
+					row = append(row, l.summaryColumn(summaryWidth))
+				}
+				fmt.Fprintln(w, strings.Join(row, "\t"))
+			}
+			return w.Flush()
+		},
+	}
+)
+
+func init() {
+	ls.Flags().StringVarP(&lsFlags.Output, "output", "o", outputTable, "output format (table, json, yaml)")
+	ls.Flags().StringVar(&lsFlags.Sort, "sort", sortCreated, "sort order (created, modified)")
+	ls.Flags().BoolVarP(&lsFlags.Archived, "archived", "a", false, "include archived documents")
+	ls.Flags().BoolVarP(&lsFlags.Wide, "wide", "w", false, "show the summary of every document in the table")
+	root.AddCommand(ls)
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/byxorna/jot/pkg/plugins/fs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateFlags = struct {
+		From   string
+		DryRun bool
+	}{}
+
+	migrateLayout = &cobra.Command{
+		Use:   "migrate-layout",
+		Short: "Move daily entries into the layout configured for the notebook",
+		Long: `Move daily entries from the paths of the --from layout into the layout configured in the
+settings of the notebook. Named notes are left where they are, and nothing is moved if any
+file would be overwritten.
+
+  # after setting "layout: 2006/01/2006-01-02.md" in the settings of the notes section
+  jot migrate-layout --dry-run
+  jot migrate-layout`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			settings, err := notebookSettings(cfg)
+			if err != nil {
+				return err
+			}
+
+			moves, err := fs.MigrateLayout(cfg.Directory, settings, migrateFlags.From, migrateFlags.DryRun)
+			if err != nil {
+				return fmt.Errorf("unable to migrate layout: %w", err)
+			}
+
+			for _, m := range moves {
+				fmt.Printf("%s -> %s\n", m.From, m.To)
+			}
+			if migrateFlags.DryRun {
+				fmt.Printf("%d files would be moved\n", len(moves))
+			} else {
+				fmt.Printf("%d files moved\n", len(moves))
+			}
+			return nil
+		},
+	}
+
+	migrateSchemaFlags = struct {
+		DryRun bool
+	}{}
+
+	migrateSchema = &cobra.Command{
+		Use:   "migrate",
+		Short: "Rewrite notes stored in an older schema in the current one",
+		Long: `Notes stored in an older schema are read as they are, and stored in the current one
+(` + string(fs.SchemaVersion) + `) whenever they are next changed. Migrate rewrites all of them at once,
+leaving files without front matter as they are.
+
+  jot migrate --dry-run
+  jot migrate`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			store, err := openNotes(cfg)
+			if err != nil {
+				return err
+			}
+
+			upgrades, err := store.Migrate(migrateSchemaFlags.DryRun)
+			if err != nil {
+				return fmt.Errorf("unable to migrate notes: %w", err)
+			}
+
+			for _, u := range upgrades {
+				fmt.Printf("%s: %s -> %s\n", u.File, u.From, fs.SchemaVersion)
+			}
+			if migrateSchemaFlags.DryRun {
+				fmt.Printf("%d notes would be upgraded\n", len(upgrades))
+			} else {
+				fmt.Printf("%d notes upgraded\n", len(upgrades))
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	migrateLayout.Flags().StringVar(&migrateFlags.From, "from", fs.StorageFilenameFormat, "layout the daily entries are currently stored in")
+	migrateLayout.Flags().BoolVar(&migrateFlags.DryRun, "dry-run", false, "only print the files that would be moved")
+	root.AddCommand(migrateLayout)
+
+	migrateSchema.Flags().BoolVar(&migrateSchemaFlags.DryRun, "dry-run", false, "only print the notes that would be upgraded")
+	root.AddCommand(migrateSchema)
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/byxorna/jot/pkg/model"
+	"github.com/spf13/cobra"
+)
+
+var (
+	newFlags = struct {
+		Tags []string
+		Name string
+	}{}
+
+	newNote = &cobra.Command{
+		Use:   "new <title>",
+		Short: "Create a named note, like meeting notes or a runbook, alongside the daily entries",
+		Long: `Create a named note, stored in a file named after its title instead of the day it was created.
+
+  jot new "Q3 planning" --tags work
+  jot new "Deploy runbook" --name deploy`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			title := strings.Join(args, " ")
+			name := newFlags.Name
+			if name == "" {
+				name = title
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			store, err := openNotes(cfg)
+			if err != nil {
+				return err
+			}
+
+			u, err := user.Current()
+			if err != nil {
+				return err
+			}
+
+			e, err := store.CreateNamedNote(model.NewNamedNote(title, newFlags.Tags, time.Now(), u.Username), name)
+			if err != nil {
+				return fmt.Errorf("unable to create note: %w", err)
+			}
+
+			fmt.Println(store.StoragePathDoc(e.Identifier()))
+			return nil
+		},
+	}
+)
+
+func init() {
+	newNote.Flags().StringSliceVarP(&newFlags.Tags, "tags", "t", nil, "tags of the note")
+	newNote.Flags().StringVar(&newFlags.Name, "name", "", "file name of the note, defaults to the title")
+	root.AddCommand(newNote)
+}
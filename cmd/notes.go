@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/byxorna/jot/pkg/config"
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/model"
+	"github.com/byxorna/jot/pkg/plugins/fs"
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+const (
+	dayFormat = "2006-01-02"
+)
+
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.NewFromFile(flags.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// notebooks are the notebooks opened by the command, closed when it is done
+var notebooks []*fs.Store
+
+// opened keeps the notebook to close it when the command is done, warning
+// about pulls that failed
+func opened(store *fs.Store) error {
+	notebooks = append(notebooks, store)
+	if err := store.PullError(); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to pull %s: %v\n", store.StoragePath(), err)
+	}
+	return unlockNotes(store)
+}
+
+// closeNotebooks waits for the commits of the notebooks opened by the command
+// to be pushed
+func closeNotebooks() {
+	for _, store := range notebooks {
+		if err := store.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	notebooks = nil
+}
+
+// openNotes opens the storage of the notebook selected by --notebook without
+// starting the UI
+func openNotes(cfg *config.Config) (*fs.Store, error) {
+	sec, err := model.JournalSection(cfg, flags.Notebook)
+	if err != nil {
+		return nil, err
+	}
+	store, err := model.NewNotebook(cfg, sec)
+	if err != nil {
+		return nil, err
+	}
+	return store, opened(store)
+}
+
+// notebookSettings returns the settings of the notebook selected by --notebook
+func notebookSettings(cfg *config.Config) (map[string]string, error) {
+	sec, err := model.JournalSection(cfg, flags.Notebook)
+	if err != nil {
+		return nil, err
+	}
+	return model.NotebookSettings(cfg, sec)
+}
+
+// parseDay parses a day given on the command line. An empty string is today.
+// The time of day is taken from now, like entries created from the UI.
+func parseDay(s string) (time.Time, error) {
+	now := time.Now()
+	switch strings.ToLower(s) {
+	case "", "today":
+		return now, nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+
+	d, err := time.ParseInLocation(dayFormat, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to parse date %q, expected %s: %w", s, dayFormat, err)
+	}
+	return time.Date(d.Year(), d.Month(), d.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.Local), nil
+}
+
+// ensureEntry returns the daily entry for day, creating it from the entry
+// template if it does not exist yet
+func ensureEntry(store *fs.Store, cfg *config.Config, day time.Time) (*v1.Note, error) {
+	e, err := store.GetByDay(day)
+	if err == nil {
+		return e, nil
+	}
+	if err != db.ErrNoNoteFound {
+		return nil, err
+	}
+
+	u, err := user.Current()
+	if err != nil {
+		return nil, fmt.Errorf("could not get current user: %w", err)
+	}
+
+	e, err = store.CreateOrUpdateNote(model.NewEntryForTime(day, u.Username, cfg))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create new entry: %w", err)
+	}
+	return e, nil
+}
@@ -2,11 +2,14 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"os/user"
 
 	"github.com/byxorna/jot/pkg/model"
+	"github.com/byxorna/jot/pkg/plugins/fs"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 )
@@ -15,25 +18,49 @@ var (
 	flags = struct {
 		ConfigFile   string
 		UseAltScreen bool
+		Notebook     string
 	}{}
 
 	root = &cobra.Command{
 		Use:   "jot",
 		Short: "Jot is a terminal based organizational tool",
 		Args:  cobra.MaximumNArgs(0),
+		// errors are reported by Execute
+		SilenceErrors: true,
+		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var err error
 
+			// anything piped into jot is captured into today's entry
+			if stdinPiped() {
+				content, err := model.ReadStdin()
+				if err != nil {
+					return fmt.Errorf("unable to capture stdin: %w", err)
+				}
+				return captureContent(content)
+			}
+
 			user, err := user.Current()
 			if err != nil {
 				return fmt.Errorf("could not get current user: %w", err)
 			}
 
-			m, err := model.NewFromConfigFile(context.TODO(), flags.ConfigFile, user.Name, flags.UseAltScreen)
+			m, err := model.NewFromConfigFile(cmd.Context(), flags.ConfigFile, user.Name, flags.UseAltScreen)
 			if err != nil {
+				var loadErr *fs.LoadError
+				if errors.As(err, &loadErr) {
+					return fmt.Errorf("unable to create program: %w\nrun `jot doctor` to find and fix broken notes", err)
+				}
 				return fmt.Errorf("unable to create program: %w", err)
 			}
 
+			// commits are pushed in the background, so wait for them on the way out
+			defer func() {
+				if err := m.Close(); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+			}()
+
 			if !m.UseAltScreen {
 				return tea.NewProgram(m).Start()
 			}
@@ -46,12 +73,25 @@ var (
 func init() {
 	root.PersistentFlags().StringVarP(&flags.ConfigFile, "config", "c", "~/.jot.yaml", "configuration file")
 	root.PersistentFlags().BoolVar(&flags.UseAltScreen, "use-alt-screen", true, "use terminal alternate screen buffer")
+	root.PersistentFlags().StringVar(&flags.Notebook, "notebook", "", "notes section to use, defaults to the first")
 }
 
 func Execute() {
-	err := root.Execute()
+	// requests still in flight are given up on when interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	args := os.Args[1:]
+	if c, _, err := root.Find(args); err == nil && c == add {
+		args = listItemArgs(args)
+	}
+	root.SetArgs(args)
+	err := root.ExecuteContext(ctx)
+	closeNotebooks()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		if !errors.Is(err, errNoMatches) {
+			fmt.Fprintln(os.Stderr, err)
+		}
 		os.Exit(1)
 	}
 }
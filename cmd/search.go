@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/text"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// errNoMatches makes jot exit 1 without printing anything, like grep
+	errNoMatches = errors.New("no matches found")
+
+	searchFlags = struct {
+		JSON     bool
+		Limit    int
+		Sections []string
+	}{}
+
+	search = &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search documents in all sections",
+		Long: `Search documents in all sections, printing the matching context of each hit.
+Exits with status 1 if nothing matches.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := strings.Join(args, " ")
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			sections, err := openSections(cmd.Context(), cfg, searchFlags.Sections...)
+			if err != nil {
+				return err
+			}
+
+			hits := []searchHit{}
+		SECTIONS:
+			for _, sec := range sections {
+				ctx, cancel := context.WithTimeout(cmd.Context(), sec.Timeout())
+				docs, err := db.Searchable(ctx, sec.Backend())
+				cancel()
+				if err != nil {
+					return fmt.Errorf("unable to list %s: %w", sec.Identifier(), err)
+				}
+				sort.Stable(db.DocsByModified(docs))
+
+				for _, d := range docs {
+					if !d.MatchesFilter(query) {
+						continue
+					}
+					hits = append(hits, searchHit{
+						docListing: newDocListing(sec.Identifier(), d),
+						Match:      text.GetClosestMatchContextLine(d.UnformattedContent(), query),
+					})
+					if searchFlags.Limit > 0 && len(hits) >= searchFlags.Limit {
+						break SECTIONS
+					}
+				}
+			}
+
+			if searchFlags.JSON {
+				if err := encode(os.Stdout, outputJSON, hits); err != nil {
+					return err
+				}
+			} else {
+				w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+				for _, h := range hits {
+					fmt.Fprintf(w, "%s/%s\t%s\t%s\n", h.Section, h.ID, h.Title, h.Match)
+				}
+				if err := w.Flush(); err != nil {
+					return err
+				}
+			}
+
+			if len(hits) == 0 {
+				return errNoMatches
+			}
+			return nil
+		},
+	}
+)
+
+// searchHit is a document matching the query, with the best matching context
+type searchHit struct {
+	docListing `yaml:",inline"`
+	Match      string `json:"match" yaml:"match"`
+}
+
+func init() {
+	search.Flags().BoolVar(&searchFlags.JSON, "json", false, "print hits as json")
+	search.Flags().IntVarP(&searchFlags.Limit, "limit", "l", 0, "maximum number of hits to print (0 for all)")
+	search.Flags().StringSliceVarP(&searchFlags.Sections, "section", "s", nil, "only search these sections")
+	root.AddCommand(search)
+}
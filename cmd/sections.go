@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/byxorna/jot/pkg/config"
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/model"
+	"github.com/byxorna/jot/pkg/plugins/fs"
+	"github.com/byxorna/jot/pkg/text"
+	"github.com/byxorna/jot/pkg/types/v1"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// openSections initializes the backends of the named sections, or all
+// configured sections if none are named. Only the requested sections are
+// initialized, so listing notes never requires authenticating to google.
+func openSections(ctx context.Context, cfg *config.Config, names ...string) ([]model.Section, error) {
+	if len(names) > 0 {
+		c := *cfg
+		c.Sections = nil
+		for _, n := range names {
+			found := false
+			for _, sec := range cfg.Sections {
+				if sec.Name == n {
+					c.Sections = append(c.Sections, sec)
+					found = true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("no section named %q is configured", n)
+			}
+		}
+		cfg = &c
+	}
+
+	sections, err := model.NewSectionsFromConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, sec := range sections {
+		if store, ok := sec.Backend().(*fs.Store); ok {
+			if err := opened(store); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return sections, nil
+}
+
+// sectionNames returns the names of the configured sections using plugin
+func sectionNames(cfg *config.Config, plugin config.PluginType) []string {
+	names := []string{}
+	for _, sec := range cfg.Sections {
+		if sec.Plugin == plugin {
+			names = append(names, sec.Name)
+		}
+	}
+	return names
+}
+
+// taskStatus is the task completion of a document
+type taskStatus struct {
+	Checked int     `json:"checked" yaml:"checked"`
+	Total   int     `json:"total" yaml:"total"`
+	Percent float64 `json:"percent" yaml:"percent"`
+}
+
+// docListing is the serialized form of a db.Doc for scripting
+type docListing struct {
+	Section  string            `json:"section" yaml:"section"`
+	ID       string            `json:"id" yaml:"id"`
+	Type     string            `json:"type" yaml:"type"`
+	Title    string            `json:"title" yaml:"title"`
+	Summary  string            `json:"summary" yaml:"summary"`
+	Created  time.Time         `json:"created" yaml:"created"`
+	Modified *time.Time        `json:"modified,omitempty" yaml:"modified,omitempty"`
+	Tags     []string          `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Tasks    *taskStatus       `json:"tasks,omitempty" yaml:"tasks,omitempty"`
+}
+
+func newDocListing(section string, d db.Doc) docListing {
+	l := docListing{
+		Section:  section,
+		ID:       d.Identifier().String(),
+		Type:     d.DocType().String(),
+		Title:    d.Title(),
+		Summary:  d.Summary(),
+		Created:  d.Created(),
+		Modified: d.Modified(),
+		Tags:     d.SelectorTags(),
+		Labels:   d.SelectorLabels(),
+	}
+
+	tls := v1.TaskList(d.UnformattedContent())
+	if e, ok := d.(*v1.Note); ok {
+		tls = e.TaskList()
+	}
+	if tls.Total > 0 {
+		l.Tasks = &taskStatus{Checked: tls.Checked, Total: tls.Total, Percent: tls.Percent()}
+	}
+	return l
+}
+
+// tasksColumn formats the task status for a table
+func (l docListing) tasksColumn() string {
+	if l.Tasks == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d", l.Tasks.Checked, l.Tasks.Total)
+}
+
+// modifiedColumn formats when the document was last modified for a table
+func (l docListing) modifiedColumn() string {
+	if l.Modified == nil {
+		return "-"
+	}
+	return l.Modified.Local().Format("2006-01-02 15:04")
+}
+
+// labelsColumn formats the labels for a table, sorted by key
+func (l docListing) labelsColumn() string {
+	labels := make([]string, 0, len(l.Labels))
+	for k, v := range l.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	return strings.Join(labels, ",")
+}
+
+// summaryColumn formats the summary on a single line of at most width for a
+// table
+func (l docListing) summaryColumn(width uint) string {
+	return text.TruncateWithTail(strings.Join(strings.Fields(l.Summary), " "), width, "…")
+}
+
+// encode writes v to w as JSON or YAML
+func encode(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case outputYAML:
+		enc := yaml.NewEncoder(w)
+		defer enc.Close()
+		return enc.Encode(v)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
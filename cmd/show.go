@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/byxorna/jot/pkg/config"
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/model"
+	"github.com/byxorna/jot/pkg/plugins/fs"
+	"github.com/byxorna/jot/pkg/types"
+	"github.com/byxorna/jot/pkg/types/v1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	showFlags = struct {
+		Raw   bool
+		Width int
+	}{}
+
+	show = &cobra.Command{
+		Use:   "show <date|id|section/id>",
+		Short: "Print a document to stdout",
+		Long: `Print a document to stdout, rendered for the terminal. When stdout is not a terminal,
+the unformatted markdown is printed instead.
+
+  jot show yesterday
+  jot show 2021-06-22 --raw
+  jot show notes/1624392613`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			doc, err := resolveDoc(cmd.Context(), cfg, args[0])
+			if err != nil {
+				return err
+			}
+
+			if showFlags.Raw || !isTerminal(os.Stdout) {
+				fmt.Println(strings.TrimRight(doc.UnformattedContent(), "\n"))
+				return nil
+			}
+
+			out, err := model.RenderMarkdown(doc.UnformattedContent(), showFlags.Width)
+			if err != nil {
+				return fmt.Errorf("unable to render %s: %w", doc.Identifier(), err)
+			}
+			fmt.Print(out)
+			return nil
+		},
+	}
+)
+
+// dayGetter is satisfied by backends that store an entry per day
+type dayGetter interface {
+	GetByDay(time.Time) (*v1.Note, error)
+}
+
+// resolveDoc finds the document referred to by ref, which is either a day
+// (i.e. 2021-06-22, today), a document ID, or a section/ID pair
+func resolveDoc(ctx context.Context, cfg *config.Config, ref string) (db.Doc, error) {
+	// section/id
+	if i := strings.Index(ref, "/"); i > 0 {
+		for _, sec := range cfg.Sections {
+			if sec.Name != ref[:i] {
+				continue
+			}
+			sections, err := openSections(ctx, cfg, sec.Name)
+			if err != nil {
+				return nil, err
+			}
+			ctx, cancel := context.WithTimeout(ctx, sections[0].Timeout())
+			defer cancel()
+			return sections[0].Get(ctx, types.DocIdentifier(ref[i+1:]), false)
+		}
+	}
+
+	// a day
+	if day, err := parseDay(ref); err == nil {
+		sections, err := openSections(ctx, cfg, sectionNames(cfg, config.PluginTypeNotes)...)
+		if err != nil {
+			return nil, err
+		}
+		for _, sec := range sections {
+			if dg, ok := sec.Backend().(dayGetter); ok {
+				e, err := dg.GetByDay(day)
+				if err == nil {
+					return e, nil
+				}
+				if errors.Is(err, fs.ErrLocked) {
+					return nil, err
+				}
+			}
+		}
+		return nil, fmt.Errorf("no entry found for %s", day.Format(dayFormat))
+	}
+
+	// an ID in any section
+	sections, err := openSections(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, sec := range sections {
+		ctx, cancel := context.WithTimeout(ctx, sec.Timeout())
+		d, err := sec.Get(ctx, types.DocIdentifier(ref), false)
+		cancel()
+		if err == nil {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("no document found for %s: %w", ref, db.ErrNoNoteFound)
+}
+
+// isTerminal returns whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+func init() {
+	show.Flags().BoolVar(&showFlags.Raw, "raw", false, "print the unformatted markdown")
+	show.Flags().IntVarP(&showFlags.Width, "width", "w", 80, "word wrap rendered output at this width")
+	root.AddCommand(show)
+}
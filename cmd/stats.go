@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/byxorna/jot/pkg/stats"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsFlags = struct {
+		JSON   bool
+		Days   int
+		Weeks  int
+		Months int
+	}{}
+
+	statsCmd = &cobra.Command{
+		Use:   "stats",
+		Short: "Report task completion and journaling activity over time",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			store, err := openNotes(cfg)
+			if err != nil {
+				return err
+			}
+
+			notes, err := store.ListAll()
+			if err != nil {
+				return fmt.Errorf("unable to list notes: %w", err)
+			}
+
+			report := stats.New(notes, time.Now(), stats.Options{
+				Days:   statsFlags.Days,
+				Weeks:  statsFlags.Weeks,
+				Months: statsFlags.Months,
+			})
+			if statsFlags.JSON {
+				return encode(os.Stdout, outputJSON, report)
+			}
+			return report.Render(os.Stdout)
+		},
+	}
+)
+
+func init() {
+	statsCmd.Flags().BoolVar(&statsFlags.JSON, "json", false, "print the report as json")
+	statsCmd.Flags().IntVar(&statsFlags.Days, "days", stats.DefaultOptions.Days, "number of days to chart")
+	statsCmd.Flags().IntVar(&statsFlags.Weeks, "weeks", stats.DefaultOptions.Weeks, "number of weeks to chart")
+	statsCmd.Flags().IntVar(&statsFlags.Months, "months", stats.DefaultOptions.Months, "number of months to chart")
+	root.AddCommand(statsCmd)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/byxorna/jot/pkg/text"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusFlags = struct {
+		Output string
+	}{}
+
+	statusCmd = &cobra.Command{
+		Use:   "status [section...]",
+		Short: "Sync every section, or the named sections, and report how it went",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			sections, err := openSections(cmd.Context(), cfg, args...)
+			if err != nil {
+				return err
+			}
+
+			statuses := []sectionStatus{}
+			for _, sec := range sections {
+				s := sectionStatus{Section: sec.Identifier()}
+				if sched := sec.Scheduler(); sched != nil {
+					// a failure is reported with the status
+					_ = sched.Refresh(cmd.Context())
+					st := sched.State()
+					s.Status = string(st.Status)
+					s.Items = st.Count
+					if !st.LastSuccess.IsZero() {
+						s.LastSync = &st.LastSuccess
+					}
+					if st.LastError != nil {
+						s.Error = st.LastError.Error()
+					}
+				} else {
+					ctx, cancel := context.WithTimeout(cmd.Context(), sec.Timeout())
+					docs, err := sec.List(ctx)
+					cancel()
+					s.Status = string(sec.Backend().Status())
+					s.Items = len(docs)
+					if err != nil {
+						s.Error = err.Error()
+					} else {
+						now := time.Now()
+						s.LastSync = &now
+					}
+				}
+				statuses = append(statuses, s)
+			}
+
+			if statusFlags.Output != outputTable {
+				return encode(os.Stdout, statusFlags.Output, statuses)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "SECTION\tSTATUS\tITEMS\tLAST SYNC\tERROR")
+			for _, s := range statuses {
+				lastSync := "-"
+				if s.LastSync != nil {
+					lastSync = text.RelativeTime(*s.LastSync)
+				}
+				errColumn := "-"
+				if s.Error != "" {
+					errColumn = s.Error
+				}
+				fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", s.Section, s.Status, s.Items, lastSync, errColumn)
+			}
+			return w.Flush()
+		},
+	}
+)
+
+// sectionStatus is how syncing a section went, for scripting
+type sectionStatus struct {
+	Section  string     `json:"section" yaml:"section"`
+	Status   string     `json:"status" yaml:"status"`
+	Items    int        `json:"items" yaml:"items"`
+	LastSync *time.Time `json:"lastSync,omitempty" yaml:"lastSync,omitempty"`
+	Error    string     `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func init() {
+	statusCmd.Flags().StringVarP(&statusFlags.Output, "output", "o", outputTable, "output format (table, json, yaml)")
+	root.AddCommand(statusCmd)
+}
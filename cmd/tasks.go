@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/byxorna/jot/pkg/model"
+	"github.com/byxorna/jot/pkg/plugins/fs"
+	"github.com/byxorna/jot/pkg/types/v1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	toggleFlags = struct {
+		Match string
+		Date  string
+	}{}
+
+	tasks = &cobra.Command{
+		Use:   "tasks [date]",
+		Short: "List the tasks of an entry with their numbers",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var date string
+			if len(args) > 0 {
+				date = args[0]
+			}
+
+			_, e, err := loadEntry(date)
+			if err != nil {
+				return err
+			}
+
+			for i, t := range v1.Tasks(e.Content) {
+				fmt.Printf("%3d %s%s %s\n", i+1, t.Indent, taskMarker(t.Checked), t.Text)
+			}
+			return nil
+		},
+	}
+
+	done = &cobra.Command{
+		Use:   "done [task number...]",
+		Short: "Check off tasks of an entry",
+		Long: `Check off tasks of an entry, by the numbers listed by jot tasks or matching text.
+
+  jot done 3
+  jot done --match deploy --date yesterday`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return toggleTasks(args, true)
+		},
+	}
+
+	undo = &cobra.Command{
+		Use:   "undo [task number...]",
+		Short: "Uncheck tasks of an entry",
+		Long: `Uncheck tasks of an entry, by the numbers listed by jot tasks or matching text.
+
+  jot undo 3`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return toggleTasks(args, false)
+		},
+	}
+)
+
+func taskMarker(checked bool) string {
+	if checked {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+// loadEntry loads the existing entry for the given day
+func loadEntry(date string) (*fs.Store, *v1.Note, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	day, err := parseDay(date)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	store, err := openNotes(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e, err := store.GetByDay(day)
+	if err != nil {
+		return nil, nil, fmt.Errorf("no entry found for %s: %w", day.Format(dayFormat), err)
+	}
+	return store, e, nil
+}
+
+// toggleTasks checks or unchecks the tasks selected by number or toggleFlags.Match
+func toggleTasks(args []string, checked bool) error {
+	if len(args) == 0 && toggleFlags.Match == "" {
+		return fmt.Errorf("provide task numbers or --match")
+	}
+
+	store, e, err := loadEntry(toggleFlags.Date)
+	if err != nil {
+		return err
+	}
+
+	all := v1.Tasks(e.Content)
+	selected := []v1.Task{}
+	for _, a := range args {
+		n, err := strconv.Atoi(a)
+		if err != nil || n < 1 || n > len(all) {
+			return fmt.Errorf("no task %s, expected a number from 1 to %d", a, len(all))
+		}
+		selected = append(selected, all[n-1])
+	}
+	if toggleFlags.Match != "" {
+		for _, t := range all {
+			if t.Checked != checked && strings.Contains(strings.ToLower(t.Text), strings.ToLower(toggleFlags.Match)) {
+				selected = append(selected, t)
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("no tasks to toggle match %q", toggleFlags.Match)
+		}
+	}
+
+	old := e.Content
+	content := e.Content
+	for _, t := range selected {
+		content, err = v1.SetTaskChecked(content, t.Line, checked)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s %s\n", taskMarker(checked), t.Text)
+	}
+
+	e.Content = content
+	if _, err := store.CreateOrUpdateNote(e); err != nil {
+		return err
+	}
+
+	if delta := model.TaskDeltaMessage(old, content); delta != "" {
+		fmt.Println(delta)
+	}
+	return nil
+}
+
+func init() {
+	for _, c := range []*cobra.Command{done, undo} {
+		c.Flags().StringVarP(&toggleFlags.Match, "match", "m", "", "toggle every task containing this text")
+		c.Flags().StringVarP(&toggleFlags.Date, "date", "d", "", "day of the entry (YYYY-MM-DD, today, yesterday)")
+	}
+	root.AddCommand(tasks, done, undo)
+}
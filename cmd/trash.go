@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	trashFlags = struct {
+		Yes bool
+	}{}
+
+	trash = &cobra.Command{
+		Use:   "trash",
+		Short: "List, restore or permanently delete notes deleted from the notebook",
+		Long: `Notes deleted with x in the UI are moved to the .trash directory of their notebook, from
+where they can be restored until the trash is emptied.`,
+	}
+
+	trashList = &cobra.Command{
+		Use:   "list",
+		Short: "List the notes in the trash",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			store, err := openNotes(cfg)
+			if err != nil {
+				return err
+			}
+
+			trashed, err := store.Trash()
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "FILE\tCREATED\tTITLE")
+			for _, t := range trashed {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", t.File, t.Note.Metadata.CreationTimestamp.Local().Format("2006-01-02 15:04"), t.Note.Metadata.Title)
+			}
+			return w.Flush()
+		},
+	}
+
+	trashRestore = &cobra.Command{
+		Use:   "restore <file...>",
+		Short: "Move notes out of the trash, back to where they were deleted from",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			store, err := openNotes(cfg)
+			if err != nil {
+				return err
+			}
+
+			for _, file := range args {
+				e, err := store.Restore(file)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s: restored\n", store.StoragePathDoc(e.Identifier()))
+			}
+			return nil
+		},
+	}
+
+	trashEmpty = &cobra.Command{
+		Use:   "empty",
+		Short: "Permanently delete the notes in the trash",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			store, err := openNotes(cfg)
+			if err != nil {
+				return err
+			}
+
+			if !trashFlags.Yes {
+				trashed, err := store.Trash()
+				if err != nil {
+					return err
+				}
+				return fmt.Errorf("this permanently deletes %d notes, run again with --yes to empty the trash", len(trashed))
+			}
+
+			n, err := store.EmptyTrash()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%d notes permanently deleted\n", n)
+			return nil
+		},
+	}
+)
+
+func init() {
+	trashEmpty.Flags().BoolVarP(&trashFlags.Yes, "yes", "y", false, "confirm permanently deleting the notes")
+	trash.AddCommand(trashList, trashRestore, trashEmpty)
+	root.AddCommand(trash)
+}
@@ -3,7 +3,8 @@ module github.com/byxorna/jot
 go 1.16
 
 require (
-	github.com/adrg/xdg v0.3.3 // indirect
+	github.com/BurntSushi/toml v0.3.1
+	github.com/adrg/xdg v0.3.3
 	github.com/charmbracelet/bubbles v0.8.0
 	github.com/charmbracelet/bubbletea v0.14.0
 	github.com/charmbracelet/charm v0.8.6
@@ -26,10 +27,13 @@ require (
 	github.com/rickar/cal/v2 v2.0.1
 	github.com/sahilm/fuzzy v0.1.0
 	github.com/spf13/cobra v1.1.3
+	github.com/spf13/pflag v1.0.5
 	github.com/voicera/gooseberry v0.0.0-20181223025147-dc233900870c // indirect
-	golang.org/x/oauth2 v0.0.0-20210628180205-a41e5a781914 // indirect
+	golang.org/x/crypto v0.0.0-20210616213533-5ff15b29337e
+	golang.org/x/oauth2 v0.0.0-20210628180205-a41e5a781914
+	golang.org/x/term v0.0.0-20210615171337-6886f2dfbf5b
 	golang.org/x/text v0.3.6
-	google.golang.org/api v0.50.0 // indirect
+	google.golang.org/api v0.50.0
 	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
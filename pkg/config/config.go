@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"time"
 
 	"github.com/adrg/xdg"
 	"github.com/go-playground/validator"
+	"github.com/mitchellh/go-homedir"
 	"gopkg.in/yaml.v3"
 )
 
@@ -17,6 +19,13 @@ const (
 )
 
 var (
+	// DefaultTimeout is how long requests to the backend of a section may take
+	// when its timeout is not configured
+	DefaultTimeout = 30 * time.Second
+	// DefaultRefresh is how often sections fetched from elsewhere are
+	// refreshed when their refresh interval is not configured
+	DefaultRefresh = 10 * time.Minute
+
 	// DefaultEntryTemplate is the default value for a new entry's content
 	//go:embed default_entry_template.md
 	DefaultEntryTemplate string
@@ -48,7 +57,7 @@ type Config struct {
 	HolidayTags    []string      `yaml:"holidayTags" validate:"unique"`
 	StartWorkHours time.Duration `yaml:"startWorkHours" validate:"required"`
 	EndWorkHours   time.Duration `yaml:"endWorkHours" validate:"required"`
-	Sections       []Section     `yaml:"sections" validate:"required,unique=name"`
+	Sections       []Section     `yaml:"sections" validate:"required,unique=Name,dive"`
 	EntryTemplate  string        `yaml:"entry_template" validate:""`
 }
 
@@ -58,15 +67,47 @@ const (
 	PluginTypeNotes    PluginType = "notes"
 	PluginTypeCalendar PluginType = "calendar"
 	PluginTypeKeep     PluginType = "keep"
+	// PluginTypeScratch sections keep their notes in memory, until jot quits
+	PluginTypeScratch PluginType = "scratch"
+	// PluginTypeEverything sections merge the documents of every other
+	// section, newest first
+	PluginTypeEverything PluginType = "everything"
 )
 
 // Section is a "tab" of the application. This defines how a given section's plugin
 // is configured, if at all
 type Section struct {
-	Name     string            `yaml:"name" validate:"required"`
+	// Name identifies the section, and the documents of the section in an
+	// everything section, so it must not contain a /
+	Name     string            `yaml:"name" validate:"required,excludesall=/"`
 	Plugin   PluginType        `yaml:"plugin" validate:"required"`
 	Settings map[string]string `yaml:"settings,omitempty" validate:""`
 	Features []string          `yaml:"features,omitempty" validate:"unique"`
+	// Timeout is how long a request to the backend of the section may take
+	// before it is given up on, or DefaultTimeout if unset
+	Timeout time.Duration `yaml:"timeout,omitempty" validate:"min=0"`
+	// Refresh is how often the documents of sections fetched from elsewhere,
+	// like calendars, are refreshed in the background, or DefaultRefresh if
+	// unset
+	Refresh time.Duration `yaml:"refresh,omitempty" validate:"min=0"`
+}
+
+// RefreshInterval returns how often the documents of the section are
+// refreshed in the background, if they are fetched from elsewhere
+func (s Section) RefreshInterval() time.Duration {
+	if s.Refresh > 0 {
+		return s.Refresh
+	}
+	return DefaultRefresh
+}
+
+// RequestTimeout returns how long a request to the backend of the section may
+// take
+func (s Section) RequestTimeout() time.Duration {
+	if s.Timeout > 0 {
+		return s.Timeout
+	}
+	return DefaultTimeout
 }
 
 func NewFromReader(r io.Reader) (*Config, error) {
@@ -90,6 +131,25 @@ func NewFromReader(r io.Reader) (*Config, error) {
 	return &c, nil
 }
 
+// NewFromFile loads the configuration at path. If the file is missing, the
+// Default configuration is returned
+func NewFromFile(path string) (*Config, error) {
+	expandedPath, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(expandedPath)
+	if err != nil {
+		// if the file is missing, ignore and use the default config
+		c := Default
+		return &c, nil
+	}
+	defer f.Close()
+
+	return NewFromReader(f)
+}
+
 func RuntimeFile(filename string) (string, error) {
 	return xdg.RuntimeFile(fmt.Sprintf("%s/%s", XDGName, filename))
 }
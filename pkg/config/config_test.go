@@ -0,0 +1,27 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewFromReader(t *testing.T) {
+	c, err := NewFromReader(strings.NewReader("sections:\n- name: work\n  plugin: notes\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Sections) != 1 || c.Sections[0].Name != "work" {
+		t.Errorf("expected the work section but got %+v", c.Sections)
+	}
+
+	for name, yaml := range map[string]string{
+		"a name with a /":  "sections:\n- name: work/x\n  plugin: notes\n",
+		"a missing plugin": "sections:\n- name: work\n",
+		"a timeout < 0":    "sections:\n- name: work\n  plugin: notes\n  timeout: -1s\n",
+		"the same name":    "sections:\n- name: work\n  plugin: notes\n- name: work\n  plugin: scratch\n",
+	} {
+		if _, err := NewFromReader(strings.NewReader(yaml)); err == nil {
+			t.Errorf("expected a section with %s to be rejected", name)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/byxorna/jot/pkg/types"
@@ -11,6 +12,19 @@ var (
 	ErrNoNoteFound = fmt.Errorf("no note found")
 	ErrNoNextNote  = fmt.Errorf("no next note found")
 	ErrNoPrevNote  = fmt.Errorf("no previous note found")
+	ErrReadOnly    = fmt.Errorf("backend is read only")
+)
+
+// Capability is a kind of write a backend supports
+type Capability int
+
+const (
+	CanCreate Capability = 1 << iota
+	CanUpdate
+	CanDelete
+
+	// ReadOnly backends can only list and get documents
+	ReadOnly Capability = 0
 )
 
 // DB is the interface any plugin satisfies to provide a backend
@@ -30,23 +44,78 @@ type DONOTUSE_DB interface {
 	DocBackend
 }
 
+// DocBackendRead reads the documents of a backend. Backends that make requests
+// over the network give up on them when ctx is done, and every backend fails
+// with the error of ctx if it is done before they start.
 type DocBackendRead interface {
-	List() ([]Doc, error)
+	List(ctx context.Context) ([]Doc, error)
+	// Count is how many documents were last listed, without listing them again
 	Count() int
-	Get(id types.DocIdentifier, hardread bool) (Doc, error)
+	Get(ctx context.Context, id types.DocIdentifier, hardread bool) (Doc, error)
 	// TODO: remove Reconcile, it is the same as hard get
 	//Reconcile(id types.DocIdentifier) (Doc, error)
 }
 
+// DocBackendWrite changes the documents of a backend. Writes a backend does not
+// support, as told by Capabilities, fail with ErrReadOnly.
 type DocBackendWrite interface {
+	Capabilities() Capability
+	// Create stores a new document, returning it as stored
+	Create(ctx context.Context, d Doc) (Doc, error)
+	// Update stores a changed document, returning it as stored
+	Update(ctx context.Context, d Doc) (Doc, error)
+	Delete(ctx context.Context, id types.DocIdentifier) error
+}
+
+// Can returns whether the backend supports every write in c
+func Can(b DocBackend, c Capability) bool {
+	return c != ReadOnly && b.Capabilities()&c == c
+}
+
+// DocBackendArchive is implemented by backends that leave archived documents
+// out of List, so they can still be searched
+type DocBackendArchive interface {
+	ListArchived() ([]Doc, error)
+}
+
+// DocBackendLazy is implemented by backends that list documents before reading
+// their content. Get reads the content of a document, and LoadAll of every
+// document.
+type DocBackendLazy interface {
+	LoadAll() error
+}
+
+// Searchable returns the documents of the backend including those that are
+// archived, with their content read, for searching
+func Searchable(ctx context.Context, b DocBackend) ([]Doc, error) {
+	if l, ok := b.(DocBackendLazy); ok {
+		if err := l.LoadAll(); err != nil {
+			return nil, err
+		}
+	}
+	docs, err := b.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a, ok := b.(DocBackendArchive); ok {
+		archived, err := a.ListArchived()
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, archived...)
+	}
+	return docs, nil
 }
 
 type DocBackend interface { // fs.Store implements this
 	DocBackendRead
 	DocBackendWrite
+	DocBackendWatch
 
 	DocType() types.DocType
 	Status() v1.SyncStatus
 	StoragePath() string
+	// StoragePathDoc is the file the document is stored in, or empty if it is
+	// not stored in one
 	StoragePathDoc(id types.DocIdentifier) string
 }
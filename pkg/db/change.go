@@ -0,0 +1,161 @@
+package db
+
+import (
+	"sync"
+
+	"github.com/byxorna/jot/pkg/types"
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+var (
+	// WatchBuffer is how many changes a watcher can fall behind before
+	// changes are dropped, and ChangesLost is sent instead
+	WatchBuffer = 64
+)
+
+// ChangeKind is what changed in a backend
+type ChangeKind string
+
+const (
+	DocAdded   ChangeKind = "added"
+	DocUpdated ChangeKind = "updated"
+	DocRemoved ChangeKind = "removed"
+	// DocConflicted is a copy of the document saved aside, as a change to it
+	// could not be merged. The document itself did not change.
+	DocConflicted ChangeKind = "conflicted"
+	// StatusChanged is a change of the status of the backend, or a failure
+	// to sync it
+	StatusChanged ChangeKind = "status"
+	// ChangesLost is sent in place of the changes dropped as a watcher fell
+	// behind. Which documents changed is not known, so they are to be listed
+	// again.
+	ChangesLost ChangeKind = "lost"
+)
+
+// Change is a document of a backend that was added, updated or removed, or a
+// change to the status of the backend
+type Change struct {
+	Kind ChangeKind
+	// ID is the document that changed, unless the status did
+	ID types.DocIdentifier
+	// Status is the status of the backend after the change
+	Status v1.SyncStatus
+	// Err is why the backend could not be synced, if it could not
+	Err error
+}
+
+// DocBackendWatch is implemented by every backend, so the documents shown
+// can be kept up to date without listing them again. The channel is closed
+// when the backend is closed.
+type DocBackendWatch interface {
+	Watch() <-chan Change
+}
+
+// Watchers fans the changes of a backend out to everything watching it.
+// Changes are dropped rather than blocking the backend if a watcher falls
+// behind, and the watcher is sent ChangesLost once. The zero value is ready
+// to use.
+type Watchers struct {
+	mu       sync.Mutex
+	watchers []*watcher
+	closed   bool
+}
+
+type watcher struct {
+	// ch has room for ChangesLost past WatchBuffer
+	ch chan Change
+	// lost is whether ChangesLost was sent, and not received yet
+	lost bool
+}
+
+// send sends the change, or ChangesLost if the watcher fell behind
+func (w *watcher) send(c Change) {
+	if w.lost && len(w.ch) == 0 {
+		// ChangesLost was received, so the changes are listed again
+		w.lost = false
+	}
+	switch {
+	case w.lost:
+		// the change is listed with the others lost
+	case len(w.ch) < WatchBuffer:
+		w.ch <- c
+	default:
+		w.ch <- Change{Kind: ChangesLost}
+		w.lost = true
+	}
+}
+
+// Watch returns a channel of the changes published from now on
+func (w *Watchers) Watch() <-chan Change {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ch := make(chan Change, WatchBuffer+1)
+	if w.closed {
+		close(ch)
+		return ch
+	}
+	w.watchers = append(w.watchers, &watcher{ch: ch})
+	return ch
+}
+
+// Publish sends the changes to every watcher
+func (w *Watchers) Publish(changes ...Change) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, c := range changes {
+		for _, watcher := range w.watchers {
+			watcher.send(c)
+		}
+	}
+}
+
+// Close closes the channels of every watcher. Changes published after are
+// dropped.
+func (w *Watchers) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, watcher := range w.watchers {
+		close(watcher.ch)
+	}
+	w.watchers = nil
+	w.closed = true
+}
+
+// Diff returns the changes from the documents in old to those in current,
+// for backends that refresh all of their documents at once
+func Diff(old, current []Doc) []Change {
+	before := map[types.DocIdentifier]Doc{}
+	for _, d := range old {
+		before[d.Identifier()] = d
+	}
+
+	changes := []Change{}
+	for _, d := range current {
+		id := d.Identifier()
+		o, ok := before[id]
+		delete(before, id)
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: DocAdded, ID: id})
+		case !sameModified(o, d) || o.UnformattedContent() != d.UnformattedContent():
+			changes = append(changes, Change{Kind: DocUpdated, ID: id})
+		}
+	}
+	for _, d := range old {
+		if _, ok := before[d.Identifier()]; ok {
+			changes = append(changes, Change{Kind: DocRemoved, ID: d.Identifier()})
+		}
+	}
+	return changes
+}
+
+func sameModified(a, b Doc) bool {
+	ma, mb := a.Modified(), b.Modified()
+	if ma == nil || mb == nil {
+		return ma == mb
+	}
+	return ma.Equal(*mb)
+}
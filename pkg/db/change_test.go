@@ -0,0 +1,40 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/byxorna/jot/pkg/types"
+)
+
+func TestWatchersLoseChanges(t *testing.T) {
+	w := &Watchers{}
+	ch := w.Watch()
+
+	changes := []Change{}
+	for i := 0; i < WatchBuffer+10; i++ {
+		changes = append(changes, Change{Kind: DocAdded, ID: types.DocIdentifier(string(rune('a' + i%26)))})
+	}
+	w.Publish(changes...)
+	if n := len(ch); n != WatchBuffer+1 {
+		t.Fatalf("expected %d changes and ChangesLost but got %d", WatchBuffer, n)
+	}
+	for i := 0; i < WatchBuffer; i++ {
+		if c := <-ch; c.Kind != DocAdded {
+			t.Fatalf("expected change %d to be added but got %s", i, c.Kind)
+		}
+	}
+	if c := <-ch; c.Kind != ChangesLost {
+		t.Fatalf("expected the changes dropped to be told but got %s", c.Kind)
+	}
+
+	// changes after ChangesLost was received are sent again
+	w.Publish(Change{Kind: DocRemoved, ID: "a"})
+	if c := <-ch; c.Kind != DocRemoved || c.ID != "a" {
+		t.Errorf("expected a to be removed but got %+v", c)
+	}
+
+	w.Close()
+	if _, ok := <-ch; ok {
+		t.Error("expected the channel to be closed")
+	}
+}
@@ -0,0 +1,331 @@
+// Package dbtest tests that a db.DocBackend behaves the way jot expects of
+// every backend, so each implementation can run the same suite:
+//
+//	func TestConformance(t *testing.T) {
+//		dbtest.Run(t, dbtest.Harness{New: newStore, Doc: newNote})
+//	}
+package dbtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/types"
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+var (
+	// Concurrency is how many goroutines use a backend at once in the
+	// concurrent access test, which is best run with -race
+	Concurrency = 8
+	// WatchTimeout is how long a change is waited for after a write
+	WatchTimeout = 5 * time.Second
+)
+
+// Harness is how the suite makes the backends it tests
+type Harness struct {
+	// New returns a backend holding exactly the documents given, which were
+	// returned by Doc
+	New func(t *testing.T, docs ...db.Doc) db.DocBackend
+	// Doc returns a new document the backend can store. Documents returned
+	// for different i differ in title, content and creation time.
+	Doc func(i int) db.Doc
+	// Edit returns the document with different content, to test Update with.
+	// Backends that cannot update documents can leave it nil.
+	Edit func(d db.Doc) db.Doc
+}
+
+// seeded is how many documents the backends are made with
+const seeded = 5
+
+// Run runs the suite against the backends made by the harness
+func Run(t *testing.T, h Harness) {
+	t.Run("ListCountGet", func(t *testing.T) { testListCountGet(t, h) })
+	t.Run("Ordering", func(t *testing.T) { testOrdering(t, h) })
+	t.Run("Cancelled", func(t *testing.T) { testCancelled(t, h) })
+	t.Run("Status", func(t *testing.T) { testStatus(t, h) })
+	t.Run("Writes", func(t *testing.T) { testWrites(t, h) })
+	t.Run("Concurrent", func(t *testing.T) { testConcurrent(t, h) })
+}
+
+func docs(h Harness, from, n int) []db.Doc {
+	ds := make([]db.Doc, n)
+	for i := range ds {
+		ds[i] = h.Doc(from + i)
+	}
+	return ds
+}
+
+func list(t *testing.T, b db.DocBackend) []db.Doc {
+	t.Helper()
+	ds, err := b.List(context.Background())
+	if err != nil {
+		t.Fatalf("unable to list: %v", err)
+	}
+	return ds
+}
+
+func ids(ds []db.Doc) []types.DocIdentifier {
+	l := make([]types.DocIdentifier, len(ds))
+	for i, d := range ds {
+		l[i] = d.Identifier()
+	}
+	return l
+}
+
+// testListCountGet tests that every document listed is counted, and can be
+// read by its identifier, whether or not it is read again
+func testListCountGet(t *testing.T, h Harness) {
+	b := h.New(t, docs(h, 0, seeded)...)
+	listed := list(t, b)
+	if len(listed) != seeded {
+		t.Fatalf("expected %d documents to be listed but got %d", seeded, len(listed))
+	}
+	if n := b.Count(); n != len(listed) {
+		t.Errorf("expected the count to be the %d documents listed but got %d", len(listed), n)
+	}
+
+	seen := map[types.DocIdentifier]bool{}
+	for _, d := range listed {
+		id := d.Identifier()
+		if seen[id] {
+			t.Errorf("expected %s to be listed once", id)
+		}
+		seen[id] = true
+
+		for _, hardread := range []bool{false, true} {
+			got, err := b.Get(context.Background(), id, hardread)
+			if err != nil {
+				t.Errorf("unable to get %s (hardread %t): %v", id, hardread, err)
+				continue
+			}
+			if got.Identifier() != id || got.Title() != d.Title() || got.UnformattedContent() != d.UnformattedContent() {
+				t.Errorf("expected %s (hardread %t) to be the document listed, but got %s %q", id, hardread, got.Identifier(), got.Title())
+			}
+		}
+	}
+
+	if _, err := b.Get(context.Background(), "no-such-document", false); err == nil {
+		t.Error("expected getting a document that does not exist to fail")
+	}
+	if _, err := b.Get(context.Background(), "no-such-document", true); err == nil {
+		t.Error("expected reading a document that does not exist to fail")
+	}
+}
+
+// testOrdering tests that listing again lists the same documents, keeping
+// when they were created so they can be sorted
+func testOrdering(t *testing.T, h Harness) {
+	seed := docs(h, 0, seeded)
+	b := h.New(t, seed...)
+
+	first, second := list(t, b), list(t, b)
+	sort.Stable(db.DocsByCreated(first))
+	sort.Stable(db.DocsByCreated(second))
+	if fmt.Sprint(ids(first)) != fmt.Sprint(ids(second)) {
+		t.Errorf("expected listing again to list the same documents, but got %v then %v", ids(first), ids(second))
+	}
+
+	sort.Stable(db.DocsByCreated(seed))
+	for i, d := range first {
+		if !d.Created().Equal(seed[i].Created()) || d.Title() != seed[i].Title() {
+			t.Errorf("expected document %d newest first to be %q created %s, but got %q created %s",
+				i, seed[i].Title(), seed[i].Created(), d.Title(), d.Created())
+		}
+	}
+}
+
+// testCancelled tests that every operation fails with the error of a context
+// done before it starts
+func testCancelled(t *testing.T, h Harness) {
+	b := h.New(t, docs(h, 0, seeded)...)
+	id := list(t, b)[0].Identifier()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := b.List(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected listing to be cancelled but got %v", err)
+	}
+	if _, err := b.Get(ctx, id, false); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected getting %s to be cancelled but got %v", id, err)
+	}
+	if db.Can(b, db.CanCreate) {
+		if _, err := b.Create(ctx, h.Doc(seeded)); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected creating to be cancelled but got %v", err)
+		}
+	}
+	if db.Can(b, db.CanDelete) {
+		if err := b.Delete(ctx, id); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected deleting %s to be cancelled but got %v", id, err)
+		}
+	}
+	if n := len(list(t, b)); n != seeded {
+		t.Errorf("expected the cancelled operations to change nothing, but %d documents are listed", n)
+	}
+}
+
+// testStatus tests that a backend listed without failing is ok
+func testStatus(t *testing.T, h Harness) {
+	b := h.New(t, docs(h, 0, seeded)...)
+	switch s := b.Status(); s {
+	case v1.StatusUninitialized, v1.StatusOK, v1.StatusOffline, v1.StatusSynchronizing, v1.StatusError:
+	default:
+		t.Errorf("unknown status %q", s)
+	}
+	list(t, b)
+	if s := b.Status(); s != v1.StatusOK {
+		t.Errorf("expected the backend to be %s once listed but got %s", v1.StatusOK, s)
+	}
+}
+
+// waitFor waits for a change of kind to the document to be watched
+func waitFor(t *testing.T, changes <-chan db.Change, kind db.ChangeKind, id types.DocIdentifier) {
+	t.Helper()
+	timeout := time.After(WatchTimeout)
+	for {
+		select {
+		case c, ok := <-changes:
+			if !ok {
+				t.Errorf("expected %s to be %s, but watching stopped", id, kind)
+				return
+			}
+			if c.Kind == kind && c.ID == id {
+				return
+			}
+		case <-timeout:
+			t.Errorf("expected %s to be %s within %s", id, kind, WatchTimeout)
+			return
+		}
+	}
+}
+
+// testWrites tests that the writes a backend supports are listed and watched,
+// and that those it does not fail with db.ErrReadOnly
+func testWrites(t *testing.T, h Harness) {
+	b := h.New(t, docs(h, 0, seeded)...)
+	changes := b.Watch()
+	ctx := context.Background()
+
+	var id types.DocIdentifier
+	if !db.Can(b, db.CanCreate) {
+		if _, err := b.Create(ctx, h.Doc(seeded)); !errors.Is(err, db.ErrReadOnly) {
+			t.Errorf("expected creating to fail with %v but got %v", db.ErrReadOnly, err)
+		}
+		id = list(t, b)[0].Identifier()
+	} else {
+		d := h.Doc(seeded)
+		created, err := b.Create(ctx, d)
+		if err != nil {
+			t.Fatalf("unable to create %q: %v", d.Title(), err)
+		}
+		id = created.Identifier()
+		waitFor(t, changes, db.DocAdded, id)
+		if n := len(list(t, b)); n != seeded+1 || b.Count() != n {
+			t.Errorf("expected %d documents once created, but %d are listed and %d counted", seeded+1, n, b.Count())
+		}
+		if got, err := b.Get(ctx, id, false); err != nil || got.Title() != d.Title() {
+			t.Errorf("expected to get %q once created, but got %v", d.Title(), err)
+		}
+	}
+
+	d, err := b.Get(ctx, id, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch {
+	case !db.Can(b, db.CanUpdate):
+		if _, err := b.Update(ctx, d); !errors.Is(err, db.ErrReadOnly) {
+			t.Errorf("expected updating to fail with %v but got %v", db.ErrReadOnly, err)
+		}
+	case h.Edit != nil:
+		edited := h.Edit(d)
+		if _, err := b.Update(ctx, edited); err != nil {
+			t.Fatalf("unable to update %s: %v", id, err)
+		}
+		waitFor(t, changes, db.DocUpdated, id)
+		got, err := b.Get(ctx, id, true)
+		if err != nil || got.UnformattedContent() != edited.UnformattedContent() {
+			t.Errorf("expected to read %s as updated, but got %v", id, err)
+		}
+	}
+
+	if !db.Can(b, db.CanDelete) {
+		if err := b.Delete(ctx, id); !errors.Is(err, db.ErrReadOnly) {
+			t.Errorf("expected deleting to fail with %v but got %v", db.ErrReadOnly, err)
+		}
+		return
+	}
+	before := len(list(t, b))
+	if err := b.Delete(ctx, id); err != nil {
+		t.Fatalf("unable to delete %s: %v", id, err)
+	}
+	waitFor(t, changes, db.DocRemoved, id)
+	if n := len(list(t, b)); n != before-1 || b.Count() != n {
+		t.Errorf("expected %d documents once deleted, but %d are listed and %d counted", before-1, n, b.Count())
+	}
+	if _, err := b.Get(ctx, id, false); err == nil {
+		t.Errorf("expected %s to be gone once deleted", id)
+	}
+}
+
+// testConcurrent uses the backend from several goroutines at once, creating
+// and deleting documents if it can, for the race detector to find unguarded
+// state
+func testConcurrent(t *testing.T, h Harness) {
+	b := h.New(t, docs(h, 0, seeded)...)
+	ctx := context.Background()
+	// only the seeded documents are read, as the others may be deleted by
+	// the time they would be
+	seededIDs := ids(list(t, b))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, Concurrency*4)
+	for g := 0; g < Concurrency; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			if _, err := b.List(ctx); err != nil {
+				errs <- err
+				return
+			}
+			for _, id := range seededIDs {
+				if _, err := b.Get(ctx, id, g%2 == 0); err != nil {
+					errs <- fmt.Errorf("unable to get %s: %w", id, err)
+				}
+			}
+			_ = b.Count()
+			_ = b.Status()
+
+			if !db.Can(b, db.CanCreate) {
+				return
+			}
+			created, err := b.Create(ctx, h.Doc(seeded+1+g))
+			if err != nil {
+				errs <- err
+				return
+			}
+			if db.Can(b, db.CanDelete) {
+				if err := b.Delete(ctx, created.Identifier()); err != nil {
+					errs <- err
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if db.Can(b, db.CanCreate) && db.Can(b, db.CanDelete) {
+		if n := len(list(t, b)); n != seeded {
+			t.Errorf("expected %d documents once every one created was deleted, but %d are listed", seeded, n)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+var (
+	// RefreshJitter is the fraction of the delay before the next refresh that
+	// it is moved earlier or later by at random, so backends refreshed on the
+	// same interval do not all make their requests at once
+	RefreshJitter = 0.1
+	// RetryAfter is how long a refresh that failed is retried after. It is
+	// doubled after every failure in a row, up to MaxBackoff.
+	RetryAfter = 30 * time.Second
+	MaxBackoff = 30 * time.Minute
+)
+
+// DocBackendRefresh is implemented by backends whose documents are fetched
+// from elsewhere, so they can be refreshed in the background. Refresh tells
+// the watchers of the backend how the documents changed, and returns how many
+// there are.
+type DocBackendRefresh interface {
+	Refresh(ctx context.Context) (int, error)
+}
+
+// SyncState is what is known of the refreshes of a backend
+type SyncState struct {
+	Status v1.SyncStatus
+	// LastSuccess is when the backend was last refreshed, and Count how many
+	// documents it had then
+	LastSuccess time.Time
+	Count       int
+	// LastError is why the last refresh failed, if it did, and Failures how
+	// many refreshes in a row failed
+	LastError error
+	Failures  int
+	// Next is when the next refresh is due, if refreshes are scheduled
+	Next time.Time
+}
+
+// Scheduler refreshes a backend every interval, backing off after failures,
+// and keeps the SyncState of the backend. Refreshes give up after timeout.
+type Scheduler struct {
+	backend  DocBackendRefresh
+	interval time.Duration
+	timeout  time.Duration
+
+	mu    sync.Mutex
+	state SyncState
+
+	started sync.Once
+	stop    context.CancelFunc
+}
+
+// NewScheduler returns a scheduler of refreshes of the backend, which are not
+// made until Start is called
+func NewScheduler(b DocBackendRefresh, interval, timeout time.Duration) *Scheduler {
+	return &Scheduler{
+		backend:  b,
+		interval: interval,
+		timeout:  timeout,
+		state:    SyncState{Status: v1.StatusUninitialized},
+	}
+}
+
+// Start refreshes the backend now, and then on schedule in the background
+// until ctx is done or Stop is called
+func (s *Scheduler) Start(ctx context.Context) {
+	s.started.Do(func() {
+		ctx, cancel := context.WithCancel(ctx)
+		s.mu.Lock()
+		s.stop = cancel
+		s.mu.Unlock()
+		go s.run(ctx)
+	})
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	for {
+		// failures are kept in the state, and retried after a while
+		_ = s.Refresh(ctx)
+
+		s.mu.Lock()
+		delay := s.delay()
+		s.state.Next = time.Now().Add(delay)
+		s.mu.Unlock()
+
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return
+		}
+	}
+}
+
+// delay returns how long to wait before the next refresh. The lock has
+// already been claimed.
+func (s *Scheduler) delay() time.Duration {
+	d := s.interval
+	if s.state.Failures > 0 {
+		d = RetryAfter
+		for i := 1; i < s.state.Failures && d < MaxBackoff; i++ {
+			d *= 2
+		}
+		if d > MaxBackoff {
+			d = MaxBackoff
+		}
+	}
+	return jitter(d)
+}
+
+// jitter moves d earlier or later by up to RefreshJitter of it
+func jitter(d time.Duration) time.Duration {
+	return d + time.Duration((rand.Float64()*2-1)*RefreshJitter*float64(d))
+}
+
+// Stop stops refreshing the backend
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		s.stop()
+	}
+	s.state.Next = time.Time{}
+}
+
+// Refresh refreshes the backend now, keeping how it went in the state
+func (s *Scheduler) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	s.state.Status = v1.StatusSynchronizing
+	s.mu.Unlock()
+
+	rctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	n, err := s.backend.Refresh(rctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil && ctx.Err() != nil {
+		// given up on, rather than failed
+		s.state.Status = v1.StatusOffline
+		return err
+	}
+	if err != nil {
+		s.state.Status = v1.StatusError
+		s.state.LastError = err
+		s.state.Failures++
+		return err
+	}
+	s.state.Status = v1.StatusOK
+	s.state.LastSuccess = time.Now()
+	s.state.Count = n
+	s.state.LastError = nil
+	s.state.Failures = 0
+	return nil
+}
+
+// State returns what is known of the refreshes of the backend
+func (s *Scheduler) State() SyncState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+type refresher struct {
+	n   int
+	err error
+}
+
+func (r *refresher) Refresh(ctx context.Context) (int, error) { return r.n, r.err }
+
+func TestSchedulerRefresh(t *testing.T) {
+	defer func(j float64) { RefreshJitter = j }(RefreshJitter)
+	RefreshJitter = 0
+
+	r := &refresher{n: 3}
+	s := NewScheduler(r, time.Hour, time.Minute)
+	if st := s.State(); st.Status != v1.StatusUninitialized {
+		t.Errorf("expected a scheduler that never refreshed to be %s but got %s", v1.StatusUninitialized, st.Status)
+	}
+
+	if err := s.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ok := s.State()
+	if ok.Status != v1.StatusOK || ok.Count != 3 || ok.LastSuccess.IsZero() {
+		t.Errorf("expected a refresh of 3 documents, but got %+v", ok)
+	}
+	if d := s.delay(); d != time.Hour {
+		t.Errorf("expected the next refresh in an hour but got %s", d)
+	}
+
+	r.err = errors.New("offline")
+	for i, expected := range []time.Duration{RetryAfter, 2 * RetryAfter, 4 * RetryAfter} {
+		if err := s.Refresh(context.Background()); err == nil {
+			t.Fatal("expected the refresh to fail")
+		}
+		if d := s.delay(); d != expected {
+			t.Errorf("expected failure %d to be retried after %s but got %s", i+1, expected, d)
+		}
+	}
+	failed := s.State()
+	if failed.Status != v1.StatusError || failed.Failures != 3 || failed.LastError != r.err {
+		t.Errorf("expected 3 failures in a row, but got %+v", failed)
+	}
+	if !failed.LastSuccess.Equal(ok.LastSuccess) || failed.Count != 3 {
+		t.Errorf("expected the last success to be kept, but got %+v", failed)
+	}
+
+	s.state.Failures = 100
+	if d := s.delay(); d != MaxBackoff {
+		t.Errorf("expected retries to back off up to %s but got %s", MaxBackoff, d)
+	}
+
+	// refreshes given up on are not failures
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = s.Refresh(ctx)
+	if st := s.State(); st.Status != v1.StatusOffline || st.Failures != 100 {
+		t.Errorf("expected a cancelled refresh to leave the scheduler offline, but got %+v", st)
+	}
+}
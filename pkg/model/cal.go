@@ -5,6 +5,8 @@ import (
 	"sort"
 	"time"
 
+	"github.com/byxorna/jot/pkg/config"
+	"github.com/byxorna/jot/pkg/types/v1"
 	cal "github.com/rickar/cal/v2"
 	"github.com/rickar/cal/v2/us"
 )
@@ -60,3 +62,34 @@ func DefaultTagsForTime(t time.Time, holidayTags, workdayTags, weekendTags []str
 	sort.Strings(tags)
 	return tags
 }
+
+// NewEntryForTime returns a new daily entry for t, titled and tagged according
+// to the configuration and populated with the entry template
+func NewEntryForTime(t time.Time, author string, cfg *config.Config) *v1.Note {
+	return &v1.Note{
+		Metadata: v1.NoteMetadata{
+			Author:            author,
+			Title:             TitleFromTime(t, cfg.StartWorkHours, cfg.EndWorkHours),
+			Tags:              DefaultTagsForTime(t, cfg.HolidayTags, cfg.WorkdayTags, cfg.WeekendTags),
+			Labels:            map[string]string{},
+			CreationTimestamp: t,
+		},
+		Content: cfg.EntryTemplate,
+	}
+}
+
+// NewNamedNote returns a new note that is not tied to a day, titled and tagged
+// as given rather than from the calendar
+func NewNamedNote(title string, tags []string, t time.Time, author string) *v1.Note {
+	sort.Strings(tags)
+	return &v1.Note{
+		Metadata: v1.NoteMetadata{
+			Author:            author,
+			Title:             title,
+			Tags:              tags,
+			Labels:            map[string]string{},
+			CreationTimestamp: t,
+		},
+		Content: fmt.Sprintf("# %s\n\n", title),
+	}
+}
@@ -0,0 +1,174 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/byxorna/jot/pkg/plugins/fs"
+	"github.com/byxorna/jot/pkg/text"
+	"github.com/byxorna/jot/pkg/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/reflow/ansi"
+	"github.com/muesli/reflow/truncate"
+)
+
+type conflictMsg *stashItem
+
+// conflictCountMsg is how many conflicts a notebook has
+type conflictCountMsg int
+
+type conflictResolvedMsg struct {
+	store    *fs.Store
+	conflict *fs.Conflict
+	keepMine bool
+	err      error
+}
+
+// conflictDoc is a read only document comparing both sides of a conflict, so
+// it can be shown in the pager like any other document
+type conflictDoc struct {
+	content  string
+	store    *fs.Store
+	conflict *fs.Conflict
+}
+
+func (d *conflictDoc) Identifier() types.DocIdentifier   { return types.DocIdentifier(d.conflict.Copy) }
+func (d *conflictDoc) DocType() types.DocType            { return types.ConflictDoc }
+func (d *conflictDoc) MatchesFilter(string) bool         { return false }
+func (d *conflictDoc) UnformattedContent() string        { return d.content }
+func (d *conflictDoc) Created() time.Time                { return d.conflict.Mine.Metadata.CreationTimestamp }
+func (d *conflictDoc) Modified() *time.Time              { return nil }
+func (d *conflictDoc) Title() string                     { return fmt.Sprintf("Conflict in %s", d.conflict.File) }
+func (d *conflictDoc) Summary() string                   { return "" }
+func (d *conflictDoc) ExtraContext() []string            { return []string{} }
+func (d *conflictDoc) Body() string                      { return d.content }
+func (d *conflictDoc) Links() map[string]string          { return map[string]string{} }
+func (d *conflictDoc) Icon() string                      { return "" }
+func (d *conflictDoc) Validate() error                   { return nil }
+func (d *conflictDoc) SelectorTags() []string            { return []string{} }
+func (d *conflictDoc) SelectorLabels() map[string]string { return map[string]string{} }
+
+// countConflictsCmd counts the conflicts of every notebook section, so the
+// user can be told about conflicts left by jot run from the shell
+func (m *stashModel) countConflictsCmd() tea.Cmd {
+	stores := []*fs.Store{}
+	for _, s := range m.sections {
+		if store, ok := s.DocBackend.(*fs.Store); ok {
+			stores = append(stores, store)
+		}
+	}
+	return func() tea.Msg {
+		n := 0
+		for _, store := range stores {
+			conflicts, err := store.Conflicts()
+			if err != nil {
+				return errMsg{err}
+			}
+			n += len(conflicts)
+		}
+		if n == 0 {
+			return nil
+		}
+		return conflictCountMsg(n)
+	}
+}
+
+// showConflictCmd compares both sides of the first conflict of the store,
+// side by side in columns fitting width
+func showConflictCmd(store *fs.Store, width int) tea.Cmd {
+	return func() tea.Msg {
+		conflicts, err := store.Conflicts()
+		if err != nil {
+			return errMsg{err}
+		}
+		if len(conflicts) == 0 {
+			return conflictCountMsg(0)
+		}
+		c := conflicts[0]
+
+		theirs := ""
+		if c.Theirs != nil {
+			theirs = c.Theirs.Content
+		}
+
+		b := strings.Builder{}
+		fmt.Fprintf(&b, "# Conflict in %s\n\n", c.File)
+		b.WriteString("Your changes could not be merged with the changes made to the note on disk, ")
+		fmt.Fprintf(&b, "so they were saved to %s.\n\n", c.Copy)
+		b.WriteString("Press **M** to keep mine, **T** to keep theirs, or **esc** to decide later.\n\n```\n")
+		b.WriteString(sideBySide(c.Mine.Content, theirs, width))
+		b.WriteString("```\n")
+		return conflictMsg(AsStashItem(&conflictDoc{content: b.String(), store: store, conflict: c}, nil))
+	}
+}
+
+// sideBySide lays out mine and theirs in two columns, marking the lines that
+// differ like diff -y
+func sideBySide(mine, theirs string, width int) string {
+	col := max(20, (width-12)/2)
+	cell := func(s string) string {
+		s = truncate.StringWithTail(strings.ReplaceAll(s, "\t", "    "), uint(col), ellipsis)
+		return s + strings.Repeat(" ", max(0, col-ansi.PrintableRuneWidth(s)))
+	}
+
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "%s   %s\n", cell("mine"), cell("theirs"))
+	var left, right []string
+	flush := func() {
+		for i := 0; i < max(len(left), len(right)); i++ {
+			switch {
+			case i >= len(right):
+				fmt.Fprintf(&b, "%s <\n", cell(left[i]))
+			case i >= len(left):
+				fmt.Fprintf(&b, "%s > %s\n", cell(""), cell(right[i]))
+			default:
+				fmt.Fprintf(&b, "%s | %s\n", cell(left[i]), cell(right[i]))
+			}
+		}
+		left, right = nil, nil
+	}
+	for _, e := range text.Diff(strings.Split(mine, "\n"), strings.Split(theirs, "\n")) {
+		switch e.Op {
+		case text.EditDelete:
+			left = append(left, e.Line)
+		case text.EditInsert:
+			right = append(right, e.Line)
+		default:
+			flush()
+			fmt.Fprintf(&b, "%s   %s\n", cell(e.Line), cell(e.Line))
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// resolveConflictCmd keeps either side of the conflict shown in the pager
+func resolveConflictCmd(d *conflictDoc, keepMine bool) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if keepMine {
+			err = d.store.ResolveConflict(d.conflict, d.conflict.Mine.Content)
+		} else {
+			err = d.store.DiscardConflict(d.conflict)
+		}
+		return conflictResolvedMsg{store: d.store, conflict: d.conflict, keepMine: keepMine, err: err}
+	}
+}
+
+// handleConflictResolved goes back to the stash once a conflict is resolved
+func (m *Model) handleConflictResolved(msg conflictResolvedMsg) []tea.Cmd {
+	if msg.err != nil {
+		return []tea.Cmd{m.pagerModel.showStatusMessage(fmt.Sprintf("Unable to resolve conflict: %v", msg.err))}
+	}
+
+	side := "theirs"
+	if msg.keepMine {
+		side = "mine"
+	}
+	cmds := m.unloadDocument()
+	return append(cmds, m.stashModel.newStatusMessage(statusMessage{
+		status:  normalStatusMessage,
+		message: fmt.Sprintf("Kept %s in %s", side, msg.conflict.File),
+	}))
+}
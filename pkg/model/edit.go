@@ -3,9 +3,13 @@ package model
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 
+	"github.com/byxorna/jot/pkg/plugins/fs"
+	"github.com/byxorna/jot/pkg/types/v1"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -22,6 +26,60 @@ func (m *Model) EditMarkdown(md *stashItem) tea.Cmd {
 		editor = "vim"
 	}
 
+	// the content before editing is compared to the edited content
+	if err := md.loadContent(m.common.ctx); err != nil {
+		return m.stashModel.newStatusMessage(statusMessage{
+			status:  errorStatusMessage,
+			message: fmt.Sprintf("Error editing %s: %s", filename, err.Error()),
+		})
+	}
+
+	// documents not stored in a file are edited in a temporary one, and
+	// stored in their backend once the editor exits
+	inMemory := filename == ""
+	if inMemory {
+		f, err := ioutil.TempFile("", "jot-*.md")
+		if err == nil {
+			_, err = f.WriteString(md.Doc.UnformattedContent())
+			f.Close()
+			defer os.Remove(f.Name())
+		}
+		if err != nil {
+			return m.stashModel.newStatusMessage(statusMessage{
+				status:  errorStatusMessage,
+				message: fmt.Sprintf("Error editing %s: %s", md.Title(), err.Error()),
+			})
+		}
+		filename = f.Name()
+	}
+
+	// keep the note as it was, in case the edit goes wrong
+	var private *fs.PrivateCopy
+	if store, ok := notebookOf(md); ok {
+		if e, ok := md.Doc.(*v1.Note); ok {
+			if err := store.Snapshot(e.Metadata.ID); err != nil {
+				return m.stashModel.newStatusMessage(statusMessage{
+					status:  errorStatusMessage,
+					message: fmt.Sprintf("Error editing %s: %s", filename, err.Error()),
+				})
+			}
+
+			// sealed notes are edited in plain text outside of the notebook,
+			// and sealed again once the editor exits
+			if store.IsSealed(e.Metadata.ID) {
+				pc, err := store.OpenPrivateCopy(e.Metadata.ID)
+				if err != nil {
+					return m.stashModel.newStatusMessage(statusMessage{
+						status:  errorStatusMessage,
+						message: fmt.Sprintf("Error editing %s: %s", filename, err.Error()),
+					})
+				}
+				defer pc.Wipe()
+				private, filename = pc, pc.Path
+			}
+		}
+	}
+
 	cmd := exec.Command(editor, filename)
 
 	{
@@ -55,9 +113,29 @@ func (m *Model) EditMarkdown(md *stashItem) tea.Cmd {
 			message: fmt.Sprintf("Error waiting for editor: %s", err.Error()),
 		})
 	}
+	if private != nil {
+		if _, err := private.Save(); err != nil {
+			return m.stashModel.newStatusMessage(statusMessage{
+				status:  errorStatusMessage,
+				message: fmt.Sprintf("Error saving %s: %s", md.Title(), err.Error()),
+			})
+		}
+	}
 
 	var cmds []tea.Cmd
-	cmds = append(cmds, doReconcileStashItemCmd(md), func() tea.Msg { return tea.WindowSizeMsg{Height: oldH, Width: oldW} })
+	if inMemory {
+		b, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return m.stashModel.newStatusMessage(statusMessage{
+				status:  errorStatusMessage,
+				message: fmt.Sprintf("Error saving %s: %s", md.Title(), err.Error()),
+			})
+		}
+		cmds = append(cmds, m.stashModel.updateContentCmd(m.focusedSection(), md, string(b)))
+	} else {
+		cmds = append(cmds, m.stashModel.reconcileCmd(m.focusedSection(), md))
+	}
+	cmds = append(cmds, func() tea.Msg { return tea.WindowSizeMsg{Height: oldH, Width: oldW} })
 	if m.UseAltScreen {
 		cmds = append(cmds, tea.EnterAltScreen)
 	}
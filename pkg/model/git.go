@@ -0,0 +1,107 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/byxorna/jot/pkg/plugins/fs"
+	"github.com/byxorna/jot/pkg/types"
+	"github.com/byxorna/jot/pkg/types/v1"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	gitTimeFormat = "2006-01-02 15:04"
+	shortHash     = 7
+)
+
+type gitMsg *stashItem
+
+// gitDoc is a read only document with the commits that changed a note and
+// the commit that last changed each of its lines, so it can be shown in the
+// pager like any other document
+type gitDoc struct {
+	content string
+	note    *v1.Note
+}
+
+func (d *gitDoc) Identifier() types.DocIdentifier   { return d.note.Identifier() }
+func (d *gitDoc) DocType() types.DocType            { return types.GitDoc }
+func (d *gitDoc) MatchesFilter(string) bool         { return false }
+func (d *gitDoc) UnformattedContent() string        { return d.content }
+func (d *gitDoc) Created() time.Time                { return d.note.Metadata.CreationTimestamp }
+func (d *gitDoc) Modified() *time.Time              { return nil }
+func (d *gitDoc) Title() string                     { return fmt.Sprintf("Git log of %s", d.note.Title()) }
+func (d *gitDoc) Summary() string                   { return "" }
+func (d *gitDoc) ExtraContext() []string            { return []string{} }
+func (d *gitDoc) Body() string                      { return d.content }
+func (d *gitDoc) Links() map[string]string          { return map[string]string{} }
+func (d *gitDoc) Icon() string                      { return "" }
+func (d *gitDoc) Validate() error                   { return nil }
+func (d *gitDoc) SelectorTags() []string            { return []string{} }
+func (d *gitDoc) SelectorLabels() map[string]string { return map[string]string{} }
+
+func short(hash string) string {
+	if len(hash) > shortHash {
+		return hash[:shortHash]
+	}
+	return hash
+}
+
+// showGitCmd lists the commits of the note, and blames its lines
+func showGitCmd(store *fs.Store, md *stashItem) tea.Cmd {
+	return func() tea.Msg {
+		e, ok := md.Doc.(*v1.Note)
+		if !ok {
+			return errMsg{fmt.Errorf("%s has no git log", md.Doc.DocType())}
+		}
+		commits, err := store.GitLog(e.Metadata.ID)
+		if err != nil {
+			return errMsg{err}
+		}
+
+		b := strings.Builder{}
+		fmt.Fprintf(&b, "# Git log of %s\n\n", e.Title())
+		if len(commits) == 0 {
+			b.WriteString("Nothing was committed yet. Notes are committed whenever they change.\n")
+			return gitMsg(AsStashItem(&gitDoc{content: b.String(), note: e}, nil))
+		}
+		for _, c := range commits {
+			fmt.Fprintf(&b, "- `%s` %s · %s · %s\n", short(c.Hash), c.Time.Local().Format(gitTimeFormat), c.Author, c.Subject)
+		}
+
+		b.WriteString("\n## Blame\n\n")
+		if store.IsSealed(e.Metadata.ID) {
+			b.WriteString("Sealed notes are committed sealed, so their lines cannot be blamed.\n")
+			return gitMsg(AsStashItem(&gitDoc{content: b.String(), note: e}, nil))
+		}
+		lines, err := store.GitBlame(e.Metadata.ID)
+		if err != nil {
+			return errMsg{err}
+		}
+		width := 0
+		for _, l := range lines {
+			width = max(width, len(l.Author))
+		}
+		b.WriteString("```\n")
+		for _, l := range lines {
+			fmt.Fprintf(&b, "%s %s %-*s │ %s\n", short(l.Hash), l.Time.Local().Format(gitTimeFormat), width, l.Author, l.Line)
+		}
+		b.WriteString("```\n")
+		return gitMsg(AsStashItem(&gitDoc{content: b.String(), note: e}, nil))
+	}
+}
+
+// pullErrorsCmd tells about the notebooks that could not be pulled from their
+// remote at startup
+func (m *stashModel) pullErrorsCmd() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, s := range m.sections {
+		if store, ok := s.DocBackend.(*fs.Store); ok && store.PullError() != nil {
+			err := fmt.Errorf("unable to pull %s: %w", store.StoragePath(), store.PullError())
+			cmds = append(cmds, func() tea.Msg { return errMsg{err} })
+		}
+	}
+	return tea.Batch(cmds...)
+}
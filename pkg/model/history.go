@@ -0,0 +1,184 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/byxorna/jot/pkg/plugins/fs"
+	"github.com/byxorna/jot/pkg/text"
+	"github.com/byxorna/jot/pkg/types"
+	"github.com/byxorna/jot/pkg/types/v1"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	revisionTimeFormat = "2006-01-02 15:04:05"
+	diffContext        = 3
+)
+
+type historyMsg *stashItem
+
+type revisionRestoredMsg struct {
+	doc  *historyDoc
+	note *v1.Note
+	err  error
+}
+
+// historyDoc is a read only document listing the revisions of a note, with
+// the changes made in the selected one, so it can be shown in the pager like
+// any other document
+type historyDoc struct {
+	content   string
+	store     *fs.Store
+	note      *v1.Note
+	revisions []*fs.Revision
+	// selected and base are indexes into revisions, which are newest first.
+	// The selected revision is compared to base, or to the revision before it
+	// if base is -1.
+	selected int
+	base     int
+}
+
+func (d *historyDoc) Identifier() types.DocIdentifier   { return d.note.Identifier() }
+func (d *historyDoc) DocType() types.DocType            { return types.HistoryDoc }
+func (d *historyDoc) MatchesFilter(string) bool         { return false }
+func (d *historyDoc) UnformattedContent() string        { return d.content }
+func (d *historyDoc) Created() time.Time                { return d.note.Metadata.CreationTimestamp }
+func (d *historyDoc) Modified() *time.Time              { return nil }
+func (d *historyDoc) Title() string                     { return fmt.Sprintf("History of %s", d.note.Title()) }
+func (d *historyDoc) Summary() string                   { return "" }
+func (d *historyDoc) ExtraContext() []string            { return []string{} }
+func (d *historyDoc) Body() string                      { return d.content }
+func (d *historyDoc) Links() map[string]string          { return map[string]string{} }
+func (d *historyDoc) Icon() string                      { return "" }
+func (d *historyDoc) Validate() error                   { return nil }
+func (d *historyDoc) SelectorTags() []string            { return []string{} }
+func (d *historyDoc) SelectorLabels() map[string]string { return map[string]string{} }
+
+// TaskDelta describes how the tasks changed between two revisions of a note,
+// like "+2 tasks, +1 done". It is empty if they did not change.
+func TaskDelta(old, current string) string {
+	o, c := v1.TaskList(old), v1.TaskList(current)
+	parts := []string{}
+	if d := c.Total - o.Total; d != 0 {
+		parts = append(parts, fmt.Sprintf("%+d tasks", d))
+	}
+	if d := c.Checked - o.Checked; d != 0 {
+		parts = append(parts, fmt.Sprintf("%+d done", d))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// previousContent is the content of the revision before i, or nothing for
+// the first revision
+func previousContent(revisions []*fs.Revision, i int) string {
+	if i+1 < len(revisions) {
+		return revisions[i+1].Note.Content
+	}
+	return ""
+}
+
+// showHistoryCmd lists the revisions of the note
+func showHistoryCmd(store *fs.Store, md *stashItem) tea.Cmd {
+	return func() tea.Msg {
+		e, ok := md.Doc.(*v1.Note)
+		if !ok {
+			return errMsg{fmt.Errorf("%s has no history", md.Doc.DocType())}
+		}
+		revisions, err := store.Revisions(e.Metadata.ID)
+		if err != nil {
+			return errMsg{err}
+		}
+		d := &historyDoc{store: store, note: e, revisions: revisions, base: -1}
+		d.render()
+		return historyMsg(AsStashItem(d, nil))
+	}
+}
+
+// selectRevisionCmd moves the selection by delta revisions, or marks the
+// selected revision as the one to compare to
+func selectRevisionCmd(d *historyDoc, delta int, markBase bool) tea.Cmd {
+	if len(d.revisions) == 0 {
+		return nil
+	}
+	selected := min(len(d.revisions)-1, max(0, d.selected+delta))
+	base := d.base
+	if markBase {
+		base = d.selected
+		if d.base == d.selected {
+			base = -1
+		}
+	}
+	next := &historyDoc{store: d.store, note: d.note, revisions: d.revisions, selected: selected, base: base}
+	next.render()
+	return func() tea.Msg { return historyMsg(AsStashItem(next, nil)) }
+}
+
+func (d *historyDoc) render() {
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "# History of %s\n\n", d.note.Title())
+	if len(d.revisions) == 0 {
+		b.WriteString("No revisions were recorded yet. They are recorded whenever the note changes.\n")
+		d.content = b.String()
+		return
+	}
+	b.WriteString("Press **[** and **]** to pick a revision, **b** to compare other revisions to it, ")
+	b.WriteString("**R** to restore it, or **esc** to go back.\n\n")
+
+	for i, r := range d.revisions {
+		line := fmt.Sprintf("%s · %s", r.Time.Local().Format(revisionTimeFormat), r.Note.NoteTaskStatus(v1.TaskStyleDiscrete))
+		if delta := TaskDelta(previousContent(d.revisions, i), r.Note.Content); delta != "" {
+			line += fmt.Sprintf(" (%s)", delta)
+		}
+		if i == d.selected {
+			line = "**" + line + "**"
+		}
+		if i == d.base {
+			line += " · compared to"
+		}
+		fmt.Fprintf(&b, "%d. %s\n", i+1, line)
+	}
+
+	selected := d.revisions[d.selected]
+	old, from := previousContent(d.revisions, d.selected), "the revision before"
+	if d.base >= 0 {
+		old, from = d.revisions[d.base].Note.Content, d.revisions[d.base].Time.Local().Format(revisionTimeFormat)
+	}
+	fmt.Fprintf(&b, "\n## Changes from %s to %s\n\n", from, selected.Time.Local().Format(revisionTimeFormat))
+	if diff := text.Unified(old, selected.Note.Content, diffContext); diff != "" {
+		b.WriteString("```diff\n" + diff + "```\n")
+	} else {
+		b.WriteString("No changes.\n")
+	}
+	d.content = b.String()
+}
+
+// restoreRevisionCmd restores the note to the selected revision
+func restoreRevisionCmd(d *historyDoc) tea.Cmd {
+	if len(d.revisions) == 0 {
+		return nil
+	}
+	r := d.revisions[d.selected]
+	return func() tea.Msg {
+		e, err := d.store.RestoreRevision(d.note.Metadata.ID, r)
+		return revisionRestoredMsg{doc: d, note: e, err: err}
+	}
+}
+
+// handleRevisionRestored goes back to the stash once a revision is restored
+func (m *Model) handleRevisionRestored(msg revisionRestoredMsg) []tea.Cmd {
+	if msg.err != nil {
+		return []tea.Cmd{m.pagerModel.showStatusMessage(fmt.Sprintf("Unable to restore revision: %v", msg.err))}
+	}
+
+	cmds := m.unloadDocument()
+	message := fmt.Sprintf("Restored %s to %s", msg.note.Title(), msg.doc.revisions[msg.doc.selected].Time.Local().Format(revisionTimeFormat))
+	if delta := TaskDelta(msg.doc.note.Content, msg.note.Content); delta != "" {
+		message += fmt.Sprintf(" (%s)", delta)
+	}
+	return append(cmds, m.stashModel.newStatusMessage(statusMessage{
+		status:  normalStatusMessage,
+		message: message,
+	}))
+}
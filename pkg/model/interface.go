@@ -1,6 +1,8 @@
 package model
 
 import (
+	"time"
+
 	"github.com/byxorna/jot/pkg/db"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -19,6 +21,12 @@ type Section interface { // section implements this
 	db.DocBackend
 	TabTitle() string
 	Identifier() string
+	Backend() db.DocBackend
+	// Timeout is how long a request to the backend may take
+	Timeout() time.Duration
+	// Scheduler refreshes the backend in the background, if its documents are
+	// fetched from elsewhere
+	Scheduler() *db.Scheduler
 }
 
 type UIDoc interface { // stashItem implements this
@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/byxorna/jot/pkg/config"
-	"github.com/mitchellh/go-homedir"
 )
 
 var (
@@ -19,34 +18,28 @@ var (
 )
 
 func NewFromConfigFile(ctx context.Context, path string, user string, useAltScreen bool) (*Model, error) {
-	expandedPath, err := homedir.Expand(path)
+	cfg, err := config.NewFromFile(path)
 	if err != nil {
-		return nil, err
-	}
-
-	var configuration config.Config
-	f, err := os.Open(expandedPath)
-	if err != nil && f == nil {
-		// if the file is missing, ignore and use the default config
-		configuration = config.Default
-	} else {
-		cfg, err := config.NewFromReader(f)
-		if err != nil {
-			return nil, fmt.Errorf("unable to load configuration: %w", err)
-		}
-		configuration = *cfg
+		return nil, fmt.Errorf("unable to load configuration: %w", err)
 	}
+	configuration := *cfg
+	return newModel(ctx, &configuration, user, useAltScreen)
+}
 
-	common := commonModel{}
-	stashModel, err := newStashModel(&common, &configuration)
+// newModel returns the UI of the sections configured
+func newModel(ctx context.Context, cfg *config.Config, user string, useAltScreen bool) (*Model, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	common := commonModel{ctx: ctx}
+	stashModel, err := newStashModel(&common, cfg)
 	if err != nil {
+		cancel()
 		return nil, err
 	}
 	pagerModel := newPagerModel(&common)
 
 	m := Model{
 		UseAltScreen: useAltScreen,
-		Config:       &configuration,
+		Config:       cfg,
 		Author:       user,
 		Date:         time.Now(),
 		Mode:         ViewMode,
@@ -56,12 +49,15 @@ func NewFromConfigFile(ctx context.Context, path string, user string, useAltScre
 		state:      stateShowStash,
 		pagerModel: pagerModel,
 		stashModel: stashModel,
+		cancel:     cancel,
 	}
 
 	return &m, nil
 }
 
-func readStdin() (string, error) {
+// ReadStdin returns everything piped or redirected into stdin. An error is
+// returned if stdin is a terminal, or otherwise has nothing to read.
+func ReadStdin() (string, error) {
 	stat, err := os.Stdin.Stat()
 	if err != nil {
 		return "", err
@@ -76,9 +72,12 @@ func readStdin() (string, error) {
 
 	for {
 		r, _, err := reader.ReadRune()
-		if err != nil && err == io.EOF {
+		if err == io.EOF {
 			break
 		}
+		if err != nil {
+			return "", err
+		}
 		_, err = b.WriteRune(r)
 		if err != nil {
 			return "", err
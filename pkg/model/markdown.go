@@ -3,6 +3,7 @@ package model
 
 import (
 	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/plugins/everything"
 	"github.com/charmbracelet/glamour"
 )
 
@@ -24,7 +25,13 @@ func (m markdownsByLocalFirst) Less(i, j int) bool {
 	return m[i].Created().After(m[j].Created())
 }
 
+// AsStashItem returns the document listed from backend as an item of the
+// stash. Documents of an everything section are told with the section they
+// are from, and are read and written through its backend.
 func AsStashItem(d db.Doc, backend db.DocBackend) *stashItem {
+	if e, ok := d.(*everything.Doc); ok {
+		return &stashItem{Doc: e.Doc, DocBackend: e.Source, section: e.Section}
+	}
 	i := stashItem{Doc: d, DocBackend: backend}
 	return &i
 }
@@ -2,6 +2,7 @@
 package model
 
 import (
+	"context"
 	"time"
 
 	"github.com/byxorna/jot/pkg/config"
@@ -42,6 +43,9 @@ type Model struct {
 	// Sub-model implementations
 	*stashModel
 	*pagerModel
+
+	// cancel cancels the requests in flight, once jot quits
+	cancel context.CancelFunc
 }
 
 type userMessage struct {
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/byxorna/jot/pkg/config"
+	"github.com/byxorna/jot/pkg/plugins/fs"
+)
+
+// NotebookSettings returns the settings of a notes section, with the
+// directories of any other notebooks nested inside of it excluded, so their
+// notes are not loaded twice
+func NotebookSettings(cfg *config.Config, sec config.Section) (map[string]string, error) {
+	dir, err := fs.NotebookDirectory(cfg.Directory, sec.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := map[string]string{}
+	for k, v := range sec.Settings {
+		settings[k] = v
+	}
+
+	exclude := []string{}
+	if ex := settings[fs.SettingExclude]; ex != "" {
+		exclude = append(exclude, ex)
+	}
+	for _, other := range cfg.Sections {
+		if other.Plugin != config.PluginTypeNotes || other.Name == sec.Name {
+			continue
+		}
+		otherDir, err := fs.NotebookDirectory(cfg.Directory, other.Settings)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(dir, otherDir)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		exclude = append(exclude, rel)
+	}
+	settings[fs.SettingExclude] = strings.Join(exclude, ",")
+
+	return settings, nil
+}
+
+// NewNotebook opens the notes storage of a notes section
+func NewNotebook(cfg *config.Config, sec config.Section) (*fs.Store, error) {
+	settings, err := NotebookSettings(cfg, sec)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := fs.New(cfg.Directory, CreateDirectoryIfMissing, settings)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing storage provider for %s: %w", sec.Name, err)
+	}
+	return store, nil
+}
+
+// JournalSection returns the notes section named name, or the first notes
+// section if name is empty. Daily entries are kept in the journal.
+func JournalSection(cfg *config.Config, name string) (config.Section, error) {
+	for _, sec := range cfg.Sections {
+		if sec.Plugin != config.PluginTypeNotes {
+			continue
+		}
+		if name == "" || sec.Name == name {
+			return sec, nil
+		}
+	}
+	if name != "" {
+		return config.Section{}, fmt.Errorf("no notes section named %q", name)
+	}
+	return config.Section{Name: "notes", Plugin: config.PluginTypeNotes}, nil
+}
@@ -263,7 +263,10 @@ func (m *pagerModel) update(msg tea.Msg) (*pagerModel, tea.Cmd) {
 
 	case stashItemUpdateMsg:
 		m.currentDocument = msg
-		return m, tea.Batch(renderWithGlamour(m, m.currentDocument.UnformattedContent()), func() tea.Msg { return tea.WindowSizeMsg{Width: m.common.width, Height: m.common.height} })
+		if err := m.currentDocument.loadContent(m.common.ctx); err != nil {
+			cmds = append(cmds, m.showStatusMessage(fmt.Sprintf("Unable to read %s: %v", m.currentDocument.Title(), err)))
+		}
+		return m, tea.Batch(append(cmds, renderWithGlamour(m, m.currentDocument.UnformattedContent()), func() tea.Msg { return tea.WindowSizeMsg{Width: m.common.width, Height: m.common.height} })...)
 
 	// We've reveived terminal dimensions, either for the first time or
 	// after a resize
@@ -476,12 +479,16 @@ func glamourRender(m *pagerModel, markdown string) (string, error) {
 	//	return markdown, nil
 	//}
 
+	return RenderMarkdown(markdown, max(0, m.viewport.Width))
+}
+
+// RenderMarkdown renders markdown for display in a terminal, word wrapped at width
+func RenderMarkdown(markdown string, width int) (string, error) {
 	// initialize glamour
 	var gs glamour.TermRendererOption
 	gs = glamour.WithAutoStyle()
 	//gs = glamour.WithStylePath(m.common.cfg.GlamourStyle)
 
-	width := max(0, m.viewport.Width)
 	r, err := glamour.NewTermRenderer(gs, glamour.WithWordWrap(width))
 	if err != nil {
 		return "", err
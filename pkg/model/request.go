@@ -0,0 +1,156 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/types/v1"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// requestDoneMsg wraps the outcome of a request to the backend of a section,
+// so the request is no longer counted as in flight once it is handled
+type requestDoneMsg struct {
+	section *section
+	msg     tea.Msg
+}
+
+// sectionListedMsg is the documents of a section, listed again
+type sectionListedMsg struct {
+	section *section
+	docs    []db.Doc
+	err     error
+}
+
+// reconciledMsg is a document read again from its backend
+type reconciledMsg struct {
+	md  *stashItem
+	doc db.Doc
+	err error
+}
+
+// requestCmd runs fn with the context of a request to the backend of the
+// section, showing the spinner while it is in flight
+func (m *stashModel) requestCmd(s *section, fn func(ctx context.Context) tea.Msg) tea.Cmd {
+	ctx, cancel := s.request(m.common.ctx)
+	return tea.Batch(spinner.Tick, func() tea.Msg {
+		defer cancel()
+		return requestDoneMsg{section: s, msg: fn(ctx)}
+	})
+}
+
+// loading returns whether a request to the backend of any section is in flight
+func (m *stashModel) loading() bool {
+	for _, s := range m.sections {
+		if s.inFlight > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// listSectionCmd lists the documents of the section again
+func (m *stashModel) listSectionCmd(s *section) tea.Cmd {
+	if s.listing {
+		s.stale = true
+		return nil
+	}
+	s.listing = true
+	return m.requestCmd(s, func(ctx context.Context) tea.Msg {
+		docs, err := s.DocBackend.List(ctx)
+		return sectionListedMsg{section: s, docs: docs, err: err}
+	})
+}
+
+// startRefreshes refreshes the sections fetched from elsewhere in the
+// background, until jot quits
+func (m *stashModel) startRefreshes() {
+	for _, s := range m.sections {
+		if s.scheduler != nil {
+			s.scheduler.Start(m.common.ctx)
+		}
+	}
+}
+
+// listSectionsCmd lists the documents of every section
+func (m *stashModel) listSectionsCmd() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, s := range m.sections {
+		cmds = append(cmds, m.listSectionCmd(s))
+	}
+	return tea.Batch(cmds...)
+}
+
+// handleSectionListed keeps the documents listed for the section. The
+// documents listed before are kept if they could not be listed again.
+func (m *stashModel) handleSectionListed(msg sectionListedMsg) tea.Cmd {
+	s := msg.section
+	s.listing = false
+
+	switch {
+	case errors.Is(msg.err, context.Canceled):
+		// the user left the section, or is quitting
+		if s == m.focusedSection() && m.common.ctx.Err() == nil {
+			return m.listSectionCmd(s)
+		}
+		return nil
+	case msg.err != nil:
+		s.err = msg.err
+		return m.newStatusMessage(statusMessage{
+			status:  errorStatusMessage,
+			message: fmt.Sprintf("Unable to list %s: %v", s.Identifier(), msg.err),
+		})
+	}
+
+	s.docs, s.listed, s.err = msg.docs, true, nil
+	m.updatePagination()
+	if s.stale {
+		s.stale = false
+		return m.listSectionCmd(s)
+	}
+	return nil
+}
+
+// focus shows the section at index i, cancelling the requests in flight for
+// the section left. The section shown is listed if it was not yet.
+func (m *stashModel) focus(i int) tea.Cmd {
+	if left := m.focusedSection(); i != m.sectionIndex {
+		left.leave()
+	}
+	m.sectionIndex = i
+	m.updatePagination()
+
+	if s := m.focusedSection(); !s.listed && !s.listing {
+		return m.listSectionCmd(s)
+	}
+	return nil
+}
+
+// updateContentCmd stores the note with its content changed, for backends
+// whose documents are not edited in a file
+func (m *stashModel) updateContentCmd(s *section, md *stashItem, content string) tea.Cmd {
+	e, ok := md.Doc.(*v1.Note)
+	if !ok {
+		return errCmd(fmt.Errorf("unable to edit a %s", md.Doc.DocType()))
+	}
+	if content == e.Content {
+		return nil
+	}
+	edited := *e
+	edited.Content = content
+	return m.requestCmd(s, func(ctx context.Context) tea.Msg {
+		d, err := md.DocBackend.Update(ctx, &edited)
+		return reconciledMsg{md: md, doc: d, err: err}
+	})
+}
+
+// reconcileCmd reads the document again from its backend
+func (m *stashModel) reconcileCmd(s *section, md *stashItem) tea.Cmd {
+	return m.requestCmd(s, func(ctx context.Context) tea.Msg {
+		d, err := md.DocBackend.Get(ctx, md.Doc.Identifier(), true)
+		return reconciledMsg{md: md, doc: d, err: err}
+	})
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSectionRequest(t *testing.T) {
+	s := newSectionModel("notes", time.Hour, nil)
+
+	ctx, cancel := s.request(context.Background())
+	defer cancel()
+	if s.inFlight != 1 {
+		t.Errorf("expected a request in flight but got %d", s.inFlight)
+	}
+	s.leave()
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("expected leaving the section to cancel its requests, but got %v", ctx.Err())
+	}
+
+	// requests made after coming back are not cancelled
+	ctx, cancel = s.request(context.Background())
+	defer cancel()
+	if ctx.Err() != nil {
+		t.Errorf("expected a new request to be in flight, but got %v", ctx.Err())
+	}
+
+	s.timeout = time.Millisecond
+	ctx, cancel = s.request(context.Background())
+	defer cancel()
+	<-ctx.Done()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("expected the request to time out, but got %v", ctx.Err())
+	}
+
+	quit, stop := context.WithCancel(context.Background())
+	s.leave()
+	ctx, cancel = s.request(quit)
+	defer cancel()
+	stop()
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("expected quitting to cancel the requests, but got %v", ctx.Err())
+	}
+}
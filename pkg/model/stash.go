@@ -3,19 +3,25 @@ package model
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	nethttp "net/http"
 	"os/user"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/byxorna/jot/pkg/config"
+	"github.com/byxorna/jot/pkg/db"
 	"github.com/byxorna/jot/pkg/net/http"
 	"github.com/byxorna/jot/pkg/plugins/calendar"
+	"github.com/byxorna/jot/pkg/plugins/everything"
 	"github.com/byxorna/jot/pkg/plugins/filter"
 	"github.com/byxorna/jot/pkg/plugins/fs"
 	"github.com/byxorna/jot/pkg/plugins/keep"
+	"github.com/byxorna/jot/pkg/plugins/memory"
+	"github.com/byxorna/jot/pkg/types"
 	"github.com/byxorna/jot/pkg/types/v1"
 	"github.com/byxorna/jot/pkg/ui"
 	"github.com/byxorna/jot/pkg/version"
@@ -31,14 +37,7 @@ import (
 	//te "github.com/muesli/termenv"
 )
 
-var (
-	fsPlugin *fs.Store
-)
-
 func newStashModel(common *commonModel, cfg *config.Config) (*stashModel, error) {
-
-	ctx := context.TODO()
-
 	sp := spinner.NewModel()
 	sp.Spinner = spinner.Line
 	sp.Style = lipgloss.NewStyle().Foreground(fuschia)
@@ -47,7 +46,7 @@ func newStashModel(common *commonModel, cfg *config.Config) (*stashModel, error)
 	sp.Start()
 
 	ni := textinput.NewModel()
-	ni.Prompt = stashTextInputPromptStyle("Memo: ")
+	ni.Prompt = stashTextInputPromptStyle("Title: ")
 	ni.CursorStyle = lipgloss.NewStyle().Foreground(fuschia)
 	ni.CharLimit = noteCharacterLimit
 	ni.Focus()
@@ -58,6 +57,52 @@ func newStashModel(common *commonModel, cfg *config.Config) (*stashModel, error)
 	si.CharLimit = noteCharacterLimit
 	si.Focus()
 
+	pi := textinput.NewModel()
+	pi.Prompt = stashTextInputPromptStyle("Passphrase: ")
+	pi.CursorStyle = lipgloss.NewStyle().Foreground(fuschia)
+	pi.EchoMode = textinput.EchoPassword
+	pi.Focus()
+
+	s, err := newSections(common.ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := user.Current()
+	if err != nil {
+		return nil, err
+	}
+
+	m := stashModel{
+		User:            *u,
+		common:          common,
+		config:          cfg,
+		spinner:         sp,
+		noteInput:       ni,
+		filterInput:     si,
+		passphraseInput: pi,
+		serverPage:      1,
+		sections:        s,
+	}
+
+	return &m, nil
+}
+
+// NewSectionsFromConfig initializes the backends for each section in the
+// configuration, without any of the UI
+func NewSectionsFromConfig(ctx context.Context, cfg *config.Config) ([]Section, error) {
+	sections, err := newSections(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	s := make([]Section, len(sections))
+	for i, sx := range sections {
+		s[i] = Section(sx)
+	}
+	return s, nil
+}
+
+func newSections(ctx context.Context, cfg *config.Config) ([]*section, error) {
 	// collect all enabled plugin auth scopes when we create our http client
 	authScopes := []string{}
 	for _, sec := range cfg.Sections {
@@ -69,24 +114,28 @@ func newStashModel(common *commonModel, cfg *config.Config) (*stashModel, error)
 		}
 	}
 
-	client, err := http.NewDefaultClient(ctx, authScopes...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create client for auth scopes %v: %w", strings.Join(authScopes, ","), err)
+	var client *nethttp.Client
+	if len(authScopes) > 0 {
+		c, err := http.NewDefaultClient(ctx, authScopes...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client for auth scopes %v: %w", strings.Join(authScopes, ","), err)
+		}
+		client = c
 	}
 
 	var s []*section
+	var everythings []int
 	for _, sec := range cfg.Sections {
 		switch sec.Plugin {
 
 		case config.PluginTypeNotes:
-			noteBackend, err := fs.New(cfg.Directory, true)
+			noteBackend, err := NewNotebook(cfg, sec)
 			if err != nil {
-				return nil, fmt.Errorf("error initializing storage provider: %w", err)
+				return nil, err
 			}
 
-			notes := newSectionModel(sec.Name, noteBackend)
+			notes := newSectionModel(sec.Name, sec.RequestTimeout(), noteBackend)
 			s = append(s, &notes)
-			fsPlugin = noteBackend
 
 		case config.PluginTypeCalendar:
 			/*
@@ -99,7 +148,8 @@ func newStashModel(common *commonModel, cfg *config.Config) (*stashModel, error)
 			if err != nil {
 				return nil, fmt.Errorf("%s failed to initialize: %w", sec.Plugin, err)
 			}
-			today := newSectionModel(sec.Name, cp)
+			today := newSectionModel(sec.Name, sec.RequestTimeout(), cp)
+			today.scheduler = db.NewScheduler(cp, sec.RefreshInterval(), sec.RequestTimeout())
 			s = append(s, &today)
 
 		case config.PluginTypeKeep:
@@ -115,32 +165,38 @@ func newStashModel(common *commonModel, cfg *config.Config) (*stashModel, error)
 			if err != nil {
 				return nil, fmt.Errorf("%s failed to initialize: %w", sec.Plugin, err)
 			}
-			keepClient := newSectionModel(sec.Name, kp)
+			keepClient := newSectionModel(sec.Name, sec.RequestTimeout(), kp)
+			keepClient.scheduler = db.NewScheduler(kp, sec.RefreshInterval(), sec.RequestTimeout())
 			s = append(s, &keepClient)
 
+		case config.PluginTypeScratch:
+			scratch := newSectionModel(sec.Name, sec.RequestTimeout(), memory.New(sec.Name))
+			s = append(s, &scratch)
+
+		case config.PluginTypeEverything:
+			// made once every other section is, as it merges them
+			everythings = append(everythings, len(s))
+			s = append(s, nil)
+
 		default:
 			// TODO: maybe skip initialization? :thinking:
 			return nil, fmt.Errorf("unsupported plugin %v for section name %s", sec.Plugin, sec.Name)
 		}
 	}
 
-	u, err := user.Current()
-	if err != nil {
-		return nil, err
+	var sources []everything.Source
+	for _, sec := range s {
+		if sec != nil {
+			sources = append(sources, everything.Source{Name: sec.Identifier(), Backend: sec.DocBackend})
+		}
 	}
-
-	m := stashModel{
-		User:        *u,
-		common:      common,
-		config:      cfg,
-		spinner:     sp,
-		noteInput:   ni,
-		filterInput: si,
-		serverPage:  1,
-		sections:    s,
+	for _, i := range everythings {
+		sec := cfg.Sections[i]
+		all := newSectionModel(sec.Name, sec.RequestTimeout(), everything.New(sources...))
+		s[i] = &all
 	}
 
-	return &m, nil
+	return s, nil
 }
 
 func newStashPaginator() paginator.Model {
@@ -198,6 +254,7 @@ const (
 	selectionIdle = iota
 	selectionSettingNote
 	selectionPromptingDelete
+	selectionUnlocking
 )
 
 // statusMessageType adds some context to the status message being sent.
@@ -239,6 +296,7 @@ type stashModel struct {
 	spinner            spinner.Model
 	noteInput          textinput.Model
 	filterInput        textinput.Model
+	passphraseInput    textinput.Model
 	viewState          StashViewState
 	filterState        filterState
 	selectionState     selectionState
@@ -254,6 +312,9 @@ type stashModel struct {
 	// Index of the section we're currently looking at
 	sectionIndex int
 
+	// Notebook we're asking the passphrase of
+	unlocking *fs.Store
+
 	// The master set of markdown documents we're working with.
 	markdowns []*stashItem
 
@@ -332,6 +393,11 @@ func (m *stashModel) filterApplied() bool {
 	return m.filterState != unfiltered
 }
 
+// IsFiltering returns whether the user is actively editing a filter
+func (m *stashModel) IsFiltering() bool {
+	return m.filterState == filtering
+}
+
 // Update pagination according to the amount of markdowns for the current
 // state.
 func (m *stashModel) updatePagination() {
@@ -401,19 +467,27 @@ func (m *stashModel) addMarkdowns(mds ...*stashItem) {
 	m.updatePagination()
 }
 
-func (m *stashModel) getVisibleStashItems() []*stashItem {
-	if m.filteredStashItems != nil && (m.filterState == filtering || m.focusedSection().Identifier() == filterSectionID) {
-		return m.filteredStashItems
+// removeMarkdown drops a document that no longer exists from the model
+func (m *stashModel) removeMarkdown(id types.DocIdentifier) {
+	for _, md := range m.markdowns {
+		if md.Identifier() == id {
+			if mds, err := deleteMarkdown(m.markdowns, md); err == nil {
+				m.markdowns = mds
+			}
+			break
+		}
 	}
+	m.updatePagination()
+}
 
-	backend := m.focusedSection().DocBackend
-	l, err := backend.List()
-	if err != nil {
-		panic(err)
-	}
-	items := make([]*stashItem, len(l))
-	for i, d := range l {
-		items[i] = AsStashItem(d, backend)
+func (m *stashModel) getVisibleStashItems() []*stashItem {
+	// the documents are listed in the background, so that a slow backend does
+	// not hold up the UI. While filtering, the filter section is focused and
+	// lists the documents matching.
+	s := m.focusedSection()
+	items := make([]*stashItem, len(s.docs))
+	for i, d := range s.docs {
+		items[i] = AsStashItem(d, s.DocBackend)
 	}
 
 	return items
@@ -512,10 +586,49 @@ func (m *stashModel) update(msg tea.Msg) (*stashModel, tea.Cmd) {
 		m.filteredStashItems = msg
 		return m, nil
 
+	case noteCreatedMsg:
+		m.addMarkdowns(msg.md)
+		return m, m.newStatusMessage(statusMessage{
+			status:  normalStatusMessage,
+			message: fmt.Sprintf("Created %s", msg.md.Title()),
+		})
+
+	case noteDeletedMsg:
+		if msg.err != nil {
+			return m, m.newStatusMessage(statusMessage{
+				status:  errorStatusMessage,
+				message: fmt.Sprintf("Unable to delete %s: %v", msg.md.Title(), msg.err),
+			})
+		}
+		m.removeMarkdown(msg.md.Identifier())
+		message := fmt.Sprintf("Deleted %s", msg.md.Title())
+		if msg.trash {
+			message = fmt.Sprintf("Moved %s to the trash", msg.md.Title())
+		}
+		return m, m.newStatusMessage(statusMessage{
+			status:  normalStatusMessage,
+			message: message,
+		})
+
+	case noteArchivedMsg:
+		if msg.err != nil {
+			return m, m.newStatusMessage(statusMessage{
+				status:  errorStatusMessage,
+				message: fmt.Sprintf("Unable to archive %s: %v", msg.md.Title(), msg.err),
+			})
+		}
+		m.addMarkdowns(msg.md)
+		message := fmt.Sprintf("Unarchived %s", msg.md.Title())
+		if n, ok := msg.md.Doc.(*v1.Note); ok && n.Metadata.Archived {
+			message = fmt.Sprintf("Archived %s, find it with /", msg.md.Title())
+		}
+		return m, m.newStatusMessage(statusMessage{
+			status:  normalStatusMessage,
+			message: message,
+		})
+
 	case spinner.TickMsg:
-		// TODO: for now, just stub this out. Need to figure out what to do
-		// when we have multiple doc types that may load asynchronously
-		loading := false
+		loading := m.loading()
 
 		openingDocument := m.viewState == stashStateLoadingDocument
 		spinnerVisible := m.spinner.Visible()
@@ -549,6 +662,18 @@ func (m *stashModel) update(msg tea.Msg) (*stashModel, tea.Cmd) {
 	// Updates per the current state
 	switch m.viewState {
 	case stashStateReady:
+		if m.selectionState == selectionSettingNote {
+			cmds = append(cmds, m.handleNewNoteTitle(msg))
+			break
+		}
+		if m.selectionState == selectionPromptingDelete {
+			cmds = append(cmds, m.handleDeletePrompt(msg))
+			break
+		}
+		if m.selectionState == selectionUnlocking {
+			cmds = append(cmds, m.handleUnlockPrompt(msg))
+			break
+		}
 		cmds = append(cmds, m.handleDocumentBrowsing(msg))
 	case stashStateShowingError:
 		// Any key exists the error view
@@ -600,22 +725,14 @@ func (m *stashModel) handleDocumentBrowsing(msg tea.Msg) tea.Cmd {
 			if len(m.sections) == 0 || m.filterState == filtering {
 				break
 			}
-			m.sectionIndex++
-			if m.sectionIndex >= len(m.sections) {
-				m.sectionIndex = 0
-			}
-			m.updatePagination()
+			cmds = append(cmds, m.focus((m.sectionIndex+1)%len(m.sections)))
 
 		// Previous section
 		case "shift+tab", "H":
 			if len(m.sections) == 0 || m.filterState == filtering {
 				break
 			}
-			m.sectionIndex--
-			if m.sectionIndex < 0 {
-				m.sectionIndex = len(m.sections) - 1
-			}
-			m.updatePagination()
+			cmds = append(cmds, m.focus((m.sectionIndex+len(m.sections)-1)%len(m.sections)))
 
 		// Open document
 		case "enter", "v":
@@ -633,6 +750,9 @@ func (m *stashModel) handleDocumentBrowsing(msg tea.Msg) tea.Cmd {
 
 			// Build values we'll filter against
 			for _, md := range m.markdowns {
+				if err := md.loadContent(m.common.ctx); err != nil {
+					return errCmd(err)
+				}
 				md.buildFilterValue()
 			}
 
@@ -645,6 +765,17 @@ func (m *stashModel) handleDocumentBrowsing(msg tea.Msg) tea.Cmd {
 			m.filterInput.Focus()
 			return textinput.Blink
 
+		// Create a named note
+		case "n":
+			if !db.Can(m.focusedSection().DocBackend, db.CanCreate) {
+				break
+			}
+			m.hideStatusMessage()
+			m.selectionState = selectionSettingNote
+			m.noteInput.SetValue("")
+			m.noteInput.CursorEnd()
+			return textinput.Blink
+
 		// Set note
 		//case "m":
 		//	m.hideStatusMessage()
@@ -666,25 +797,30 @@ func (m *stashModel) handleDocumentBrowsing(msg tea.Msg) tea.Cmd {
 		//	}
 
 		// Prompt for deletion
-		//case "x":
-		//	m.hideStatusMessage()
-
-		//	validState := m.viewState == stashStateReady &&
-		//		m.selectionState == selectionIdle
-
-		//	if numDocs == 0 && !validState {
-		//		break
-		//	}
+		case "x":
+			m.hideStatusMessage()
+			if numDocs == 0 || m.selectionState != selectionIdle {
+				break
+			}
+			if md, err := m.CurrentStashItem(); err == nil && db.Can(md.DocBackend, db.CanDelete) {
+				m.selectionState = selectionPromptingDelete
+			}
 
-		//	md := m.CurrentStashItem()
-		//	if md == nil {
-		//		break
-		//	}
+		// Unlock a notebook left locked
+		case "U":
+			if store, ok := m.notebook(); ok && store.Locked() {
+				return m.promptUnlock(store)
+			}
 
-		//	t := md.docType
-		//	if t == StashedDoc || t == ConvertedDoc {
-		//		m.selectionState = selectionPromptingDelete
-		//	}
+		// Archive or unarchive
+		case "a":
+			m.hideStatusMessage()
+			if numDocs == 0 {
+				break
+			}
+			if md, err := m.CurrentStashItem(); err == nil {
+				return toggleArchiveCmd(md)
+			}
 
 		// Toggle full help
 		case "?":
@@ -728,6 +864,42 @@ func (m *stashModel) handleDocumentBrowsing(msg tea.Msg) tea.Cmd {
 
 // Updates for when a user is being prompted whether or not to delete a
 // markdown item.
+func (m *stashModel) handleDeletePrompt(msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		m.selectionState = selectionIdle
+		if msg.String() != "y" {
+			return nil
+		}
+		md, err := m.CurrentStashItem()
+		if err != nil {
+			return nil
+		}
+		return m.deleteNoteCmd(m.focusedSection(), md)
+	}
+	return nil
+}
+
+// Updates for when a user is typing the title of a new named note.
+func (m *stashModel) handleNewNoteTitle(msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc":
+			m.selectionState = selectionIdle
+			return nil
+		case "enter":
+			m.selectionState = selectionIdle
+			title := strings.TrimSpace(m.noteInput.Value())
+			if title == "" {
+				return nil
+			}
+			return m.createNamedNote(m.focusedSection(), title)
+		}
+	}
+
+	newNoteInputModel, cmd := m.noteInput.Update(msg)
+	m.noteInput = newNoteInputModel
+	return cmd
+}
 
 // Updates for when a user is in the filter editing interface.
 func (m *stashModel) handleFiltering(msg tea.Msg) tea.Cmd {
@@ -735,13 +907,16 @@ func (m *stashModel) handleFiltering(msg tea.Msg) tea.Cmd {
 
 	{ // if there is no filter section, add one immediately at the end
 		if m.sections[len(m.sections)-1].Identifier() != filterSectionID {
-			fmt.Printf("adding new filter backend")
-			filterBackend, err := filter.New(func() string { return m.filterInput.Value() }, m.focusedSection().DocBackend)
+			focused := m.focusedSection()
+			ctx, cancel := context.WithTimeout(m.common.ctx, focused.timeout)
+			filterBackend, err := filter.New(ctx, func() string { return m.filterInput.Value() }, focused.DocBackend)
+			cancel()
 			if err != nil {
 				cmds = append(cmds, errCmd(err))
 			} else {
-				filterSection := newSectionModel(filterSectionID, filterBackend)
+				filterSection := newSectionModel(filterSectionID, focused.timeout, filterBackend)
 				m.sections = append(m.sections, &filterSection)
+				cmds = append(cmds, m.listSectionCmd(&filterSection))
 			}
 		}
 		m.sectionIndex = len(m.sections) - 1
@@ -756,7 +931,8 @@ func (m *stashModel) handleFiltering(msg tea.Msg) tea.Cmd {
 		case "enter", "tab", "shift+tab", "ctrl+k", "up", "ctrl+j", "down":
 			m.hideStatusMessage()
 
-			if len(m.markdowns) == 0 {
+			if !m.focusedSection().listed {
+				// the documents matching are not listed yet
 				break
 			}
 
@@ -799,16 +975,15 @@ func (m *stashModel) handleFiltering(msg tea.Msg) tea.Cmd {
 	}
 
 	// Update the filter text input component
+	currentFilterVal := m.filterInput.Value()
 	newFilterInputModel, inputCmd := m.filterInput.Update(msg)
-	//currentFilterVal := m.filterInput.Value()
-	//newFilterVal := newFilterInputModel.Value()
 	m.filterInput = newFilterInputModel
 	cmds = append(cmds, inputCmd)
 
-	// If the filtering input has changed, request updated filtering
-	//if newFilterVal != currentFilterVal {
-	//	cmds = append(cmds, filterMarkdowns(*m))
-	//}
+	// If the filtering input has changed, list the documents matching it
+	if s := m.focusedSection(); s.Identifier() == filterSectionID && m.filterInput.Value() != currentFilterVal {
+		cmds = append(cmds, m.listSectionCmd(s))
+	}
 
 	// Update pagination
 	m.updatePagination()
@@ -827,17 +1002,19 @@ func (m *stashModel) View() string {
 		s += " " + m.spinner.View() + " Loading document..."
 	case stashStateReady:
 		loadingIndicator := " "
-		if m.focusedSection().Status() == v1.StatusSynchronizing || m.spinner.Visible() {
+		if m.focusedSection().Status() == v1.StatusSynchronizing || m.focusedSection().inFlight > 0 || m.spinner.Visible() {
 			loadingIndicator = m.spinner.View()
 		}
 
 		var header string
-		//switch m.selectionState {
-		//case selectionPromptingDelete:
-		//	header = redFg("Delete this item from your stash? ") + faintRedFg("(y/N)")
-		//case selectionSettingNote:
-		//	header = yellowFg("Set the memo for this item?")
-		//}
+		switch m.selectionState {
+		case selectionPromptingDelete:
+			header = deletePromptView(m.deletesToTrash())
+		case selectionSettingNote:
+			header = m.noteInput.View()
+		case selectionUnlocking:
+			header = unlockPromptView(m.passphraseInput)
+		}
 
 		// Only draw the normal header if we're not using the header area for
 		// something else (like a note or delete prompt).
@@ -920,7 +1097,7 @@ func (m *stashModel) headerView() string {
 	for i, v := range m.sections {
 		var s string
 		if v.Identifier() == filterSectionID {
-			s = fmt.Sprintf("%d %s “%s”", len(m.filteredStashItems), v.DocType(), m.filterInput.Value())
+			s = fmt.Sprintf("%d %s “%s”", len(v.docs), v.DocType(), m.filterInput.Value())
 		} else {
 			s = v.TabTitle()
 		}
@@ -938,7 +1115,11 @@ func (m *stashModel) headerView() string {
 		sections = append(sections, s)
 	}
 
-	return strings.Join(sections, dividerBar)
+	header := strings.Join(sections, dividerBar)
+	if sync := m.focusedSection().syncView(); sync != "" {
+		header += dividerDot + sync
+	}
+	return header
 }
 
 func (m stashModel) populatedView() string {
@@ -956,18 +1137,22 @@ func (m stashModel) populatedView() string {
 		if thisFocusedSection.Identifier() == filterSectionID {
 			return ""
 		}
-		switch thisFocusedSection.DocBackend.Status() {
-		case v1.StatusUninitialized:
-			f(fmt.Sprintf("Still initializing %vs...", thisFocusedSection.DocType()))
+		switch {
+		case thisFocusedSection.err != nil && !thisFocusedSection.listed:
+			f(fmt.Sprintf("Unable to list %s: %v", thisFocusedSection.DocType().Plural(), thisFocusedSection.err))
+		case !thisFocusedSection.listed:
+			f(fmt.Sprintf("Loading %s...", thisFocusedSection.DocType().Plural()))
+		case thisFocusedSection.DocBackend.Status() == v1.StatusUninitialized:
+			f(fmt.Sprintf("Still initializing %s...", thisFocusedSection.DocType().Plural()))
 		default:
-			f(fmt.Sprintf("No %vs found.", thisFocusedSection.DocType()))
+			f(fmt.Sprintf("No %s found.", thisFocusedSection.DocType().Plural()))
 		}
 	} else {
 		start, end := m.paginator().GetSliceBounds(len(mds))
 		stashItems := mds[start:end]
 
 		for i, si := range stashItems {
-			rendered := stashItemView(m.common.width, m.cursor() == i, m.filterState == filtering, m.filterInput.Value(), len(m.getVisibleStashItems()), si.Doc)
+			rendered := stashItemView(m.common.width, m.cursor() == i, m.filterState == filtering, m.filterInput.Value(), len(m.getVisibleStashItems()), si.section, si.Doc)
 			fmt.Fprint(&b, rendered)
 			if i != len(stashItems)-1 {
 				fmt.Fprintf(&b, "\n\n")
@@ -1073,45 +1258,23 @@ func (m *stashModel) Sections() []Section {
 	return s
 }
 
-func (m *stashModel) ReloadNoteCollectionCmd() tea.Cmd {
-	return func() tea.Msg {
-		entries, err := fsPlugin.ListAll()
-		if err != nil {
-			return errMsg{err}
-		}
-
-		mds := make([]*stashItem, len(entries))
-		for i, e := range entries {
-			locale := e
-			md := AsStashItem(locale, fsPlugin)
-			mds[i] = md
-		}
-
-		return stashItemCollectionReconcileMsg(mds)
-	}
+// noteCreatedMsg is a note created in the backend of a section
+type noteCreatedMsg struct {
+	md *stashItem
 }
 
 // Open either the appropriate entry for today, or create a new one
-func (m *stashModel) createTodayNote(day time.Time) (*stashModel, tea.Cmd) {
+func (m *stashModel) createTodayNote(store *fs.Store, day time.Time) (*stashModel, tea.Cmd) {
 	return m, func() tea.Msg {
-		if entries, err := fsPlugin.ListAll(); err == nil {
-			// if the most recent entry isnt the same as our expected filename, create a new entry for today
+		if _, err := store.GetByDay(day); err == nil || errors.Is(err, db.ErrNoNoteFound) {
+			// if there is no daily entry for today yet (named notes dont count), create one
 			expectedFilename := day.Format(fs.StorageFilenameFormat)
-			if len(entries) == 0 || (len(entries) > 0 && entries[0].Metadata.CreationTimestamp.Format(fs.StorageFilenameFormat) != expectedFilename) {
-				_, err := fsPlugin.CreateOrUpdateNote(&v1.Note{
-					Metadata: v1.NoteMetadata{
-						Author: m.User.Username,
-						Title:  TitleFromTime(day, m.config.StartWorkHours, m.config.EndWorkHours),
-						Tags:   DefaultTagsForTime(day, m.config.HolidayTags, m.config.WorkdayTags, m.config.WeekendTags),
-						Labels: map[string]string{},
-					},
-					Content: m.config.EntryTemplate,
-				})
+			if errors.Is(err, db.ErrNoNoteFound) {
+				e, err := store.CreateOrUpdateNote(NewEntryForTime(day, m.User.Username, m.config))
 				if err != nil {
 					return errMsg{fmt.Errorf("unable to create new entry: %w", err)}
 				}
-				// TODO: we should not need to reload the whole collection, but I dunno how to make this work otherwise
-				return m.ReloadNoteCollectionCmd()
+				return noteCreatedMsg{md: AsStashItem(e, store)}
 			} else {
 				return m.newStatusMessage(statusMessage{
 					status:  normalStatusMessage,
@@ -1123,3 +1286,15 @@ func (m *stashModel) createTodayNote(day time.Time) (*stashModel, tea.Cmd) {
 		}
 	}
 }
+
+// Create a named note in the backend of a section
+func (m *stashModel) createNamedNote(s *section, title string) tea.Cmd {
+	note := NewNamedNote(title, nil, time.Now(), m.User.Username)
+	return m.requestCmd(s, func(ctx context.Context) tea.Msg {
+		d, err := s.DocBackend.Create(ctx, note)
+		if err != nil {
+			return errMsg{fmt.Errorf("unable to create note: %w", err)}
+		}
+		return noteCreatedMsg{md: AsStashItem(d, s.DocBackend)}
+	})
+}
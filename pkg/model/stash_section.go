@@ -2,17 +2,31 @@
 package model
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/text"
+	"github.com/byxorna/jot/pkg/types"
+	"github.com/byxorna/jot/pkg/types/v1"
+	"github.com/byxorna/jot/pkg/ui"
 	"github.com/charmbracelet/bubbles/paginator"
 )
 
-func newSectionModel(name string, be db.DocBackend) section {
+const (
+	// syncErrorWidth is how much of why a refresh failed the header tells
+	syncErrorWidth = 48
+)
+
+func newSectionModel(name string, timeout time.Duration, be db.DocBackend) section {
 	return section{
 		name:       name,
 		paginator:  newStashPaginator(),
 		DocBackend: be,
+		timeout:    timeout,
 	}
 }
 
@@ -24,23 +38,133 @@ type section struct {
 	name      string
 	paginator paginator.Model
 	cursor    int
+
+	// timeout is how long a request to the backend may take
+	timeout time.Duration
+	// scheduler refreshes the backend in the background, if its documents
+	// are fetched from elsewhere
+	scheduler *db.Scheduler
+	// requests is done when the user leaves the section, cancelling the
+	// requests still in flight, of which there are inFlight
+	requests       context.Context
+	cancelRequests context.CancelFunc
+	inFlight       int
+
+	// docs are the documents as last listed, once listed is set, and err why
+	// they could not be listed again. The section is listed again once the
+	// list in flight is done when it is stale.
+	docs    []db.Doc
+	listed  bool
+	listing bool
+	stale   bool
+	err     error
 }
 
 func (s *section) Identifier() string { return s.name }
 
+// Backend returns the backend that stores the documents of this section
+func (s *section) Backend() db.DocBackend { return s.DocBackend }
+
+// Timeout returns how long a request to the backend of the section may take
+func (s *section) Timeout() time.Duration { return s.timeout }
+
+// Scheduler returns what refreshes the backend of the section in the
+// background, or nil if its documents are not fetched from elsewhere
+func (s *section) Scheduler() *db.Scheduler { return s.scheduler }
+
+// syncView tells when the backend was last refreshed, and why it could not be
+// since, if it was refreshed in the background
+func (s *section) syncView() string {
+	if s.scheduler == nil {
+		return ""
+	}
+	st := s.scheduler.State()
+
+	var parts []string
+	switch {
+	case st.Status == v1.StatusSynchronizing:
+		parts = append(parts, "syncing"+ellipsis)
+	case st.LastError != nil:
+		parts = append(parts, ui.RedFg("sync failed: "+text.TruncateWithTail(st.LastError.Error(), syncErrorWidth, text.Ellipsis)))
+	}
+	if !st.LastSuccess.IsZero() {
+		parts = append(parts, ui.DarkGrayFg("synced "+text.RelativeTime(st.LastSuccess)))
+	}
+	if st.LastError != nil && !st.Next.IsZero() {
+		parts = append(parts, ui.DarkGrayFg(fmt.Sprintf("retrying in %s", time.Until(st.Next).Round(time.Second))))
+	}
+	return strings.Join(parts, dividerDot)
+}
+
 func (s *section) TabTitle() string {
 	if s.DocBackend == nil {
 		return s.name
 	}
-
-	itemType := s.DocBackend.DocType().String()
-	items, err := s.DocBackend.List()
-	if err != nil {
+	if s.err != nil {
 		return fmt.Sprintf("!! %s", s.name)
 	}
-	t := itemType
-	if len(items) > 1 {
-		t = t + "s"
+	if !s.listed {
+		return fmt.Sprintf("%s %s", s.name, ellipsis)
+	}
+
+	t := s.DocBackend.DocType().String()
+	if len(s.docs) > 1 {
+		t = s.DocBackend.DocType().Plural()
+	}
+	return fmt.Sprintf("%d %s", len(s.docs), t)
+}
+
+// putDoc keeps d in place of the document listed with the same identifier, or
+// adds it, newest first. A list in flight may not have d yet, so the section
+// is listed again once it is done.
+func (s *section) putDoc(d db.Doc) {
+	if s.listing {
+		s.stale = true
+	}
+	if !s.listed {
+		return
+	}
+	docs := make([]db.Doc, 0, len(s.docs)+1)
+	for _, existing := range s.docs {
+		if existing.Identifier() != d.Identifier() {
+			docs = append(docs, existing)
+		}
+	}
+	docs = append(docs, d)
+	sort.Stable(db.DocsByCreated(docs))
+	s.docs = docs
+}
+
+// dropDoc removes the document listed with the identifier
+func (s *section) dropDoc(id types.DocIdentifier) {
+	if s.listing {
+		s.stale = true
+	}
+	docs := make([]db.Doc, 0, len(s.docs))
+	for _, existing := range s.docs {
+		if existing.Identifier() != id {
+			docs = append(docs, existing)
+		}
+	}
+	s.docs = docs
+}
+
+// request returns the context of a request to the backend, which is done once
+// it times out, the user leaves the section or jot quits. cancel must be
+// called once the request is done, and inFlight decremented once its outcome
+// is handled.
+func (s *section) request(parent context.Context) (ctx context.Context, cancel context.CancelFunc) {
+	if s.requests == nil {
+		s.requests, s.cancelRequests = context.WithCancel(parent)
+	}
+	s.inFlight++
+	return context.WithTimeout(s.requests, s.timeout)
+}
+
+// leave cancels the requests in flight, as the user left the section
+func (s *section) leave() {
+	if s.cancelRequests != nil {
+		s.cancelRequests()
 	}
-	return fmt.Sprintf("%d %s", len(items), t)
+	s.requests, s.cancelRequests = nil, nil
 }
@@ -0,0 +1,279 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/byxorna/jot/pkg/config"
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/plugins/fs"
+	"github.com/byxorna/jot/pkg/plugins/memory"
+	"github.com/byxorna/jot/pkg/types/v1"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newScratchModel returns the UI of two scratch sections, the first holding
+// the notes titled
+func newScratchModel(t *testing.T, titles ...string) (*Model, *memory.Store) {
+	cfg := config.Default
+	cfg.Sections = []config.Section{
+		{Name: "scratch", Plugin: config.PluginTypeScratch},
+		{Name: "other", Plugin: config.PluginTypeScratch},
+	}
+	m, err := newModel(context.Background(), &cfg, "a", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	m.common.width, m.common.height = 80, 24
+
+	store := m.stashModel.sections[0].DocBackend.(*memory.Store)
+	for i, title := range titles {
+		n := NewNamedNote(title, nil, time.Date(2021, 6, 1+i, 12, 0, 0, 0, time.UTC), "a")
+		n.Content += fmt.Sprintf("- [ ] %s\n", title)
+		if _, err := store.Create(context.Background(), n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m, store
+}
+
+// listSection lists the section the way listSectionCmd does
+func listSection(t *testing.T, m *Model, s *section) {
+	t.Helper()
+	ctx, cancel := s.request(m.common.ctx)
+	defer cancel()
+	docs, err := s.DocBackend.List(ctx)
+	m.update(requestDoneMsg{section: s, msg: sectionListedMsg{section: s, docs: docs, err: err}})
+}
+
+func titles(items []*stashItem) []string {
+	l := make([]string, len(items))
+	for i, md := range items {
+		l[i] = md.Title()
+	}
+	return l
+}
+
+func TestStashListsSection(t *testing.T) {
+	m, _ := newScratchModel(t, "groceries", "laundry", "taxes")
+	s := m.stashModel.focusedSection()
+	if s.TabTitle() != "scratch …" {
+		t.Errorf("expected the section to be loading before it is listed, but got %q", s.TabTitle())
+	}
+
+	listSection(t, m, s)
+	if s.inFlight != 0 || !s.listed {
+		t.Errorf("expected the listing to be done, but %d requests are in flight", s.inFlight)
+	}
+	if got := titles(m.stashModel.getVisibleStashItems()); strings.Join(got, ",") != "taxes,laundry,groceries" {
+		t.Errorf("expected the notes newest first but got %v", got)
+	}
+	if view := m.stashModel.View(); !strings.Contains(view, "taxes") || !strings.Contains(view, "other") {
+		t.Errorf("expected the notes and every section to be shown, but got\n%s", view)
+	}
+
+	// the other section is listed when it is first shown
+	cmd := m.stashModel.focus(1)
+	other := m.stashModel.focusedSection()
+	if cmd == nil || !other.listing {
+		t.Errorf("expected %s to be listed once focused", other.Identifier())
+	}
+	listSection(t, m, other)
+	if n := len(m.stashModel.getVisibleStashItems()); n != 0 {
+		t.Errorf("expected %s to be empty but got %d notes", other.Identifier(), n)
+	}
+}
+
+func TestStashFollowsChanges(t *testing.T) {
+	m, store := newScratchModel(t, "groceries", "laundry")
+	s := m.stashModel.focusedSection()
+	listSection(t, m, s)
+	changes := store.Watch()
+
+	docs, _ := store.List(context.Background())
+	if err := store.Delete(context.Background(), docs[0].Identifier()); err != nil {
+		t.Fatal(err)
+	}
+	c := <-changes
+	if c.Kind != db.DocRemoved {
+		t.Fatalf("expected the note to be removed but got %s", c.Kind)
+	}
+	m.update(docChangeMsg{section: s, changes: changes, Change: c})
+	if s.listing {
+		t.Error("expected the removal to be applied without listing the section again")
+	}
+	if got := titles(m.stashModel.getVisibleStashItems()); len(got) != 1 || got[0] != "groceries" {
+		t.Errorf("expected only groceries to be left but got %v", got)
+	}
+
+	// notes added and updated are read on their own
+	n := NewNamedNote("laundry", nil, time.Date(2021, 6, 5, 12, 0, 0, 0, time.UTC), "a")
+	if _, err := store.Create(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	m.update(docChangeMsg{section: s, changes: changes, Change: <-changes})
+	groceries := docs[1].(*v1.Note)
+	updated := *groceries
+	updated.Content += "- [ ] milk\n"
+	if _, err := store.Update(context.Background(), &updated); err != nil {
+		t.Fatal(err)
+	}
+	m.update(docChangeMsg{section: s, changes: changes, Change: <-changes})
+	if s.listing {
+		t.Error("expected the changes to be applied without listing the section again")
+	}
+	items := m.stashModel.getVisibleStashItems()
+	if got := titles(items); strings.Join(got, ",") != "laundry,groceries" {
+		t.Fatalf("expected the new note first but got %v", got)
+	}
+	if !strings.Contains(items[1].UnformattedContent(), "milk") {
+		t.Errorf("expected groceries to be updated but got %q", items[1].UnformattedContent())
+	}
+
+	// conflicts are told, leaving the notes as they are
+	m.update(docChangeMsg{section: s, changes: changes, Change: db.Change{Kind: db.DocConflicted, ID: items[1].Identifier()}})
+	if msg := m.stashModel.statusMessage.message; !strings.Contains(msg, "groceries could not be merged") {
+		t.Errorf("expected the conflict to be told but got %q", msg)
+	}
+	if n := len(m.stashModel.getVisibleStashItems()); n != 2 {
+		t.Errorf("expected the notes to be left as they are but got %d", n)
+	}
+
+	// the section is listed again when changes were lost
+	m.update(docChangeMsg{section: s, changes: changes, Change: db.Change{Kind: db.ChangesLost}})
+	if !s.listing {
+		t.Error("expected the section to be listed again when changes were lost")
+	}
+}
+
+func TestPagerShowsDocument(t *testing.T) {
+	m, store := newScratchModel(t, "groceries")
+	docs, _ := store.List(context.Background())
+	md := AsStashItem(docs[0], store)
+
+	m.pagerModel.update(stashItemUpdateMsg(md))
+	if m.pagerModel.currentDocument != md {
+		t.Fatal("expected the note to be shown")
+	}
+	msg := renderWithGlamour(m.pagerModel, m.pagerModel.currentDocument.UnformattedContent())()
+	rendered, ok := msg.(contentRenderedMsg)
+	if !ok {
+		t.Fatalf("expected the note to be rendered but got %v", msg)
+	}
+	if !strings.Contains(string(rendered), "groceries") {
+		t.Errorf("expected the rendered note to tell its tasks, but got %q", rendered)
+	}
+
+	// notes not stored in a file are edited through their backend
+	if p := store.StoragePathDoc(md.Identifier()); p != "" {
+		t.Errorf("expected no file for %s but got %s", md.Identifier(), p)
+	}
+	if cmd := m.stashModel.updateContentCmd(m.stashModel.focusedSection(), md, md.Doc.UnformattedContent()); cmd != nil {
+		t.Errorf("expected a note left as it was not to be updated")
+	}
+}
+
+func TestEverythingSection(t *testing.T) {
+	cfg := config.Default
+	cfg.Sections = []config.Section{
+		{Name: "all", Plugin: config.PluginTypeEverything},
+		{Name: "scratch", Plugin: config.PluginTypeScratch},
+		{Name: "other", Plugin: config.PluginTypeScratch},
+	}
+	m, err := newModel(context.Background(), &cfg, "a", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	m.common.width, m.common.height = 80, 24
+
+	stores := []*memory.Store{
+		m.stashModel.sections[1].DocBackend.(*memory.Store),
+		m.stashModel.sections[2].DocBackend.(*memory.Store),
+	}
+	for i, title := range []string{"groceries", "laundry", "taxes"} {
+		n := NewNamedNote(title, nil, time.Date(2021, 6, 1+i, 12, 0, 0, 0, time.UTC), "a")
+		if _, err := stores[i%2].Create(context.Background(), n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := m.stashModel.focusedSection()
+	listSection(t, m, s)
+	items := m.stashModel.getVisibleStashItems()
+	if got := titles(items); strings.Join(got, ",") != "taxes,laundry,groceries" {
+		t.Errorf("expected the notes of every section newest first but got %v", got)
+	}
+	if s.TabTitle() != "3 everything" {
+		t.Errorf("expected the tab to count everything but got %q", s.TabTitle())
+	}
+	if view := m.stashModel.View(); !strings.Contains(view, "other laundry") {
+		t.Errorf("expected the notes to tell their section, but got\n%s", view)
+	}
+
+	// actions go to the section the note is from
+	laundry := items[1]
+	if laundry.DocBackend != stores[1] || laundry.section != "other" {
+		t.Fatalf("expected laundry to be from other, but got %s", laundry.section)
+	}
+	if err := laundry.DocBackend.Delete(context.Background(), laundry.Identifier()); err != nil {
+		t.Fatalf("unable to delete laundry from other: %v", err)
+	}
+	listSection(t, m, s)
+	if got := titles(m.stashModel.getVisibleStashItems()); strings.Join(got, ",") != "taxes,groceries" {
+		t.Errorf("expected laundry to be gone but got %v", got)
+	}
+
+	// filtering matches the notes of every section
+	m.stashModel.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	for _, r := range "tax" {
+		m.stashModel.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	filtered := m.stashModel.focusedSection()
+	if filtered.Identifier() != filterSectionID {
+		t.Fatalf("expected the filter to be shown but got %s", filtered.Identifier())
+	}
+	listSection(t, m, filtered)
+	got := m.stashModel.getVisibleStashItems()
+	if len(got) != 1 || got[0].Title() != "taxes" || got[0].DocBackend != stores[0] {
+		t.Errorf("expected only taxes from scratch to match but got %v", titles(got))
+	}
+}
+
+func TestNotebookOfFocus(t *testing.T) {
+	cfg := config.Default
+	cfg.Directory = t.TempDir()
+	cfg.Sections = []config.Section{
+		{Name: "all", Plugin: config.PluginTypeEverything},
+		{Name: "work", Plugin: config.PluginTypeNotes, Settings: map[string]string{fs.SettingDirectory: "work"}},
+		{Name: "home", Plugin: config.PluginTypeNotes, Settings: map[string]string{fs.SettingDirectory: "home"}},
+	}
+	m, err := newModel(context.Background(), &cfg, "a", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	m.common.width, m.common.height = 80, 24
+
+	work := m.stashModel.sections[1].DocBackend.(*fs.Store)
+	home := m.stashModel.sections[2].DocBackend.(*fs.Store)
+	n := NewNamedNote("groceries", nil, time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC), "a")
+	if _, err := home.Create(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+
+	// an everything section has the notebook of the focused note
+	listSection(t, m, m.stashModel.focusedSection())
+	if store, ok := m.stashModel.notebook(); !ok || store != home {
+		t.Errorf("expected the notebook of groceries to be home")
+	}
+
+	m.stashModel.sectionIndex = 1
+	if store, ok := m.stashModel.notebook(); !ok || store != work {
+		t.Errorf("expected the notebook of the work section to be work")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/byxorna/jot/pkg/db"
 	"github.com/byxorna/jot/pkg/ui"
 	lib "github.com/charmbracelet/charm/ui/common"
 	"github.com/muesli/reflow/ansi"
@@ -107,7 +108,12 @@ func (m stashModel) helpView() (string, int) {
 	case selectionSettingNote:
 		return m.renderHelp([]string{"enter", "confirm", "esc", "cancel"}, []string{"q", "quit"})
 	case selectionPromptingDelete:
-		return m.renderHelp([]string{"y", "delete", "n", "cancel"}, []string{"q", "quit"})
+		if !m.deletesToTrash() {
+			return m.renderHelp([]string{"y", "delete for good", "n", "cancel"})
+		}
+		return m.renderHelp([]string{"y", "move to trash", "n", "cancel"})
+	case selectionUnlocking:
+		return m.renderHelp([]string{"enter", "unlock", "esc", "stay locked"}, []string{"ctrl+c", "quit"})
 	}
 
 	var (
@@ -141,10 +147,34 @@ func (m stashModel) helpView() (string, int) {
 		filterHelp = []string{"/", "find"}
 	}
 
-	selectionHelp = []string{"v", "view", "e", "edit", "r", "reload"}
-	switch m.focusedSection().Identifier() {
-	case "notes":
-		sectionHelp = append(sectionHelp, "o", "create new entry")
+	// documents are edited and deleted through the backend they are from,
+	// which in an everything section is not the one of the section
+	backend := m.focusedSection().DocBackend
+	item := backend
+	if md, err := m.CurrentStashItem(); err == nil && md.DocBackend != nil {
+		item = md.DocBackend
+	}
+	selectionHelp = []string{"v", "view"}
+	if db.Can(item, db.CanUpdate) {
+		selectionHelp = append(selectionHelp, "e", "edit")
+	}
+	selectionHelp = append(selectionHelp, "r", "reload")
+	if db.Can(backend, db.CanCreate) {
+		sectionHelp = append(sectionHelp, "n", "new named note")
+	}
+	if db.Can(item, db.CanDelete) {
+		sectionHelp = append(sectionHelp, "x", "delete")
+	}
+	store, notebook := m.notebook()
+	if notebook {
+		sectionHelp = append([]string{"o", "create new entry"}, sectionHelp...)
+		sectionHelp = append(sectionHelp, "a", "archive", "R", "history", "C", "resolve conflicts")
+		if store.IsGit() {
+			sectionHelp = append(sectionHelp, "B", "git log")
+		}
+		if store.Locked() {
+			sectionHelp = append(sectionHelp, "U", "unlock")
+		}
 	}
 
 	// If there are errors
@@ -152,6 +182,10 @@ func (m stashModel) helpView() (string, int) {
 		appHelp = append(appHelp, "!", "errors")
 	}
 
+	if notebook {
+		appHelp = append(appHelp, "S", "stats")
+	}
+
 	appHelp = append(appHelp, "q", "quit")
 
 	// Detailed help
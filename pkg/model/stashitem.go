@@ -2,6 +2,7 @@
 package model
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"github.com/byxorna/jot/pkg/text"
 	"github.com/byxorna/jot/pkg/ui"
 	lib "github.com/charmbracelet/charm/ui/common"
+	"github.com/muesli/reflow/ansi"
 	"github.com/muesli/termenv"
 	"github.com/sahilm/fuzzy"
 )
@@ -28,6 +30,9 @@ type stashItem struct {
 
 	db.Doc
 	db.DocBackend
+	// section is the name of the section the document is from, when it is
+	// listed in an everything section
+	section string
 }
 
 // Generate the value we're doing to filter against.
@@ -40,12 +45,26 @@ func (m *stashItem) buildFilterValue() {
 	}
 }
 
-func stashItemView(commonWidth int, isSelected bool, isFiltering bool, filterText string, visibleItemsCount int, doc db.Doc) string {
+// loadContent reads the content of a document that its backend listed
+// without reading it
+func (m *stashItem) loadContent(ctx context.Context) error {
+	if _, ok := m.DocBackend.(db.DocBackendLazy); !ok {
+		return nil
+	}
+	d, err := m.DocBackend.Get(ctx, m.Doc.Identifier(), false)
+	if err != nil {
+		return err
+	}
+	m.Doc = d
+	return nil
+}
+
+func stashItemView(commonWidth int, isSelected bool, isFiltering bool, filterText string, visibleItemsCount int, section string, doc db.Doc) string {
 
-	//title / summary / body / links / icon
+	//section / title / summary / body / links / icon
 
 	var (
-		truncateTo   = uint(commonWidth - stashViewHorizontalPadding*2)
+		truncateTo   = uint(max(1, commonWidth-stashViewHorizontalPadding*2-badgeWidth(section)))
 		gutter       string
 		title        = text.TruncateWithTail(doc.Title(), truncateTo, text.Ellipsis)
 		summary      = doc.Summary()
@@ -84,8 +103,13 @@ func stashItemView(commonWidth int, isSelected bool, isFiltering bool, filterTex
 		}
 	}
 
+	var badge string
+	if section != "" {
+		badge = tertiaryColor(section) + " "
+	}
+
 	lines := []string{
-		fmt.Sprintf("%s %s %s", gutter, primaryColor(title), icon),
+		fmt.Sprintf("%s %s%s %s", gutter, badge, primaryColor(title), icon),
 		fmt.Sprintf("%s %s", gutter, secondaryColor(summary)),
 	}
 	for _, ctxline := range extracontext {
@@ -97,6 +121,15 @@ func stashItemView(commonWidth int, isSelected bool, isFiltering bool, filterTex
 	return strings.Join(lines, "\n")
 }
 
+// badgeWidth returns how wide the name of the section a document is from is
+// told before its title
+func badgeWidth(section string) int {
+	if section == "" {
+		return 0
+	}
+	return ansi.PrintableRuneWidth(section) + 1
+}
+
 // finds matching context line from the content of haystack and returns it, with
 // some buffer on either side to provide interesting context
 func getClosestMatchContextLine(haystack, needle string) string {
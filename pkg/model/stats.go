@@ -0,0 +1,55 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/byxorna/jot/pkg/plugins/fs"
+	"github.com/byxorna/jot/pkg/stats"
+	"github.com/byxorna/jot/pkg/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type statsReportMsg *stashItem
+
+// statsDoc is a read only document holding a rendered stats report, so it can
+// be shown in the pager like any other document
+type statsDoc struct {
+	content string
+	created time.Time
+}
+
+func (d *statsDoc) Identifier() types.DocIdentifier   { return "stats" }
+func (d *statsDoc) DocType() types.DocType            { return types.StatsDoc }
+func (d *statsDoc) MatchesFilter(string) bool         { return false }
+func (d *statsDoc) UnformattedContent() string        { return d.content }
+func (d *statsDoc) Created() time.Time                { return d.created }
+func (d *statsDoc) Modified() *time.Time              { return nil }
+func (d *statsDoc) Title() string                     { return "Stats" }
+func (d *statsDoc) Summary() string                   { return "" }
+func (d *statsDoc) ExtraContext() []string            { return []string{} }
+func (d *statsDoc) Body() string                      { return d.content }
+func (d *statsDoc) Links() map[string]string          { return map[string]string{} }
+func (d *statsDoc) Icon() string                      { return "" }
+func (d *statsDoc) Validate() error                   { return nil }
+func (d *statsDoc) SelectorTags() []string            { return []string{} }
+func (d *statsDoc) SelectorLabels() map[string]string { return map[string]string{} }
+
+// showStatsCmd builds a stats report of every note of the notebook
+func showStatsCmd(store *fs.Store, now time.Time) tea.Cmd {
+	return func() tea.Msg {
+		notes, err := store.ListAll()
+		if err != nil {
+			return errMsg{fmt.Errorf("unable to list entries: %w", err)}
+		}
+
+		b := strings.Builder{}
+		b.WriteString("# Stats\n\n```\n")
+		if err := stats.New(notes, now, stats.DefaultOptions).Render(&b); err != nil {
+			return errMsg{err}
+		}
+		b.WriteString("```\n")
+		return statsReportMsg(AsStashItem(&statsDoc{content: b.String(), created: now}, nil))
+	}
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/plugins/filter"
+	"github.com/byxorna/jot/pkg/plugins/fs"
+	"github.com/byxorna/jot/pkg/types/v1"
+	"github.com/byxorna/jot/pkg/ui"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type noteDeletedMsg struct {
+	md    *stashItem
+	trash bool
+	err   error
+}
+
+type noteArchivedMsg struct {
+	md  *stashItem
+	err error
+}
+
+// sourceOf returns the backend a document is stored in, also when it was found
+// by filtering
+func sourceOf(b db.DocBackend) db.DocBackend {
+	if f, ok := b.(*filter.FilteringBackend); ok {
+		return f.Source()
+	}
+	return b
+}
+
+// notebookOf returns the notebook a document of the stash is stored in, also
+// when it was found by filtering
+func notebookOf(md *stashItem) (*fs.Store, bool) {
+	store, ok := sourceOf(md.DocBackend).(*fs.Store)
+	return store, ok
+}
+
+// notebook returns the notebook of the focused section, or else the one the
+// focused document is stored in, like in an everything section
+func (m *stashModel) notebook() (*fs.Store, bool) {
+	if store, ok := sourceOf(m.focusedSection().DocBackend).(*fs.Store); ok {
+		return store, true
+	}
+	if md, err := m.CurrentStashItem(); err == nil {
+		return notebookOf(md)
+	}
+	return nil, false
+}
+
+// deletesToTrash returns whether deleting the current document moves it to the
+// trash of its notebook, rather than deleting it for good
+func (m *stashModel) deletesToTrash() bool {
+	md, err := m.CurrentStashItem()
+	if err != nil {
+		return false
+	}
+	_, ok := notebookOf(md)
+	return ok
+}
+
+func deletePromptView(trash bool) string {
+	if !trash {
+		return ui.RedFg("Delete this note for good? ") + ui.FaintRedFg("(y/N)")
+	}
+	return ui.RedFg("Move this note to the trash? ") + ui.FaintRedFg("(y/N)")
+}
+
+// deleteNoteCmd deletes the note of the section from its backend, which for
+// notebooks moves it to the trash
+func (m *stashModel) deleteNoteCmd(s *section, md *stashItem) tea.Cmd {
+	if !db.Can(md.DocBackend, db.CanDelete) {
+		return func() tea.Msg {
+			return noteDeletedMsg{md: md, err: fmt.Errorf("%s cannot be deleted: %w", md.Doc.DocType(), db.ErrReadOnly)}
+		}
+	}
+	_, trash := notebookOf(md)
+	return m.requestCmd(s, func(ctx context.Context) tea.Msg {
+		return noteDeletedMsg{md: md, trash: trash, err: md.DocBackend.Delete(ctx, md.Identifier())}
+	})
+}
+
+// toggleArchiveCmd archives the note, or unarchives an archived note
+func toggleArchiveCmd(md *stashItem) tea.Cmd {
+	return func() tea.Msg {
+		store, ok := notebookOf(md)
+		n, isNote := md.Doc.(*v1.Note)
+		if !ok || !isNote {
+			return noteArchivedMsg{md: md, err: fmt.Errorf("%s cannot be archived", md.Doc.DocType())}
+		}
+		archived, err := store.Archive(md.Identifier(), !n.Metadata.Archived)
+		if err != nil {
+			return noteArchivedMsg{md: md, err: err}
+		}
+		return noteArchivedMsg{md: AsStashItem(archived, md.DocBackend)}
+	}
+}
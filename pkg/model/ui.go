@@ -3,11 +3,14 @@ package model
 // Source: https://raw.githubusercontent.com/charmbracelet/glow/d0737b41af48960a341e24327d9d5acb5b7d92aa/ui/ui.go
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/byxorna/jot/pkg/db"
 	"github.com/byxorna/jot/pkg/types/v1"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
@@ -53,9 +56,7 @@ type stashFailMsg struct {
 	err      error
 	markdown stashItem
 }
-type stashItemCollectionReconcileMsg []*stashItem
 type stashItemUpdateMsg *stashItem
-type doReconcileStashItemMsg *stashItem
 
 // applicationContext indicates the area of the application something appies
 // to. Occasionally used as an argument to commands and messages.
@@ -81,15 +82,13 @@ func (s state) String() string {
 	}[s]
 }
 
-func doReconcileStashItemCmd(md *stashItem) tea.Cmd {
-	return func() tea.Msg { return doReconcileStashItemMsg(md) }
-}
-
 // Common stuff we'll need to access in all models.
 type commonModel struct {
 	cwd    string
 	width  int
 	height int
+	// ctx is done once jot quits, cancelling every request in flight
+	ctx context.Context
 }
 
 // unloadDocument unloads a document from the pager. Note that while this
@@ -111,10 +110,36 @@ func (m *Model) unloadDocument() []tea.Cmd {
 
 func (m Model) Init() tea.Cmd {
 	var cmds []tea.Cmd
-	cmds = append(cmds, spinner.Tick, m.ReloadNoteCollectionCmd())
+	m.startRefreshes()
+	cmds = append(cmds, spinner.Tick, m.listSectionsCmd(), m.watchSectionsCmd(), m.countConflictsCmd(), m.lockedNotebookCmd(), m.pullErrorsCmd())
 	return tea.Batch(cmds...)
 }
 
+// quit cancels the requests in flight, and quits
+func (m *Model) quit() (*Model, tea.Cmd) {
+	m.cancel()
+	return m, tea.Quit
+}
+
+// Close cancels the requests in flight and closes the backend of every
+// section, stopping their refreshes and waiting for the commits of notebooks
+// to be pushed
+func (m *Model) Close() error {
+	var err error
+	m.cancel()
+	for _, s := range m.stashModel.sections {
+		if s.scheduler != nil {
+			s.scheduler.Stop()
+		}
+		if c, ok := s.DocBackend.(io.Closer); ok {
+			if cerr := c.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}
+	return err
+}
+
 // Update handles messages emitted by the model
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	newModel, cmd := m.update(msg)
@@ -127,23 +152,31 @@ func (m *Model) update(msg tea.Msg) (*Model, tea.Cmd) {
 	// If there's been an error, any key exits
 	if m.fatalErr != nil {
 		if _, ok := msg.(tea.KeyMsg); ok {
-			return m, tea.Quit
+			return m.quit()
 		}
 	}
 
 	var cmds []tea.Cmd
 	focusedSection := m.focusedSection()
-	backend := focusedSection.DocBackend
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// pass all keys through while typing the title of a new note, or
+		// confirming a deletion
+		if m.state == stateShowStash && m.stashModel.selectionState != selectionIdle && msg.String() != "ctrl+c" {
+			newModel, cmd := m.stashModel.update(msg)
+			m.stashModel = newModel
+			return m, cmd
+		}
+
 		switch msg.String() {
 		case "o":
-			if m.focusedSection().Identifier() == "notes" {
+			// daily entries are kept in notebooks only
+			if store, ok := m.stashModel.notebook(); ok {
 				switch m.state {
 				case stateShowStash, stateShowDocument:
 					if m.stashModel.filterState != filtering && m.pagerModel.state == pagerStateBrowse {
-						newModel, cmd := m.stashModel.createTodayNote(m.Date)
+						newModel, cmd := m.stashModel.createTodayNote(store, m.Date)
 						m.stashModel = newModel
 						return m, cmd
 					}
@@ -158,11 +191,21 @@ func (m *Model) update(msg tea.Msg) (*Model, tea.Cmd) {
 		case "e":
 			switch m.state {
 			case stateShowStash, stateShowDocument:
+				if m.state == stateShowDocument && m.pagerModel.currentDocument != nil && m.pagerModel.currentDocument.DocBackend == nil {
+					// generated documents like stats have nothing to edit
+					break
+				}
 				if m.stashModel.filterState != filtering && m.pagerModel.state == pagerStateBrowse {
 					md, err := m.stashModel.CurrentStashItem()
 					if err != nil {
 						return m, errCmd(err)
 					}
+					if !db.Can(md.DocBackend, db.CanUpdate) {
+						return m, m.stashModel.newStatusMessage(statusMessage{
+							status:  subtleStatusMessage,
+							message: fmt.Sprintf("%s cannot be edited, %s is read only", md.Title(), md.DocBackend.StoragePath()),
+						})
+					}
 					return m, m.EditMarkdown(md)
 				}
 			}
@@ -175,12 +218,87 @@ func (m *Model) update(msg tea.Msg) (*Model, tea.Cmd) {
 				cmds = append(cmds,
 					m.stashModel.newStatusMessage(statusMessage{
 						status:  subtleStatusMessage,
-						message: fmt.Sprintf("Reloading %s %s from %s", focusedSection.DocType(), currentMd.Identifier(), backend.StoragePath()),
+						message: fmt.Sprintf("Reloading %s %s from %s", currentMd.Doc.DocType(), currentMd.Identifier(), currentMd.DocBackend.StoragePath()),
 					}),
-					doReconcileStashItemCmd(currentMd),
+					m.stashModel.reconcileCmd(focusedSection, currentMd),
 				)
 			}
 
+		case "S":
+			if store, ok := m.stashModel.notebook(); ok && m.state == stateShowStash && m.stashModel.filterState != filtering && m.stashModel.selectionState != selectionSettingNote {
+				m.state = stateShowDocument
+				return m, tea.Batch(spinner.Tick, showStatsCmd(store, m.Date))
+			}
+
+		case "C":
+			if store, ok := m.stashModel.notebook(); ok && m.state == stateShowStash && m.stashModel.filterState != filtering && m.stashModel.selectionState != selectionSettingNote {
+				return m, showConflictCmd(store, max(m.pagerModel.viewport.Width, m.common.width))
+			}
+
+		case "M", "T":
+			if m.state == stateShowDocument && m.pagerModel.state == pagerStateBrowse && m.pagerModel.currentDocument != nil {
+				if d, ok := m.pagerModel.currentDocument.Doc.(*conflictDoc); ok {
+					return m, resolveConflictCmd(d, msg.String() == "M")
+				}
+			}
+
+		case "R":
+			if m.stashModel.filterState == filtering || m.stashModel.selectionState == selectionSettingNote || m.pagerModel.state != pagerStateBrowse {
+				break
+			}
+			switch m.state {
+			case stateShowDocument:
+				if m.pagerModel.currentDocument == nil {
+					break
+				}
+				if d, ok := m.pagerModel.currentDocument.Doc.(*historyDoc); ok {
+					return m, restoreRevisionCmd(d)
+				}
+				if store, ok := notebookOf(m.pagerModel.currentDocument); ok {
+					return m, showHistoryCmd(store, m.pagerModel.currentDocument)
+				}
+			case stateShowStash:
+				md, err := m.stashModel.CurrentStashItem()
+				if err != nil {
+					return m, errCmd(err)
+				}
+				if store, ok := notebookOf(md); ok {
+					return m, showHistoryCmd(store, md)
+				}
+			}
+
+		case "B":
+			if m.stashModel.filterState == filtering || m.stashModel.selectionState == selectionSettingNote || m.pagerModel.state != pagerStateBrowse {
+				break
+			}
+			md := m.pagerModel.currentDocument
+			if m.state == stateShowStash {
+				var err error
+				if md, err = m.stashModel.CurrentStashItem(); err != nil {
+					return m, errCmd(err)
+				}
+			}
+			if md == nil {
+				break
+			}
+			if store, ok := notebookOf(md); ok && store.IsGit() {
+				return m, showGitCmd(store, md)
+			}
+
+		case "[", "]", "b":
+			if m.state == stateShowDocument && m.pagerModel.state == pagerStateBrowse && m.pagerModel.currentDocument != nil {
+				if d, ok := m.pagerModel.currentDocument.Doc.(*historyDoc); ok {
+					switch msg.String() {
+					case "[":
+						return m, selectRevisionCmd(d, 1, false)
+					case "]":
+						return m, selectRevisionCmd(d, -1, false)
+					default:
+						return m, selectRevisionCmd(d, 0, true)
+					}
+				}
+			}
+
 		case "enter", "v":
 			if m.state == stateShowStash && m.filterApplied() {
 				// pass event thru
@@ -222,7 +340,7 @@ func (m *Model) update(msg tea.Msg) (*Model, tea.Cmd) {
 				}
 			}
 
-			return m, tea.Quit
+			return m.quit()
 
 		case "left", "h", "delete":
 			if m.state == stateShowDocument && m.pagerModel.state != pagerStateSetNote {
@@ -232,7 +350,7 @@ func (m *Model) update(msg tea.Msg) (*Model, tea.Cmd) {
 
 		// Ctrl+C always quits no matter where in the application you are.
 		case "ctrl+c":
-			return m, tea.Quit
+			return m.quit()
 		}
 
 	// Window size is received when starting up and on every resize
@@ -251,7 +369,7 @@ func (m *Model) update(msg tea.Msg) (*Model, tea.Cmd) {
 	case contentRenderedMsg:
 		m.state = stateShowDocument
 
-	case stashItemCollectionReconcileMsg, stashItemUpdateMsg:
+	case stashItemUpdateMsg:
 		//switch m.state {
 		//case stateShowDocument:
 		newpm, cmd := m.pagerModel.update(msg)
@@ -263,80 +381,96 @@ func (m *Model) update(msg tea.Msg) (*Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 		//}
 
-	case filteredStashItemMsg:
+	case statsReportMsg:
+		newpm, cmd := m.pagerModel.update(stashItemUpdateMsg(msg))
+		m.pagerModel = newpm
+		cmds = append(cmds, cmd)
+
+	case conflictMsg:
+		m.state = stateShowDocument
+		newpm, cmd := m.pagerModel.update(stashItemUpdateMsg(msg))
+		m.pagerModel = newpm
+		cmds = append(cmds, spinner.Tick, cmd)
+
+	case historyMsg:
+		m.state = stateShowDocument
+		newpm, cmd := m.pagerModel.update(stashItemUpdateMsg(msg))
+		m.pagerModel = newpm
+		cmds = append(cmds, spinner.Tick, cmd)
+
+	case gitMsg:
+		m.state = stateShowDocument
+		newpm, cmd := m.pagerModel.update(stashItemUpdateMsg(msg))
+		m.pagerModel = newpm
+		cmds = append(cmds, spinner.Tick, cmd)
+
+	case revisionRestoredMsg:
+		cmds = append(cmds, m.handleRevisionRestored(msg)...)
+
+	case conflictCountMsg:
+		message := "No conflicts to resolve"
+		if msg > 0 {
+			message = fmt.Sprintf("%d notes have changes that could not be merged, press C to resolve", msg)
+		}
+		cmds = append(cmds, m.stashModel.newStatusMessage(statusMessage{
+			status:  normalStatusMessage,
+			message: message,
+		}))
+
+	case conflictResolvedMsg:
+		cmds = append(cmds, m.handleConflictResolved(msg)...)
+
+	case lockedMsg:
+		cmds = append(cmds, m.stashModel.promptUnlock(msg))
+
+	case unlockedMsg:
+		cmds = append(cmds, m.handleUnlocked(msg)...)
+
+	case filteredStashItemMsg, noteCreatedMsg:
 		if m.state == stateShowDocument {
 			newModel, cmd := m.stashModel.update(msg)
 			m.stashModel = newModel
 			cmds = append(cmds, cmd)
 		}
 
-	case doReconcileStashItemMsg:
-		var oldContent string
-		if msg != nil {
-			oldContent = msg.Doc.UnformattedContent()
-		}
-		reconciled, err := msg.DocBackend.Get(msg.Doc.Identifier(), true)
-		if err != nil {
+	case requestDoneMsg:
+		msg.section.inFlight--
+		return m.update(msg.msg)
+
+	case sectionListedMsg:
+		cmds = append(cmds, m.stashModel.handleSectionListed(msg))
+
+	case reconciledMsg:
+		oldContent := msg.md.Doc.UnformattedContent()
+		if msg.err != nil {
 			cmds = append(cmds,
 				m.stashModel.newStatusMessage(statusMessage{
 					status:  errorStatusMessage,
-					message: fmt.Sprintf("%s: unable to reconcile: %s", msg.Doc.Title(), err.Error()),
+					message: fmt.Sprintf("%s: unable to reconcile: %s", msg.md.Doc.Title(), msg.err.Error()),
 				}))
 		} else {
-			cmds = append(cmds,
-				func() tea.Msg { return contentDiffMsg{Old: oldContent, Current: reconciled.UnformattedContent()} },
-				doReconcileStashItemCmd(AsStashItem(reconciled, msg.DocBackend)),
-			)
+			item := AsStashItem(msg.doc, msg.md.DocBackend)
+			current := msg.doc.UnformattedContent()
+			cmds = append(cmds, func() tea.Msg { return contentDiffMsg{Old: oldContent, Current: current} })
+			if m.state == stateShowDocument {
+				// rerender the open document
+				cmds = append(cmds, func() tea.Msg { return stashItemUpdateMsg(item) })
+			} else {
+				m.stashModel.addMarkdowns(item)
+			}
 		}
 
+	case docChangeMsg:
+		cmds = append(cmds, m.handleDocChange(msg)...)
+
 		// someone changed the rendered content, so lets seem if we can figure out anything interesting
 		// to report as a motivation
 	case contentDiffMsg:
-		diff := contentDiffMsg(msg)
-		oldtls := v1.TaskList(diff.Old)
-		currenttls := v1.TaskList(diff.Current)
-
-		totalDelta := currenttls.Total - oldtls.Total
-		checkedDelta := currenttls.Checked - oldtls.Checked
-		pctDeltaString := fmt.Sprintf("%+.f%%", (currenttls.Percent()-oldtls.Percent())*100.0)
-
-		// TODO: DRY this up
-		if totalDelta == 0 {
-			if checkedDelta > 0 {
-				if currenttls.Percent() > .95 {
-					cmds = append(cmds, m.stashModel.newStatusMessage(statusMessage{
-						status:  normalStatusMessage,
-						message: fmt.Sprintf("Well done! %+d tasks completed (%s)", checkedDelta, currenttls.PercentString()),
-					}))
-				} else {
-					cmds = append(cmds, m.stashModel.newStatusMessage(statusMessage{
-						status:  normalStatusMessage,
-						message: fmt.Sprintf("Keep going! %d tasks completed (%s)", checkedDelta, pctDeltaString),
-					}))
-				}
-			} else if checkedDelta < 0 {
-				cmds = append(cmds, m.stashModel.newStatusMessage(statusMessage{
-					status:  normalStatusMessage,
-					message: fmt.Sprintf("%d tasks unchecked (%s)", max(checkedDelta, -checkedDelta), pctDeltaString),
-				}))
-			}
-		} else {
-			if checkedDelta == 0 {
-				cmds = append(cmds, m.stashModel.newStatusMessage(statusMessage{
-					status:  normalStatusMessage,
-					message: fmt.Sprintf("%+d tasks", totalDelta),
-				}))
-			} else if checkedDelta > 0 {
-				cmds = append(cmds, m.stashModel.newStatusMessage(statusMessage{
-					status:  normalStatusMessage,
-					message: fmt.Sprintf("%+d tasks, %d tasks completed (%s)", totalDelta, checkedDelta, pctDeltaString),
-				}))
-			} else if checkedDelta < 0 {
-				cmds = append(cmds, m.stashModel.newStatusMessage(statusMessage{
-					status:  normalStatusMessage,
-					message: fmt.Sprintf("%+d tasks, %d tasks unchecked (%s)", totalDelta, checkedDelta, pctDeltaString),
-				}))
-			}
+		if delta := TaskDeltaMessage(msg.Old, msg.Current); delta != "" {
+			cmds = append(cmds, m.stashModel.newStatusMessage(statusMessage{
+				status:  normalStatusMessage,
+				message: delta,
+			}))
 		}
 
 	}
@@ -357,6 +491,38 @@ func (m *Model) update(msg tea.Msg) (*Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// TaskDeltaMessage describes how the tasks changed between the old and current
+// content of a document, as a bit of motivation. It is empty if nothing changed.
+func TaskDeltaMessage(old, current string) string {
+	oldtls := v1.TaskList(old)
+	currenttls := v1.TaskList(current)
+
+	totalDelta := currenttls.Total - oldtls.Total
+	checkedDelta := currenttls.Checked - oldtls.Checked
+	pctDeltaString := fmt.Sprintf("%+.f%%", (currenttls.Percent()-oldtls.Percent())*100.0)
+
+	if totalDelta == 0 {
+		switch {
+		case checkedDelta > 0 && currenttls.Percent() > .95:
+			return fmt.Sprintf("Well done! %+d tasks completed (%s)", checkedDelta, currenttls.PercentString())
+		case checkedDelta > 0:
+			return fmt.Sprintf("Keep going! %d tasks completed (%s)", checkedDelta, pctDeltaString)
+		case checkedDelta < 0:
+			return fmt.Sprintf("%d tasks unchecked (%s)", -checkedDelta, pctDeltaString)
+		}
+		return ""
+	}
+
+	switch {
+	case checkedDelta > 0:
+		return fmt.Sprintf("%+d tasks, %d tasks completed (%s)", totalDelta, checkedDelta, pctDeltaString)
+	case checkedDelta < 0:
+		return fmt.Sprintf("%+d tasks, %d tasks unchecked (%s)", totalDelta, -checkedDelta, pctDeltaString)
+	default:
+		return fmt.Sprintf("%+d tasks", totalDelta)
+	}
+}
+
 func (m Model) View() string {
 	if m.fatalErr != nil {
 		return errorView(m.fatalErr, true)
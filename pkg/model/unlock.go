@@ -0,0 +1,99 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/byxorna/jot/pkg/plugins/fs"
+	"github.com/byxorna/jot/pkg/ui"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// lockedMsg is a notebook with sealed notes that was not unlocked yet
+type lockedMsg *fs.Store
+
+type unlockedMsg struct {
+	store *fs.Store
+	err   error
+}
+
+// lockedNotebookCmd finds the first notebook section still locked, so the
+// passphrase is asked for once per session
+func (m *stashModel) lockedNotebookCmd() tea.Cmd {
+	stores := []*fs.Store{}
+	for _, s := range m.sections {
+		if store, ok := s.DocBackend.(*fs.Store); ok {
+			stores = append(stores, store)
+		}
+	}
+	return func() tea.Msg {
+		for _, store := range stores {
+			if store.Locked() {
+				return lockedMsg(store)
+			}
+		}
+		return nil
+	}
+}
+
+// promptUnlock asks for the passphrase of the notebook
+func (m *stashModel) promptUnlock(store *fs.Store) tea.Cmd {
+	m.hideStatusMessage()
+	m.unlocking = store
+	m.selectionState = selectionUnlocking
+	m.passphraseInput.SetValue("")
+	m.passphraseInput.CursorEnd()
+	return textinput.Blink
+}
+
+func unlockPromptView(input textinput.Model) string {
+	return ui.FaintRedFg("Locked ") + input.View()
+}
+
+// Updates for when a user is typing the passphrase of a locked notebook.
+func (m *stashModel) handleUnlockPrompt(msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc":
+			// sealed notes stay locked, until U is pressed
+			m.selectionState = selectionIdle
+			return nil
+		case "enter":
+			m.selectionState = selectionIdle
+			store, passphrase := m.unlocking, m.passphraseInput.Value()
+			m.passphraseInput.SetValue("")
+			return func() tea.Msg {
+				return unlockedMsg{store: store, err: store.UnlockWith(passphrase)}
+			}
+		}
+	}
+
+	newPassphraseInputModel, cmd := m.passphraseInput.Update(msg)
+	m.passphraseInput = newPassphraseInputModel
+	return cmd
+}
+
+// handleUnlocked asks for the passphrase of the next locked notebook once the
+// notebook is unlocked, or for the same passphrase again
+func (m *Model) handleUnlocked(msg unlockedMsg) []tea.Cmd {
+	if errors.Is(msg.err, fs.ErrWrongPassphrase) {
+		return []tea.Cmd{m.stashModel.promptUnlock(msg.store), m.stashModel.newStatusMessage(statusMessage{
+			status:  errorStatusMessage,
+			message: "Wrong passphrase",
+		})}
+	}
+	if msg.err != nil {
+		return []tea.Cmd{m.stashModel.newStatusMessage(statusMessage{
+			status:  errorStatusMessage,
+			message: fmt.Sprintf("Unable to unlock %s: %v", msg.store.StoragePath(), msg.err),
+		})}
+	}
+	return []tea.Cmd{
+		m.stashModel.lockedNotebookCmd(),
+		m.stashModel.newStatusMessage(statusMessage{
+			status:  normalStatusMessage,
+			message: fmt.Sprintf("Unlocked %s", msg.store.StoragePath()),
+		}),
+	}
+}
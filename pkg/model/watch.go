@@ -0,0 +1,115 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// docChangeMsg is a document of a section added, updated or removed, or a
+// change to the status of its backend
+type docChangeMsg struct {
+	section *section
+	changes <-chan db.Change
+	db.Change
+}
+
+// watchSectionsCmd watches the backend of every section
+func (m *stashModel) watchSectionsCmd() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, s := range m.sections {
+		cmds = append(cmds, waitForDocChange(s, s.Watch()))
+	}
+	return tea.Batch(cmds...)
+}
+
+// waitForDocChange waits for the next change of the section. It needs to be
+// issued again after every docChangeMsg to keep watching.
+func waitForDocChange(s *section, changes <-chan db.Change) tea.Cmd {
+	return func() tea.Msg {
+		c, ok := <-changes
+		if !ok {
+			return nil
+		}
+		return docChangeMsg{section: s, changes: changes, Change: c}
+	}
+}
+
+// isOpen returns whether the document is open in the pager
+func (m *Model) isOpen(backend db.DocBackend, id types.DocIdentifier) bool {
+	open := m.pagerModel.currentDocument
+	return m.state == stateShowDocument && open != nil && sourceOf(open.DocBackend) == backend && open.Identifier() == id
+}
+
+// handleDocChange brings the section and the open document up to date with a
+// change to the backend of the section
+func (m *Model) handleDocChange(msg docChangeMsg) []tea.Cmd {
+	cmds := []tea.Cmd{waitForDocChange(msg.section, msg.changes)}
+	backend := msg.section.DocBackend
+
+	switch msg.Kind {
+	case db.StatusChanged:
+		if msg.Err != nil {
+			cmds = append(cmds, m.stashModel.newStatusMessage(statusMessage{
+				status:  errorStatusMessage,
+				message: fmt.Sprintf("Unable to sync %s: %v", msg.section.Identifier(), msg.Err),
+			}))
+		}
+		return cmds
+	case db.DocConflicted:
+		name := "a note"
+		if d, err := backend.Get(m.common.ctx, msg.ID, false); err == nil {
+			name = d.Title()
+		}
+		return append(cmds, m.stashModel.newStatusMessage(statusMessage{
+			status:  errorStatusMessage,
+			message: fmt.Sprintf("Changes to %s could not be merged, press C to resolve", name),
+		}))
+	case db.ChangesLost:
+		// what changed is not known, so the section is listed again
+		cmds = append(cmds, m.stashModel.listSectionCmd(msg.section))
+		open := m.pagerModel.currentDocument
+		if open == nil {
+			return cmds
+		}
+		msg.Kind, msg.ID = db.DocUpdated, open.Identifier()
+	case db.DocRemoved:
+		msg.section.dropDoc(msg.ID)
+		m.stashModel.removeMarkdown(msg.ID)
+	default:
+		d, err := backend.Get(m.common.ctx, msg.ID, false)
+		if errors.Is(err, db.ErrNoNoteFound) {
+			// removed again since
+			msg.section.dropDoc(msg.ID)
+			m.stashModel.removeMarkdown(msg.ID)
+		} else if err == nil {
+			msg.section.putDoc(d)
+			m.stashModel.addMarkdowns(AsStashItem(d, backend))
+		}
+	}
+
+	if !m.isOpen(backend, msg.ID) {
+		return cmds
+	}
+	open := m.pagerModel.currentDocument
+	switch msg.Kind {
+	case db.DocRemoved:
+		cmds = append(cmds, m.unloadDocument()...)
+		cmds = append(cmds, m.stashModel.newStatusMessage(statusMessage{
+			status:  subtleStatusMessage,
+			message: fmt.Sprintf("%s was removed", open.Title()),
+		}))
+	default:
+		if d, err := backend.Get(m.common.ctx, msg.ID, false); err == nil {
+			oldContent := open.UnformattedContent()
+			cmds = append(cmds,
+				func() tea.Msg { return stashItemUpdateMsg(AsStashItem(d, open.DocBackend)) },
+				func() tea.Msg { return contentDiffMsg{Old: oldContent, Current: d.UnformattedContent()} },
+			)
+		}
+	}
+	return cmds
+}
@@ -19,7 +19,8 @@ import (
 )
 
 var (
-	// ReconciliationDuration is how often to refresh events from the API
+	// ReconciliationDuration is how long fetched events are listed before List
+	// fetches them again, when they are not refreshed in the background
 	ReconciliationDuration = time.Minute * 10
 	pluginName             = config.PluginTypeCalendar
 	// maxEventsInDay is how many events we query from google calendar per day
@@ -41,6 +42,9 @@ type Client struct {
 	eventList   []*Event
 	eventMap    map[types.DocIdentifier]*Event
 	lastFetched time.Time
+
+	// changes are told to watchers when the events are refreshed
+	changes db.Watchers
 }
 
 func New(ctx context.Context, client *http.Client, settings map[string]string, calendarIDs []string) (*Client, error) {
@@ -58,15 +62,44 @@ func New(ctx context.Context, client *http.Client, settings map[string]string, c
 		calendarIDs: calendarIDs,
 		eventMap:    map[types.DocIdentifier]*Event{},
 		eventList:   []*Event{},
+		status:      v1.StatusUninitialized,
 	}
 	return &c, nil
 }
 
-func (c *Client) DayEvents(t time.Time) ([]*Event, error) {
+// Watch returns a channel of the events added, updated and removed whenever
+// the events are refreshed
+func (c *Client) Watch() <-chan db.Change {
+	return c.changes.Watch()
+}
+
+// Refresh fetches the events again, returning how many there are
+func (c *Client) Refresh(ctx context.Context) (int, error) {
+	docs, err := c.fetchAndPopulateCollection(ctx, true)
+	return len(docs), err
+}
+
+// Close closes the channels of all watchers
+func (c *Client) Close() error {
+	c.changes.Close()
+	return nil
+}
+
+// setStatus tells watchers about a new status, or a failure to refresh. The
+// lock has already been claimed.
+func (c *Client) setStatus(status v1.SyncStatus, err error) {
+	if c.status == status && err == nil {
+		return
+	}
+	c.status = status
+	c.changes.Publish(db.Change{Kind: db.StatusChanged, Status: status, Err: err})
+}
+
+func (c *Client) DayEvents(ctx context.Context, t time.Time) ([]*Event, error) {
 	// search each calendar serially for the events
 	aggr := []*Event{}
 	for _, calID := range c.calendarIDs {
-		events, err := c.dayEvents(t, calID)
+		events, err := c.dayEvents(ctx, t, calID)
 		if err != nil {
 			return nil, err
 		}
@@ -77,7 +110,7 @@ func (c *Client) DayEvents(t time.Time) ([]*Event, error) {
 	return aggr, nil
 }
 
-func (c *Client) dayEvents(t time.Time, calendarID string) ([]*Event, error) {
+func (c *Client) dayEvents(ctx context.Context, t time.Time, calendarID string) ([]*Event, error) {
 	tMin := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 	// TODO: use the working hours from config instead
 	tMax := time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 0, 0, time.UTC)
@@ -90,6 +123,7 @@ func (c *Client) dayEvents(t time.Time, calendarID string) ([]*Event, error) {
 		TimeMax(tMax.Format(time.RFC3339)).
 		MaxResults(maxEventsInDay).
 		OrderBy("startTime").
+		Context(ctx).
 		Do()
 	if err != nil {
 		return nil, fmt.Errorf("unable to retrieve events from %s calendar: %w", calendarID, err)
@@ -124,19 +158,18 @@ func (c *Client) needsReconciliation() bool {
 	return c.lastFetched.Before(time.Now().Add(-ReconciliationDuration)) || c.eventList == nil
 }
 
-func (c *Client) Get(id types.DocIdentifier, hardread bool) (db.Doc, error) {
-	c.Lock()
-	defer c.Unlock()
-
+func (c *Client) Get(ctx context.Context, id types.DocIdentifier, hardread bool) (db.Doc, error) {
 	if hardread {
 		// refresh and inject into eventList
-		reconciledEvent, err := c.Reconcile(id)
+		reconciledEvent, err := c.Reconcile(ctx, id)
 		if err != nil {
 			return nil, err
 		}
 		return reconciledEvent, nil
 	}
 
+	c.RLock()
+	defer c.RUnlock()
 	for _, e := range c.eventList {
 		if e.Identifier() == id {
 			return e, nil
@@ -145,12 +178,12 @@ func (c *Client) Get(id types.DocIdentifier, hardread bool) (db.Doc, error) {
 	return nil, fmt.Errorf("no event found in cache with id=%s", id)
 }
 
-func (c *Client) Reconcile(id types.DocIdentifier) (db.Doc, error) {
-	_, err := c.fetchAndPopulateCollection(true)
+func (c *Client) Reconcile(ctx context.Context, id types.DocIdentifier) (db.Doc, error) {
+	_, err := c.fetchAndPopulateCollection(ctx, true)
 	if err != nil {
 		return nil, err
 	}
-	return c.Get(id, false)
+	return c.Get(ctx, id, false)
 }
 
 func (c *Client) reconcileSingleEventBroken(id types.DocIdentifier) (db.Doc, error) {
@@ -221,29 +254,63 @@ func (c *Client) addToCollection(e *Event) {
 	sort.Stable(eventsByCreationDate(c.eventList))
 }
 
-func (c *Client) List() ([]db.Doc, error) {
-	return c.fetchAndPopulateCollection(false)
+func (c *Client) List(ctx context.Context) ([]db.Doc, error) {
+	return c.fetchAndPopulateCollection(ctx, false)
 }
 
-func (c *Client) fetchAndPopulateCollection(hardread bool) ([]db.Doc, error) {
+func (c *Client) fetchAndPopulateCollection(ctx context.Context, hardread bool) ([]db.Doc, error) {
 	c.Lock()
-	defer c.Unlock()
+	if !c.needsReconciliation() && !hardread {
+		defer c.Unlock()
+		return c.docs(), nil
+	}
+	c.setStatus(v1.StatusSynchronizing, nil)
+	c.Unlock()
 
-	if c.needsReconciliation() || hardread {
-		events, err := c.DayEvents(time.Now())
-		if err != nil {
-			return nil, fmt.Errorf("unable to fetch events: %w", err)
-		}
-		c.lastFetched = time.Now()
-		c.status = v1.StatusOK
-		c.eventList = events
+	// the lock is not held while waiting on the API, so the events fetched
+	// before can still be read
+	events, err := c.DayEvents(ctx, time.Now())
+
+	c.Lock()
+	defer c.Unlock()
+	if err != nil {
+		err = fmt.Errorf("unable to fetch events: %w", err)
+		c.setStatus(v1.StatusError, err)
+		return nil, err
 	}
+	c.lastFetched = time.Now()
+	old := c.docs()
+	c.eventList = events
+	c.changes.Publish(db.Diff(old, c.docs())...)
+	c.setStatus(v1.StatusOK, nil)
 
+	return c.docs(), nil
+}
+
+func (c *Client) docs() []db.Doc {
 	docs := make([]db.Doc, len(c.eventList))
 	for i, e := range c.eventList {
 		docs[i] = db.Doc(e)
 	}
-	return docs, nil
+	return docs
+}
+
+// Capabilities tells that events are read only, as the calendar is only
+// authorized to read them
+func (c *Client) Capabilities() db.Capability {
+	return db.ReadOnly
+}
+
+func (c *Client) Create(ctx context.Context, d db.Doc) (db.Doc, error) {
+	return nil, fmt.Errorf("unable to create %s: %w", d.Title(), db.ErrReadOnly)
+}
+
+func (c *Client) Update(ctx context.Context, d db.Doc) (db.Doc, error) {
+	return nil, fmt.Errorf("unable to update %s: %w", d.Identifier(), db.ErrReadOnly)
+}
+
+func (c *Client) Delete(ctx context.Context, id types.DocIdentifier) error {
+	return fmt.Errorf("unable to delete %s: %w", id, db.ErrReadOnly)
 }
 
 func (c *Client) StoragePath() string {
@@ -255,8 +322,7 @@ func (c *Client) StoragePathDoc(id types.DocIdentifier) string {
 }
 
 func (c *Client) Status() v1.SyncStatus {
-	if c.needsReconciliation() {
-		c.status = v1.StatusSynchronizing
-	}
+	c.RLock()
+	defer c.RUnlock()
 	return c.status
 }
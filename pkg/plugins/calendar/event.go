@@ -96,7 +96,7 @@ func (e *Event) UnformattedContent() string {
 	sb.WriteString(
 		fmt.Sprintf("# **%s**\n", e.Title()) +
 			"\n" +
-			fmt.Sprintf("%s for %s", e.start.Local().Format("2006-02-01 15:03"), e.duration))
+			fmt.Sprintf("%s for %s", e.start.Local().Format("2006-01-02 15:04"), e.duration))
 	if e.body != "" {
 		sb.WriteString("\n\n" + fmt.Sprintf("> %s\n", e.body))
 	}
@@ -0,0 +1,306 @@
+// Package everything merges the documents of every other section into one
+// stream, newest first. Documents are told apart by the section they are from,
+// and writes to them go to the backend of that section.
+package everything
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/types"
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+// separator joins the name of a section to the identifier of one of its
+// documents. Identifiers are split at the first one, so section names must
+// not contain it.
+const separator = "/"
+
+// Source is a section whose documents are merged
+type Source struct {
+	Name    string
+	Backend db.DocBackend
+}
+
+// Doc is a document of a section, identified by the name of the section and
+// its identifier there
+type Doc struct {
+	db.Doc
+	Section string
+	Source  db.DocBackend
+}
+
+func (d *Doc) Identifier() types.DocIdentifier {
+	return types.DocIdentifier(d.Section + separator + d.Doc.Identifier().String())
+}
+
+// Backend merges the documents of its sources
+type Backend struct {
+	sources []Source
+
+	mu     sync.RWMutex
+	status v1.SyncStatus
+	listed int
+
+	changes  db.Watchers
+	watching sync.Once
+}
+
+// New returns a backend merging the documents of the sources
+func New(sources ...Source) *Backend {
+	return &Backend{sources: sources, status: v1.StatusUninitialized}
+}
+
+// source returns the source of the document identified, and its identifier
+// there
+func (b *Backend) source(id types.DocIdentifier) (Source, types.DocIdentifier, error) {
+	parts := strings.SplitN(id.String(), separator, 2)
+	if len(parts) == 2 {
+		for _, s := range b.sources {
+			if s.Name == parts[0] {
+				return s, types.DocIdentifier(parts[1]), nil
+			}
+		}
+	}
+	return Source{}, "", fmt.Errorf("%s: %w", id, db.ErrNoNoteFound)
+}
+
+func wrap(s Source, d db.Doc) db.Doc {
+	return &Doc{Doc: d, Section: s.Name, Source: s.Backend}
+}
+
+// Sources returns the sections whose documents are merged
+func (b *Backend) Sources() []Source { return b.sources }
+
+func (b *Backend) DocType() types.DocType { return types.AllDocs }
+
+func (b *Backend) Status() v1.SyncStatus {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.status
+}
+
+func (b *Backend) StoragePath() string { return b.DocType().String() }
+
+func (b *Backend) StoragePathDoc(id types.DocIdentifier) string {
+	s, sid, err := b.source(id)
+	if err != nil {
+		return ""
+	}
+	return s.Backend.StoragePathDoc(sid)
+}
+
+func (b *Backend) Count() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.listed
+}
+
+// List lists every source at once, returning their documents newest first.
+// Sources that cannot be listed are left out and told to watchers as a
+// failure to sync; List only fails if none can be listed.
+func (b *Backend) List(ctx context.Context) ([]db.Doc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	listed := make([][]db.Doc, len(b.sources))
+	errs := make([]error, len(b.sources))
+	var wg sync.WaitGroup
+	for i, s := range b.sources {
+		wg.Add(1)
+		go func(i int, s Source) {
+			defer wg.Done()
+			docs, err := s.Backend.List(ctx)
+			if err != nil {
+				errs[i] = fmt.Errorf("unable to list %s: %w", s.Name, err)
+				return
+			}
+			for _, d := range docs {
+				listed[i] = append(listed[i], wrap(s, d))
+			}
+		}(i, s)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	docs := []db.Doc{}
+	var failed []string
+	var err error
+	for i := range b.sources {
+		docs = append(docs, listed[i]...)
+		if errs[i] != nil {
+			failed = append(failed, errs[i].Error())
+			err = errs[i]
+		}
+	}
+	sort.Stable(db.DocsByCreated(docs))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(failed) > 0 && len(failed) == len(b.sources) {
+		b.setStatus(v1.StatusError, err)
+		return nil, err
+	}
+	b.listed = len(docs)
+	switch len(failed) {
+	case 0:
+		b.setStatus(v1.StatusOK, nil)
+	case 1:
+		b.setStatus(v1.StatusError, err)
+	default:
+		b.setStatus(v1.StatusError, fmt.Errorf("%s", strings.Join(failed, "; ")))
+	}
+	return docs, nil
+}
+
+// setStatus tells watchers about a new status, or a failure to list. The lock
+// has already been claimed.
+func (b *Backend) setStatus(status v1.SyncStatus, err error) {
+	if b.status == status && err == nil {
+		return
+	}
+	b.status = status
+	b.changes.Publish(db.Change{Kind: db.StatusChanged, Status: status, Err: err})
+}
+
+// Get reads the document from the backend of its section
+func (b *Backend) Get(ctx context.Context, id types.DocIdentifier, hardread bool) (db.Doc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s, sid, err := b.source(id)
+	if err != nil {
+		return nil, err
+	}
+	d, err := s.Backend.Get(ctx, sid, hardread)
+	if err != nil {
+		return nil, err
+	}
+	return wrap(s, d), nil
+}
+
+// LoadAll reads the content of every document of the sources listed without
+// reading it
+func (b *Backend) LoadAll() error {
+	for _, s := range b.sources {
+		if l, ok := s.Backend.(db.DocBackendLazy); ok {
+			if err := l.LoadAll(); err != nil {
+				return fmt.Errorf("unable to load %s: %w", s.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ListArchived returns the archived documents of every source, so they can
+// be searched
+func (b *Backend) ListArchived() ([]db.Doc, error) {
+	docs := []db.Doc{}
+	for _, s := range b.sources {
+		a, ok := s.Backend.(db.DocBackendArchive)
+		if !ok {
+			continue
+		}
+		archived, err := a.ListArchived()
+		if err != nil {
+			return nil, fmt.Errorf("unable to list archived %s: %w", s.Name, err)
+		}
+		for _, d := range archived {
+			docs = append(docs, wrap(s, d))
+		}
+	}
+	return docs, nil
+}
+
+// Capabilities tells the writes any of the sources supports
+func (b *Backend) Capabilities() db.Capability {
+	c := db.ReadOnly
+	for _, s := range b.sources {
+		c |= s.Backend.Capabilities()
+	}
+	return c
+}
+
+// Create stores a new document in the first section that can create it
+func (b *Backend) Create(ctx context.Context, d db.Doc) (db.Doc, error) {
+	for _, s := range b.sources {
+		if !db.Can(s.Backend, db.CanCreate) {
+			continue
+		}
+		created, err := s.Backend.Create(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+		return wrap(s, created), nil
+	}
+	return nil, fmt.Errorf("unable to create %s: %w", d.Title(), db.ErrReadOnly)
+}
+
+// Update stores a document listed from the backend in its section
+func (b *Backend) Update(ctx context.Context, d db.Doc) (db.Doc, error) {
+	wrapped, ok := d.(*Doc)
+	if !ok {
+		return nil, fmt.Errorf("unable to tell which section %s is from", d.Identifier())
+	}
+	s, _, err := b.source(wrapped.Identifier())
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.Backend.Update(ctx, wrapped.Doc)
+	if err != nil {
+		return nil, err
+	}
+	return wrap(s, updated), nil
+}
+
+// Delete deletes the document from the backend of its section
+func (b *Backend) Delete(ctx context.Context, id types.DocIdentifier) error {
+	s, sid, err := b.source(id)
+	if err != nil {
+		return err
+	}
+	return s.Backend.Delete(ctx, sid)
+}
+
+// Watch returns a channel of the documents added, updated and removed in
+// every section. Failures of a section to sync and conflicts are told by its
+// own backend, and only failures to list it here are told.
+func (b *Backend) Watch() <-chan db.Change {
+	ch := b.changes.Watch()
+	b.watching.Do(func() {
+		for _, s := range b.sources {
+			go b.forward(s, s.Backend.Watch())
+		}
+	})
+	return ch
+}
+
+// forward publishes the changes to the documents of the source, until it is
+// closed
+func (b *Backend) forward(s Source, changes <-chan db.Change) {
+	for c := range changes {
+		switch c.Kind {
+		case db.StatusChanged, db.DocConflicted:
+			continue
+		case db.ChangesLost:
+			b.changes.Publish(c)
+			continue
+		}
+		c.ID = types.DocIdentifier(s.Name + separator + c.ID.String())
+		b.changes.Publish(c)
+	}
+}
+
+// Close closes the channels of all watchers. The sources are left open, as
+// they belong to their own sections.
+func (b *Backend) Close() error {
+	b.changes.Close()
+	return nil
+}
@@ -0,0 +1,98 @@
+package everything
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/db/dbtest"
+	"github.com/byxorna/jot/pkg/plugins/memory"
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+func note(i int) *v1.Note {
+	return &v1.Note{
+		Metadata: v1.NoteMetadata{
+			Author:            "a",
+			Title:             fmt.Sprintf("note %d", i),
+			CreationTimestamp: time.Date(2021, 6, 1+i, 12, 0, 0, 0, time.UTC),
+		},
+		Content: fmt.Sprintf("# note %d\n", i),
+	}
+}
+
+func TestConformance(t *testing.T) {
+	dbtest.Run(t, dbtest.Harness{
+		New: func(t *testing.T, docs ...db.Doc) db.DocBackend {
+			// the documents are spread across two sections
+			var odd, even []db.Doc
+			for i, d := range docs {
+				if i%2 == 0 {
+					even = append(even, d)
+				} else {
+					odd = append(odd, d)
+				}
+			}
+			a, b := memory.New("a", even...), memory.New("b", odd...)
+			x := New(Source{Name: "a", Backend: a}, Source{Name: "b", Backend: b})
+			t.Cleanup(func() {
+				x.Close()
+				a.Close()
+				b.Close()
+			})
+			return x
+		},
+		Doc: func(i int) db.Doc { return note(i) },
+		Edit: func(d db.Doc) db.Doc {
+			w := *d.(*Doc)
+			e := *w.Doc.(*v1.Note)
+			e.Content += "\n- [ ] edited\n"
+			w.Doc = &e
+			return &w
+		},
+	})
+}
+
+// offline is a section that cannot be listed
+type offline struct {
+	*memory.Store
+}
+
+var errOffline = errors.New("offline")
+
+func (o offline) List(ctx context.Context) ([]db.Doc, error) { return nil, errOffline }
+
+func TestPartialList(t *testing.T) {
+	notes := memory.New("notes", note(0), note(2))
+	x := New(Source{Name: "notes", Backend: notes}, Source{Name: "today", Backend: offline{memory.New("today")}})
+	changes := x.Watch()
+
+	docs, err := x.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || docs[0].Title() != "note 2" {
+		t.Fatalf("expected the notes newest first but got %v", docs)
+	}
+	if c := <-changes; c.Kind != db.StatusChanged || !errors.Is(c.Err, errOffline) || x.Status() != v1.StatusError {
+		t.Errorf("expected the section that could not be listed to be told, but got %+v", c)
+	}
+
+	// writes go to the section the document is from
+	d := docs[0].(*Doc)
+	if d.Section != "notes" || d.Source != notes || d.Identifier().String() != "notes/"+d.Doc.Identifier().String() {
+		t.Errorf("expected %s to be told apart by its section", d.Identifier())
+	}
+	if err := x.Delete(context.Background(), d.Identifier()); err != nil {
+		t.Fatal(err)
+	}
+	if notes.Count() != 1 {
+		t.Errorf("expected the note to be deleted from its section")
+	}
+	if c := <-changes; c.Kind != db.DocRemoved || c.ID != d.Identifier() {
+		t.Errorf("expected the removal to be watched as %s but got %+v", d.Identifier(), c)
+	}
+}
@@ -1,8 +1,10 @@
 package filter
 
 import (
+	"context"
 	"fmt"
 	"sort"
+	"sync"
 
 	"github.com/byxorna/jot/pkg/db"
 	"github.com/byxorna/jot/pkg/types"
@@ -10,6 +12,9 @@ import (
 )
 
 type FilteringBackend struct {
+	// mu guards the documents cached, which are listed in the background
+	mu sync.Mutex
+
 	source         db.DocBackend
 	filterSource   func() string
 	filterText     string
@@ -17,22 +22,22 @@ type FilteringBackend struct {
 	displayed      []db.Doc
 }
 
-func New(filterValue func() string, backend db.DocBackend) (*FilteringBackend, error) {
+func New(ctx context.Context, filterValue func() string, backend db.DocBackend) (*FilteringBackend, error) {
 	b := FilteringBackend{
 		source:       backend,
 		filterSource: filterValue,
 		filterText:   filterValue(),
 	}
 
-	err := b.hardPopulate()
+	err := b.hardPopulate(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("unable to populate filter: %w", err)
 	}
 	return &b, nil
 }
 
-func (b *FilteringBackend) hardPopulate() error {
-	docs, err := b.source.List()
+func (b *FilteringBackend) hardPopulate(ctx context.Context) error {
+	docs, err := db.Searchable(ctx, b.source)
 	if err != nil {
 		return err
 	}
@@ -40,19 +45,25 @@ func (b *FilteringBackend) hardPopulate() error {
 	return nil
 }
 
-func (b *FilteringBackend) cachedFilteredList() ([]db.Doc, error) {
+func (b *FilteringBackend) cachedFilteredList(ctx context.Context) ([]db.Doc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
 
 	// handle lazily populating from backend until we actually need this data
 	if b.cachedFullList == nil {
-		err := b.hardPopulate()
+		err := b.hardPopulate(ctx)
 		if err != nil {
 			return nil, err
 		}
+		b.displayed = nil
 	}
 
 	currentFilter := b.filterSource()
-	if currentFilter == b.filterText {
-		return b.cachedFullList, nil
+	if b.displayed != nil && currentFilter == b.filterText {
+		return b.displayed, nil
 	}
 
 	if currentFilter == "" {
@@ -80,22 +91,63 @@ func (b *FilteringBackend) cachedFilteredList() ([]db.Doc, error) {
 	return filtered, nil
 }
 
-func (b *FilteringBackend) DocType() types.DocType  { return b.source.DocType() }
-func (b *FilteringBackend) List() ([]db.Doc, error) { return b.cachedFilteredList() }
+// Source returns the backend being filtered
+func (b *FilteringBackend) Source() db.DocBackend { return b.source }
+
+func (b *FilteringBackend) DocType() types.DocType { return b.source.DocType() }
+func (b *FilteringBackend) List(ctx context.Context) ([]db.Doc, error) {
+	return b.cachedFilteredList(ctx)
+}
 func (b *FilteringBackend) Count() int {
-	cfl, err := b.cachedFilteredList()
+	b.mu.Lock()
+	listed := b.cachedFullList != nil
+	b.mu.Unlock()
+	if !listed {
+		// not listed again since a write
+		return 0
+	}
+	cfl, err := b.cachedFilteredList(context.Background())
 	if err != nil {
 		return -1
 	}
 	return len(cfl)
 }
 func (b *FilteringBackend) Status() v1.SyncStatus { return b.source.Status() }
-func (b *FilteringBackend) Get(id types.DocIdentifier, hardread bool) (db.Doc, error) {
-	return b.source.Get(id, hardread)
+func (b *FilteringBackend) Get(ctx context.Context, id types.DocIdentifier, hardread bool) (db.Doc, error) {
+	return b.source.Get(ctx, id, hardread)
 }
 func (b *FilteringBackend) Reconcile(id types.DocIdentifier) (db.Doc, error) {
 	return nil, fmt.Errorf("filter backend is readonly, cannot reconcile %s", id)
 }
+
+// Capabilities, Create, Update and Delete write to the backend being filtered,
+// which is read again when the filter is next applied
+func (b *FilteringBackend) Capabilities() db.Capability { return b.source.Capabilities() }
+func (b *FilteringBackend) Create(ctx context.Context, d db.Doc) (db.Doc, error) {
+	defer b.invalidate()
+	return b.source.Create(ctx, d)
+}
+func (b *FilteringBackend) Update(ctx context.Context, d db.Doc) (db.Doc, error) {
+	defer b.invalidate()
+	return b.source.Update(ctx, d)
+}
+func (b *FilteringBackend) Delete(ctx context.Context, id types.DocIdentifier) error {
+	defer b.invalidate()
+	return b.source.Delete(ctx, id)
+}
+
+// Watch returns the changes of the backend being filtered
+func (b *FilteringBackend) Watch() <-chan db.Change { return b.source.Watch() }
+
+// invalidate drops the cached documents, so they are listed and filtered again
+func (b *FilteringBackend) invalidate() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cachedFullList = nil
+	b.displayed = nil
+	b.filterText = ""
+}
+
 func (b *FilteringBackend) StoragePath() string { return b.source.StoragePath() }
 func (b *FilteringBackend) StoragePathDoc(id types.DocIdentifier) string {
 	return b.source.StoragePathDoc(id)
@@ -0,0 +1,64 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/db/dbtest"
+	"github.com/byxorna/jot/pkg/plugins/memory"
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+func note(i int, content string) *v1.Note {
+	return &v1.Note{
+		Metadata: v1.NoteMetadata{
+			Author:            "a",
+			Title:             fmt.Sprintf("note %d", i),
+			CreationTimestamp: time.Date(2021, 6, 1+i, 12, 0, 0, 0, time.UTC),
+		},
+		Content: content,
+	}
+}
+
+func TestConformance(t *testing.T) {
+	dbtest.Run(t, dbtest.Harness{
+		New: func(t *testing.T, docs ...db.Doc) db.DocBackend {
+			b, err := New(context.Background(), func() string { return "" }, memory.New("scratch", docs...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return b
+		},
+		Doc: func(i int) db.Doc { return note(i, fmt.Sprintf("# note %d\n", i)) },
+		Edit: func(d db.Doc) db.Doc {
+			e := *d.(*v1.Note)
+			e.Content += "\n- [ ] edited\n"
+			return &e
+		},
+	})
+}
+
+func TestFilter(t *testing.T) {
+	value := "groceries"
+	source := memory.New("scratch", note(0, "- [ ] groceries\n"), note(1, "- [ ] laundry\n"), note(2, "groceries again\n"))
+	b, err := New(context.Background(), func() string { return value }, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docs, err := b.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || b.Count() != 2 {
+		t.Errorf("expected the 2 notes about groceries but got %d, counting %d", len(docs), b.Count())
+	}
+
+	value = ""
+	if docs, _ := b.List(context.Background()); len(docs) != 3 {
+		t.Errorf("expected every note once the filter is cleared but got %d", len(docs))
+	}
+}
@@ -0,0 +1,206 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/byxorna/jot/pkg/text"
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+const (
+	// ConflictSuffix marks a copy of a note that could not be written because
+	// the note changed on disk in the same places. It is followed by the time of
+	// the write, and keeps the copy from being loaded as a note of its own.
+	ConflictSuffix     = ".conflict-"
+	conflictTimeFormat = "20060102T150405"
+)
+
+var (
+	ErrConflict = errors.New("note changed on disk since it was loaded")
+)
+
+// ConflictError is returned by Write when the note changed on disk in the same
+// lines as the note being written. The note on disk is left alone, and the
+// note being written is saved to Copy instead.
+type ConflictError struct {
+	ID v1.ID
+	// File and Copy are relative to Directory
+	File string
+	Copy string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: %v, your changes were saved to %s", e.File, ErrConflict, e.Copy)
+}
+func (e *ConflictError) Unwrap() error { return ErrConflict }
+
+// base is the note as it was last loaded from or written to disk, which both
+// the note on disk and the note being written are changes of
+type base struct {
+	modTime time.Time
+	content string
+}
+
+// merge makes e include the changes made on disk since the note was loaded,
+// so writing it does not lose them. Changes to the same lines are not merged;
+// e is saved to a conflict copy instead, and the store goes on with the note
+// on disk.
+func (x *Store) merge(e *v1.Note, targetpath string) error {
+	b, ok := x.bases[e.Metadata.ID]
+	if !ok {
+		return nil
+	}
+	finfo, err := os.Stat(targetpath)
+	if err != nil || finfo.ModTime().Equal(b.modTime) {
+		return nil
+	}
+
+	bytes, err := ioutil.ReadFile(targetpath)
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %w", targetpath, err)
+	}
+	rel := x.fileName(e.Metadata.ID)
+	theirs, _, err := x.decode(rel, finfo.ModTime(), bytes)
+	if err != nil {
+		// whatever is on disk is not a note anymore, so there is nothing to keep
+		return nil
+	}
+	if theirs.Tasks != nil {
+		return ErrLocked
+	}
+	theirs.Metadata.ID = e.Metadata.ID
+
+	if theirs.Content == b.content || theirs.Content == e.Content {
+		return nil
+	}
+	if merged, ok := text.Merge3(b.content, e.Content, theirs.Content); ok {
+		e.Content = merged
+		return nil
+	}
+
+	copyName := rel + ConflictSuffix + time.Now().Format(conflictTimeFormat)
+	copyBytes, err := x.encode(e)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path.Join(x.Directory, copyName), copyBytes, 0644); err != nil {
+		return fmt.Errorf("unable to save conflicting note %d: %w", e.Metadata.ID, err)
+	}
+
+	x.entries[e.Metadata.ID] = theirs
+	x.sorted = nil
+	x.mtimeMap[e.Metadata.ID] = finfo.ModTime()
+	x.bases[e.Metadata.ID] = base{modTime: finfo.ModTime(), content: theirs.Content}
+	return &ConflictError{ID: e.Metadata.ID, File: rel, Copy: copyName}
+}
+
+// conflictOf returns the file a conflict copy at rel was made of
+func conflictOf(rel string) (string, bool) {
+	i := strings.LastIndex(rel, ConflictSuffix)
+	if i < 0 {
+		return "", false
+	}
+	if _, err := time.Parse(conflictTimeFormat, rel[i+len(ConflictSuffix):]); err != nil {
+		return "", false
+	}
+	return rel[:i], true
+}
+
+// Conflict is a note that could not be written because it changed on disk
+type Conflict struct {
+	ID   v1.ID
+	File string
+	Copy string
+	// Mine is the note that could not be written, and Theirs the note on disk,
+	// which is nil if it was removed since
+	Mine   *v1.Note
+	Theirs *v1.Note
+
+	theirsModTime time.Time
+}
+
+// Conflicts returns the unresolved conflicts of the notebook, by file
+func (x *Store) Conflicts() ([]*Conflict, error) {
+	conflicts := []*Conflict{}
+	err := x.layout.walk(func(rel string, finfo os.FileInfo) error {
+		file, ok := conflictOf(rel)
+		if finfo.IsDir() || !ok {
+			return nil
+		}
+		bytes, err := ioutil.ReadFile(path.Join(x.Directory, rel))
+		if err != nil {
+			return err
+		}
+		mine, _, err := x.decode(file, finfo.ModTime(), bytes)
+		if err != nil {
+			return fmt.Errorf("unable to parse %s: %w", rel, err)
+		}
+		if mine.Tasks != nil {
+			return ErrLocked
+		}
+
+		c := &Conflict{ID: mine.Metadata.ID, File: file, Copy: rel, Mine: mine}
+		x.Lock()
+		for id, fn := range x.files {
+			if fn == file {
+				c.ID, c.Theirs, c.theirsModTime = id, x.entries[id], x.mtimeMap[id]
+				break
+			}
+		}
+		x.Unlock()
+		if c.Theirs != nil {
+			if c.Theirs, err = x.loaded(c.Theirs); err != nil {
+				return err
+			}
+		}
+		conflicts = append(conflicts, c)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to find conflicts in %s: %w", x.Directory, err)
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Copy < conflicts[j].Copy })
+	return conflicts, nil
+}
+
+// ResolveConflict stores content as the note and removes the conflict copy.
+// It fails with ErrConflict if the note changed on disk again since the
+// conflict was found.
+func (x *Store) ResolveConflict(c *Conflict, content string) error {
+	n := *c.Mine
+	x.Lock()
+	if c.Theirs != nil {
+		n = *c.Theirs
+		x.bases[c.ID] = base{modTime: c.theirsModTime, content: c.Theirs.Content}
+	} else {
+		// the note was removed, so mine is restored where it was
+		x.files[c.ID] = c.File
+	}
+	x.Unlock()
+	n.Metadata.ID = c.ID
+	n.Content = content
+
+	if c.Theirs == nil || content != c.Theirs.Content {
+		if _, err := x.CreateOrUpdateNote(&n); err != nil {
+			return err
+		}
+	}
+
+	return x.DiscardConflict(c)
+}
+
+// DiscardConflict keeps the note on disk by removing the conflict copy
+func (x *Store) DiscardConflict(c *Conflict) error {
+	if err := os.Remove(path.Join(x.Directory, c.Copy)); err != nil {
+		return fmt.Errorf("unable to remove %s: %w", c.Copy, err)
+	}
+	return nil
+}
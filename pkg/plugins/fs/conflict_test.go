@@ -0,0 +1,90 @@
+package fs
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+// changeOnDisk rewrites the note as if another device had changed it
+func changeOnDisk(t *testing.T, x *Store, e *v1.Note, content string) {
+	t.Helper()
+	n := *e
+	n.Content = content
+	b, err := encodeNote(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := x.StoragePathDoc(e.Identifier())
+	if err := ioutil.WriteFile(fn, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(fn, later, later); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteConflict(t *testing.T) {
+	dir := t.TempDir()
+	x, err := New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+
+	base := "# Tuesday\n\n- [ ] call mom\n- [ ] water plants\n"
+	e, err := x.CreateOrUpdateNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", CreationTimestamp: time.Now()}, Content: base})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// changes to different lines are merged
+	mine := *e
+	mine.Content = strings.Replace(base, "[ ] call", "[x] call", 1)
+	changeOnDisk(t, x, e, base+"- [ ] book flights\n")
+	if _, err := x.CreateOrUpdateNote(&mine); err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Replace(base, "[ ] call", "[x] call", 1) + "- [ ] book flights\n"
+	if n, err := x.LoadFromID(e.Metadata.ID); err != nil || n.Content != expected {
+		t.Fatalf("expected the changes to be merged into %q but got %v, %v", expected, n, err)
+	}
+
+	// changes to the same line are saved to a conflict copy
+	mine.Content = strings.Replace(expected, "call mom", "call mum", 1)
+	theirs := strings.Replace(expected, "call mom", "call dad", 1)
+	changeOnDisk(t, x, &mine, theirs)
+	_, err = x.CreateOrUpdateNote(&mine)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected a conflict but got %v", err)
+	}
+	if n, err := x.GetByID(e.Metadata.ID, false); err != nil || n.Content != theirs {
+		t.Fatalf("expected the note on disk to be kept but got %v, %v", n, err)
+	}
+
+	conflicts, err := x.Conflicts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Copy != conflict.Copy || conflicts[0].Mine.Content != mine.Content || conflicts[0].Theirs.Content != theirs {
+		t.Fatalf("expected the conflict saved to %s but got %v", conflict.Copy, conflicts)
+	}
+
+	if err := x.ResolveConflict(conflicts[0], conflicts[0].Mine.Content); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := x.LoadFromID(e.Metadata.ID); err != nil || n.Content != mine.Content {
+		t.Fatalf("expected my changes to be kept but got %v, %v", n, err)
+	}
+	if _, err := os.Stat(path.Join(dir, conflict.Copy)); !os.IsNotExist(err) {
+		t.Errorf("expected the conflict copy to be removed but got %v", err)
+	}
+}
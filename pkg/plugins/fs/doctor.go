@@ -0,0 +1,214 @@
+package fs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+// ProblemKind classifies an integrity problem found in the notes directory
+type ProblemKind string
+
+const (
+	ProblemUnparseable  ProblemKind = "unparseable"
+	ProblemMisnamed     ProblemKind = "misnamed"
+	ProblemIDMismatch   ProblemKind = "id-mismatch"
+	ProblemDuplicateID  ProblemKind = "duplicate-id"
+	ProblemDateMismatch ProblemKind = "date-mismatch"
+	ProblemStrayFile    ProblemKind = "stray-file"
+	ProblemConflict     ProblemKind = "conflict"
+)
+
+// Problem is an integrity problem with a file in the notes directory
+type Problem struct {
+	Kind    ProblemKind
+	File    string
+	Message string
+
+	// fix repairs the problem, if it is unambiguous how to do so
+	fix func() error
+}
+
+// Fixable returns whether Fix can repair the problem
+func (p *Problem) Fixable() bool { return p.fix != nil }
+
+// Fix repairs the problem
+func (p *Problem) Fix() error {
+	if p.fix == nil {
+		return fmt.Errorf("%s: %s cannot be fixed automatically", p.File, p.Kind)
+	}
+	return p.fix()
+}
+
+func (p *Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.File, p.Kind, p.Message)
+}
+
+// Diagnose checks every file of the notebook in dir configured by settings for
+// problems that would prevent New from loading it, or that would make notes
+// shadow each other. Unlike New, it does not stop at the first broken note.
+func Diagnose(dir string, settings map[string]string) ([]*Problem, error) {
+	l, err := newLayout(dir, settings)
+	if err != nil {
+		return nil, err
+	}
+	expandedPath := l.directory
+
+	problems := []*Problem{}
+	notes := map[string]*v1.Note{}
+	filesByID := map[v1.ID][]string{}
+	err = l.walk(func(rel string, finfo os.FileInfo) error {
+		fn := path.Join(expandedPath, rel)
+		if finfo.IsDir() {
+			return nil
+		}
+		if file, ok := conflictOf(rel); ok {
+			problems = append(problems, &Problem{
+				Kind:    ProblemConflict,
+				File:    fn,
+				Message: fmt.Sprintf("changes that could not be merged into %s, resolve them with C in jot", file),
+			})
+			return nil
+		}
+		if matched, _ := filepath.Match(StorageGlob, finfo.Name()); !matched {
+			problems = append(problems, &Problem{
+				Kind:    ProblemStrayFile,
+				File:    fn,
+				Message: "not a markdown note",
+			})
+			return nil
+		}
+
+		bytes, err := ioutil.ReadFile(fn)
+		if err != nil {
+			return fmt.Errorf("unable to read %s: %w", fn, err)
+		}
+		e, inferred, err := decodeNote(l, rel, finfo.ModTime(), bytes)
+		if err != nil {
+			problems = append(problems, &Problem{
+				Kind:    ProblemUnparseable,
+				File:    fn,
+				Message: err.Error(),
+			})
+			return nil
+		}
+		if inferred {
+			// files without front matter are given an ID when loaded, and
+			// only written back with one when edited
+			return nil
+		}
+		notes[fn] = e
+		filesByID[e.Metadata.ID] = append(filesByID[e.Metadata.ID], fn)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", expandedPath, err)
+	}
+
+	// sort so duplicates are reported deterministically
+	fns := make([]string, 0, len(notes))
+	for fn := range notes {
+		fns = append(fns, fn)
+	}
+	sort.Strings(fns)
+
+	for _, fn := range fns {
+		fn := fn
+		e := notes[fn]
+		id := e.Metadata.ID
+		name, _ := filepath.Rel(expandedPath, fn)
+		name = filepath.ToSlash(name)
+		idFile := l.dailyFile(int64(id))
+		createdFile := l.dailyFile(e.Metadata.CreationTimestamp.Unix())
+
+		if dupes := filesByID[id]; len(dupes) > 1 {
+			problems = append(problems, &Problem{
+				Kind:    ProblemDuplicateID,
+				File:    fn,
+				Message: fmt.Sprintf("id %d is shared by %s", id, strings.Join(dupes, ", ")),
+			})
+			continue
+		}
+
+		switch {
+		case !l.isDaily(name):
+			// named notes are not named after their ID or creation time
+
+		case name == idFile && createdFile != name:
+			// the filename and ID agree, so the ID is the creation time
+			p := &Problem{
+				Kind:    ProblemDateMismatch,
+				File:    fn,
+				Message: fmt.Sprintf("created %s does not match the filename", e.Metadata.CreationTimestamp.Format(time.RFC3339)),
+			}
+			p.fix = func() error {
+				e.Metadata.CreationTimestamp = time.Unix(int64(id), 0)
+				return writeNoteFile(fn, e)
+			}
+			problems = append(problems, p)
+
+		case name != idFile && createdFile == idFile:
+			// the metadata agrees with itself, so the file was renamed
+			p := &Problem{
+				Kind:    ProblemMisnamed,
+				File:    fn,
+				Message: fmt.Sprintf("id %d should be stored in %s", id, idFile),
+			}
+			target := path.Join(expandedPath, idFile)
+			if _, err := os.Stat(target); os.IsNotExist(err) {
+				p.fix = func() error {
+					if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+						return err
+					}
+					return os.Rename(fn, target)
+				}
+			} else {
+				p.Message += ", which already exists"
+			}
+			problems = append(problems, p)
+
+		case name != idFile && createdFile == name:
+			// the filename and creation time agree, so the ID is wrong
+			newID := v1.ID(e.Metadata.CreationTimestamp.Unix())
+			p := &Problem{
+				Kind:    ProblemIDMismatch,
+				File:    fn,
+				Message: fmt.Sprintf("id %d does not match the filename or created time", id),
+			}
+			if _, taken := filesByID[newID]; !taken {
+				p.fix = func() error {
+					e.Metadata.ID = newID
+					return writeNoteFile(fn, e)
+				}
+			} else {
+				p.Message += fmt.Sprintf(", and id %d is already taken", newID)
+			}
+			problems = append(problems, p)
+
+		case name != idFile:
+			problems = append(problems, &Problem{
+				Kind:    ProblemMisnamed,
+				File:    fn,
+				Message: fmt.Sprintf("id %d and created %s both disagree with the filename", id, e.Metadata.CreationTimestamp.Format(time.RFC3339)),
+			})
+		}
+	}
+
+	return problems, nil
+}
+
+// writeNoteFile overwrites fn with the serialized note
+func writeNoteFile(fn string, e *v1.Note) error {
+	b, err := encodeNote(e)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fn, b, 0644)
+}
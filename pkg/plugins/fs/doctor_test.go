@@ -0,0 +1,70 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func writeFixture(t *testing.T, dir, name, content string) {
+	if err := os.MkdirAll(path.Dir(path.Join(dir, name)), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	dir := t.TempDir()
+
+	// 1624308406 is 2021-06-21T20:46:46Z
+	writeFixture(t, dir, "2021-06-21.md", "---\nid: 1624308406\nauthor: a\ncreated: 2021-06-21T16:46:46-04:00\n---\nok\n")
+	writeFixture(t, dir, "2021-06-20.md", "---\nid: 1624308406\nauthor: a\ncreated: 2021-06-21T16:46:46-04:00\n---\ndupe\n")
+	writeFixture(t, dir, "2021-06-23.md", "---\nid: 1624478400\nno closing fence here\n")
+	// plain markdown is adopted as is
+	writeFixture(t, dir, "2021-06-26.md", "no metadata here\n")
+	writeFixture(t, dir, "notes.txt", "stray\n")
+	// 1624392613 is 2021-06-22T20:10:13Z, but this was renamed
+	writeFixture(t, dir, "2021-06-24.md", "---\nid: 1624392613\nauthor: a\ncreated: 2021-06-22T16:10:13-04:00\n---\n")
+	// named notes are not named after their ID
+	writeFixture(t, dir, "q3-planning.md", "---\nid: 1624392614\nauthor: a\ncreated: 2021-06-22T16:10:14-04:00\n---\n")
+	// 1624642302 is 2021-06-25T17:31:42Z
+	writeFixture(t, dir, "2021-06-25.md", "---\nid: 1624642302\nauthor: a\ncreated: 2021-06-28T13:31:42-04:00\n---\n")
+
+	problems, err := Diagnose(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]ProblemKind{
+		"2021-06-20.md": ProblemDuplicateID,
+		"2021-06-21.md": ProblemDuplicateID,
+		"2021-06-23.md": ProblemUnparseable,
+		"notes.txt":     ProblemStrayFile,
+		"2021-06-24.md": ProblemMisnamed,
+		"2021-06-25.md": ProblemDateMismatch,
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %d problems but found %d: %v", len(expected), len(problems), problems)
+	}
+	for _, p := range problems {
+		if kind := expected[path.Base(p.File)]; kind != p.Kind {
+			t.Errorf("expected %s to have problem %s but found %s", p.File, kind, p)
+		}
+		if p.Fixable() {
+			if err := p.Fix(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	problems, err = Diagnose(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 4 {
+		t.Fatalf("expected only the unfixable problems to remain but found %v", problems)
+	}
+}
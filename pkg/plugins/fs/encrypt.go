@@ -0,0 +1,523 @@
+package fs
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/types/v1"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// LabelEncrypted seals a note with the passphrase of its notebook, like
+	// SettingEncrypted does for every note of the notebook
+	LabelEncrypted = "encrypted"
+
+	// SealContent seals the content of notes, leaving their metadata readable
+	SealContent = "true"
+	// SealMetadata seals the title, tags and labels of notes along with their
+	// content. Only their ID and creation time are left readable.
+	SealMetadata = "metadata"
+
+	// KeyFile holds what is needed to check the passphrase of a notebook and
+	// derive its key from it, but not the key itself
+	KeyFile = ".key.json"
+
+	sealedBegin = "-----BEGIN JOT SEALED NOTE-----"
+	sealedEnd   = "-----END JOT SEALED NOTE-----"
+	keyVersion  = 1
+	keyCheck    = "jot"
+)
+
+var (
+	ErrLocked          = errors.New("notebook is locked, unlock it with its passphrase")
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+	ErrNoPassphrase    = errors.New("notebook has no passphrase to encrypt notes with, set one with jot encrypt")
+)
+
+// keyFile is saved to KeyFile
+type keyFile struct {
+	Version int `json:"version"`
+	// Salt and the scrypt parameters derive the key from the passphrase
+	Salt []byte `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	// Check is sealed with the key, to tell a wrong passphrase apart
+	Check []byte `json:"check"`
+}
+
+func (x *Store) keyFile() string {
+	return filepath.Join(x.Directory, KeyFile)
+}
+
+func (k *keyFile) derive(passphrase string) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), k.Salt, k.N, k.R, k.P, 32)
+}
+
+// seal encrypts plain with key, authenticating it along with data, so it can
+// only be opened along with the same data
+func seal(key, plain, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plain, data), nil
+}
+
+// open decrypts what seal encrypted with the same key and data
+func open(key, sealed, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("sealed note is too short")
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], data)
+}
+
+// sealedData is what a sealed note is authenticated along with: the metadata
+// left readable in its file, which includes its ID. A sealed note moved to
+// another note, or whose readable metadata is changed, cannot be opened.
+func sealedData(m v1.NoteMetadata) ([]byte, error) {
+	m.CreationTimestamp = m.CreationTimestamp.UTC()
+	if m.ModifiedTimestamp != nil {
+		t := m.ModifiedTimestamp.UTC()
+		m.ModifiedTimestamp = &t
+	}
+	if len(m.Tags) == 0 {
+		m.Tags = nil
+	}
+	if len(m.Labels) == 0 {
+		m.Labels = nil
+	}
+	return json.Marshal(m)
+}
+
+// armor wraps sealed bytes in lines of base64 between markers, to be the
+// content of a note
+func armor(sealed []byte) string {
+	enc := base64.StdEncoding.EncodeToString(sealed)
+	b := strings.Builder{}
+	b.WriteString(sealedBegin + "\n")
+	for len(enc) > 64 {
+		b.WriteString(enc[:64] + "\n")
+		enc = enc[64:]
+	}
+	b.WriteString(enc + "\n" + sealedEnd + "\n")
+	return b.String()
+}
+
+// unarmor returns the sealed bytes in the content of a note, if it is sealed
+func unarmor(content string) ([]byte, bool) {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, sealedBegin) || !strings.HasSuffix(content, sealedEnd) {
+		return nil, false
+	}
+	enc := strings.Join(strings.Fields(content[len(sealedBegin):len(content)-len(sealedEnd)]), "")
+	sealed, err := base64.StdEncoding.DecodeString(enc)
+	return sealed, err == nil
+}
+
+// HasPassphrase returns whether a passphrase was set to encrypt the notes of
+// the notebook with
+func (x *Store) HasPassphrase() bool {
+	_, err := os.Stat(x.keyFile())
+	return err == nil
+}
+
+// Locked returns whether the notebook has a passphrase it was not unlocked
+// with yet. The content of its sealed notes cannot be read or written.
+func (x *Store) Locked() bool {
+	return x.sealKey() == nil && x.HasPassphrase()
+}
+
+// sealKey returns the key derived from the passphrase, or nil until the
+// notebook is unlocked
+func (x *Store) sealKey() []byte {
+	key, _ := x.key.Load().([]byte)
+	return key
+}
+
+// SetPassphrase sets the passphrase of a notebook that has none, and unlocks
+// it
+func (x *Store) SetPassphrase(passphrase string) error {
+	if x.HasPassphrase() {
+		return fmt.Errorf("%s already has a passphrase", x.Directory)
+	}
+	if passphrase == "" {
+		return fmt.Errorf("passphrase must not be empty")
+	}
+
+	k := keyFile{Version: keyVersion, Salt: make([]byte, 16), N: 1 << 15, R: 8, P: 1}
+	if _, err := rand.Read(k.Salt); err != nil {
+		return err
+	}
+	key, err := k.derive(passphrase)
+	if err != nil {
+		return err
+	}
+	if k.Check, err = seal(key, []byte(keyCheck), nil); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(k, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(x.keyFile(), b, 0600); err != nil {
+		return fmt.Errorf("unable to save the passphrase of %s: %w", x.Directory, err)
+	}
+
+	x.key.Store(key)
+	// other devices need it to unlock the notebook
+	return x.gitCommit(KeyFile)
+}
+
+// UnlockWith derives the key of the notebook from its passphrase, for the
+// rest of the session, and reads the sealed notes with it. It fails with
+// ErrWrongPassphrase if the passphrase is not the one the notebook was
+// sealed with.
+func (x *Store) UnlockWith(passphrase string) error {
+	b, err := ioutil.ReadFile(x.keyFile())
+	if os.IsNotExist(err) {
+		return ErrNoPassphrase
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read the passphrase of %s: %w", x.Directory, err)
+	}
+	k := keyFile{}
+	if err := json.Unmarshal(b, &k); err != nil || k.Version != keyVersion {
+		return fmt.Errorf("unable to read the passphrase of %s: unsupported %s", x.Directory, KeyFile)
+	}
+
+	key, err := k.derive(passphrase)
+	if err != nil {
+		return err
+	}
+	if check, err := open(key, k.Check, nil); err != nil || string(check) != keyCheck {
+		return ErrWrongPassphrase
+	}
+
+	x.key.Store(key)
+	x.Lock()
+	sealed := []*v1.Note{}
+	for _, e := range x.entries {
+		if e.Tasks != nil && x.sealOf(e) != "" {
+			sealed = append(sealed, e)
+		}
+	}
+	x.Unlock()
+
+	for _, e := range sealed {
+		if _, err := x.loaded(e); err != nil {
+			return err
+		}
+		x.changed(db.DocUpdated, e.Identifier())
+	}
+	return nil
+}
+
+// sealOf returns how the note is sealed when it is written, or nothing if it
+// is stored in plain text
+func (x *Store) sealOf(e *v1.Note) string {
+	switch mode := e.Metadata.Labels[LabelEncrypted]; mode {
+	case SealContent, SealMetadata:
+		return mode
+	case "false":
+		return ""
+	}
+	switch x.sealing {
+	case SealContent, SealMetadata:
+		return x.sealing
+	}
+	return ""
+}
+
+// IsSealed returns whether the note is encrypted at rest, and can only be
+// edited through a PrivateCopy
+func (x *Store) IsSealed(id v1.ID) bool {
+	x.Lock()
+	defer x.Unlock()
+	e, ok := x.entries[id]
+	return ok && x.sealOf(e) != ""
+}
+
+// sealedMetadata is the metadata left readable in the file of a note sealed
+// the given way
+func sealedMetadata(e *v1.Note, mode string) v1.NoteMetadata {
+	if mode != SealMetadata {
+		return e.Metadata
+	}
+	return v1.NoteMetadata{
+		ID:                e.Metadata.ID,
+		UID:               e.Metadata.UID,
+		CreationTimestamp: e.Metadata.CreationTimestamp,
+		Labels:            map[string]string{LabelEncrypted: SealMetadata},
+	}
+}
+
+// encode serializes the note for its file, sealing it if it is encrypted
+func (x *Store) encode(e *v1.Note) ([]byte, error) {
+	mode := x.sealOf(e)
+	if mode == "" {
+		return encodeNote(e)
+	}
+	plain, err := encodeNote(e)
+	if err != nil {
+		return nil, err
+	}
+	return x.sealFile(e, plain, mode)
+}
+
+// sealFile seals plain, the file of the note in plain text, the given way
+func (x *Store) sealFile(e *v1.Note, plain []byte, mode string) ([]byte, error) {
+	key := x.sealKey()
+	if key == nil {
+		if !x.HasPassphrase() {
+			return nil, ErrNoPassphrase
+		}
+		return nil, ErrLocked
+	}
+	metadata := sealedMetadata(e, mode)
+	data, err := sealedData(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("unable to seal note %d: %w", e.Metadata.ID, err)
+	}
+	sealed, err := seal(key, plain, data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to seal note %d: %w", e.Metadata.ID, err)
+	}
+	return encodeNote(&v1.Note{Metadata: metadata, Content: armor(sealed)})
+}
+
+// sealRevision returns b, a revision of the note in the file rel, sealed the
+// given way. Revisions already sealed are returned as they are.
+func (x *Store) sealRevision(id v1.ID, rel string, b []byte, modTime time.Time, mode string) ([]byte, error) {
+	if isSealed(b) {
+		return b, nil
+	}
+	e, _, err := decodeNote(x.layout, rel, modTime, b)
+	if err != nil {
+		return nil, fmt.Errorf("unable to seal revision of %d: %w", id, err)
+	}
+	e.Metadata.ID = id
+	return x.sealFile(e, b, mode)
+}
+
+// sealHistory seals the revisions of the note in the file rel that were
+// recorded in plain text, like before it was sealed. Revisions that cannot be
+// read as a note are removed, as they cannot be sealed either.
+func (x *Store) sealHistory(id v1.ID, rel string, mode string) error {
+	names, err := x.revisionFiles(id)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		b, t, err := x.readRevision(id, name)
+		if err == nil && isSealed(b) {
+			continue
+		}
+		file := filepath.Join(x.historyDirectory(id), name)
+		var sealed []byte
+		if err == nil {
+			sealed, err = x.sealRevision(id, rel, b, t, mode)
+		}
+		if errors.Is(err, ErrLocked) || errors.Is(err, ErrNoPassphrase) {
+			return err
+		}
+		if err != nil {
+			if err := os.Remove(file); err != nil {
+				return fmt.Errorf("unable to remove revision %s of %d: %w", name, id, err)
+			}
+			continue
+		}
+
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return fmt.Errorf("unable to seal revision %s of %d: %w", name, id, err)
+		}
+		err = writeRevision(f, sealed, t)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("unable to seal revision %s of %d: %w", name, id, err)
+		}
+	}
+	return nil
+}
+
+// SealHistory seals the revisions of a sealed note that were recorded in plain
+// text, like those kept before it was sealed. The history of notes stored in
+// plain text is left as it is.
+func (x *Store) SealHistory(id v1.ID) error {
+	x.Lock()
+	e, ok := x.entries[id]
+	rel := x.fileName(id)
+	x.Unlock()
+	if !ok {
+		return fmt.Errorf("%d: %w", id, db.ErrNoNoteFound)
+	}
+	mode := x.sealOf(e)
+	if mode == "" {
+		return nil
+	}
+	return x.sealHistory(id, rel, mode)
+}
+
+// decode parses the note in the file rel, opening it if it is sealed. Sealed
+// notes of a locked notebook are returned without content, with Tasks set
+// like notes listed from the index.
+func (x *Store) decode(rel string, modTime time.Time, b []byte) (*v1.Note, bool, error) {
+	e, inferred, err := decodeNote(x.layout, rel, modTime, b)
+	if err != nil {
+		return nil, false, err
+	}
+	sealed, ok := unarmor(e.Content)
+	if !ok {
+		return e, inferred, nil
+	}
+
+	key := x.sealKey()
+	if key == nil {
+		e.Content = ""
+		e.Tasks = &v1.TaskListStatus{}
+		return e, inferred, nil
+	}
+
+	// the metadata as it is in the file, before what is missing is inferred
+	outer, err := parseNote(b)
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := sealedData(outer.Metadata)
+	if err != nil {
+		return nil, false, err
+	}
+	plain, err := open(key, sealed, data)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to open sealed note, it was tampered with or moved from another note: %w", err)
+	}
+	inner, _, err := decodeNote(x.layout, rel, modTime, plain)
+	if err != nil {
+		return nil, false, err
+	}
+	inner.Metadata.ID = e.Metadata.ID
+	return inner, inferred, nil
+}
+
+// isSealed returns whether the file holds a sealed note
+func isSealed(b []byte) bool {
+	return bytes.Contains(b, []byte(sealedBegin))
+}
+
+// PrivateCopy is a sealed note opened to a file only the user can read, in
+// the runtime directory, so it can be edited in an editor
+type PrivateCopy struct {
+	Path  string
+	id    v1.ID
+	store *Store
+}
+
+// privateDirectory is where private copies are made, which is not synced and
+// is usually wiped at logout
+func privateDirectory() (string, error) {
+	dir := xdg.RuntimeDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	dir = filepath.Join(dir, "jot")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, os.Chmod(dir, 0700)
+}
+
+// OpenPrivateCopy writes the note in plain text to a private file, to be
+// saved back with Save and wiped with Wipe
+func (x *Store) OpenPrivateCopy(id v1.ID) (*PrivateCopy, error) {
+	e, err := x.GetByID(id, false)
+	if err != nil {
+		return nil, err
+	}
+	b, err := encodeNote(e)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := privateDirectory()
+	if err != nil {
+		return nil, fmt.Errorf("unable to make a private copy of %d: %w", id, err)
+	}
+	f, err := ioutil.TempFile(dir, fmt.Sprintf("%d-*.md", id))
+	if err != nil {
+		return nil, fmt.Errorf("unable to make a private copy of %d: %w", id, err)
+	}
+	defer f.Close()
+
+	c := &PrivateCopy{Path: f.Name(), id: id, store: x}
+	if _, err := f.Write(b); err != nil {
+		c.Wipe()
+		return nil, fmt.Errorf("unable to make a private copy of %d: %w", id, err)
+	}
+	return c, nil
+}
+
+// Save seals the private copy back into the note
+func (c *PrivateCopy) Save() (*v1.Note, error) {
+	b, err := ioutil.ReadFile(c.Path)
+	if err != nil {
+		return nil, err
+	}
+	e, err := c.store.GetByID(c.id, false)
+	if err != nil {
+		return nil, err
+	}
+	edited, _, err := decodeNote(c.store.layout, c.store.fileName(c.id), time.Now(), b)
+	if err != nil {
+		return nil, err
+	}
+
+	n := *e
+	n.Metadata = edited.Metadata
+	n.Metadata.ID = c.id
+	n.Content = edited.Content
+	return c.store.CreateOrUpdateNote(&n)
+}
+
+// Wipe overwrites the private copy before removing it
+func (c *PrivateCopy) Wipe() error {
+	if finfo, err := os.Stat(c.Path); err == nil {
+		if f, err := os.OpenFile(c.Path, os.O_WRONLY, 0); err == nil {
+			f.Write(make([]byte, finfo.Size()))
+			f.Sync()
+			f.Close()
+		}
+	}
+	return os.Remove(c.Path)
+}
@@ -0,0 +1,264 @@
+package fs
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+func TestEncrypt(t *testing.T) {
+	dir := t.TempDir()
+	x, err := New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2021, 6, 22, 12, 0, 0, 0, time.UTC)
+	sealed := &v1.Note{Metadata: v1.NoteMetadata{Author: "a", Title: "plans", CreationTimestamp: day, Labels: map[string]string{LabelEncrypted: SealContent}}, Content: "- [ ] secret plan\n"}
+	if _, err := x.CreateOrUpdateNote(sealed); !errors.Is(err, ErrNoPassphrase) {
+		t.Fatalf("expected sealing without a passphrase to fail but got %v", err)
+	}
+	if err := x.SetPassphrase("hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	e, err := x.CreateOrUpdateNote(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := e.Metadata.ID
+	x.Close()
+
+	for _, f := range []string{"2021-06-22.md", IndexFile} {
+		b, err := ioutil.ReadFile(path.Join(dir, f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(b), "secret plan") {
+			t.Errorf("expected %s to be sealed but got %s", f, b)
+		}
+	}
+
+	// locked until the passphrase is given, but still listed
+	x, err = New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	if !x.Locked() || !x.IsSealed(id) {
+		t.Fatalf("expected the notebook to be locked")
+	}
+	if list, err := x.ListAll(); err != nil || len(list) != 1 || list[0].Metadata.Title != "plans" {
+		t.Errorf("expected the sealed note to be listed by title but got %v, %v", list, err)
+	}
+	if _, err := x.GetByID(id, false); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected reading a locked note to fail but got %v", err)
+	}
+	if err := x.UnlockWith("hunter3"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("expected a wrong passphrase to fail but got %v", err)
+	}
+	if err := x.UnlockWith("hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	if e, err := x.GetByID(id, false); err != nil || e.Content != "- [ ] secret plan\n" {
+		t.Fatalf("expected the note to be opened but got %v, %v", e, err)
+	}
+
+	// edited in plain text outside of the notebook, and wiped afterwards
+	c, err := x.OpenPrivateCopy(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasPrefix(c.Path, dir) {
+		t.Errorf("expected the private copy outside of the notebook but got %s", c.Path)
+	}
+	b, err := ioutil.ReadFile(c.Path)
+	if err != nil || !strings.Contains(string(b), "secret plan") {
+		t.Fatalf("expected the private copy in plain text but got %s, %v", b, err)
+	}
+	if err := ioutil.WriteFile(c.Path, []byte(strings.Replace(string(b), "- [ ]", "- [x]", 1)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if e, err := c.Save(); err != nil || e.Content != "- [x] secret plan\n" {
+		t.Errorf("expected the private copy to be saved but got %v, %v", e, err)
+	}
+	if err := c.Wipe(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(c.Path); !os.IsNotExist(err) {
+		t.Errorf("expected the private copy to be wiped but got %v", err)
+	}
+	if b, _ := ioutil.ReadFile(path.Join(dir, "2021-06-22.md")); !isSealed(b) || strings.Contains(string(b), "secret plan") {
+		t.Errorf("expected the saved note to stay sealed but got %s", b)
+	}
+
+	// revisions are kept sealed too
+	if revisions, err := x.Revisions(id); err != nil || len(revisions) != 2 || revisions[1].Note.Content != "- [ ] secret plan\n" {
+		t.Errorf("expected the revisions to be opened but got %v, %v", revisions, err)
+	}
+}
+
+func TestEncryptMetadata(t *testing.T) {
+	dir := t.TempDir()
+	settings := map[string]string{SettingEncrypted: SealMetadata}
+	x, err := New(dir, false, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := x.SetPassphrase("hunter2"); err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2021, 6, 22, 12, 0, 0, 0, time.UTC)
+	if _, err := x.CreateOrUpdateNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", Title: "hidden title", Tags: []string{"hidden"}, CreationTimestamp: day}, Content: "hidden content\n"}); err != nil {
+		t.Fatal(err)
+	}
+	// opting out of the notebook setting
+	if _, err := x.CreateOrUpdateNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", Title: "open", CreationTimestamp: day.AddDate(0, 0, 1), Labels: map[string]string{LabelEncrypted: "false"}}, Content: "open content\n"}); err != nil {
+		t.Fatal(err)
+	}
+	x.Close()
+
+	for _, f := range []string{"2021-06-22.md", IndexFile} {
+		b, err := ioutil.ReadFile(path.Join(dir, f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(b), "hidden") {
+			t.Errorf("expected the metadata in %s to be sealed but got %s", f, b)
+		}
+	}
+	if b, _ := ioutil.ReadFile(path.Join(dir, "2021-06-23.md")); !strings.Contains(string(b), "open content") {
+		t.Errorf("expected the note to be in plain text but got %s", b)
+	}
+
+	x, err = New(dir, false, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	if list, err := x.ListAll(); err != nil || len(list) != 2 || list[0].Metadata.Title != "open" || list[1].Metadata.Title != "" {
+		t.Errorf("expected only the open note to have a title but got %v, %v", list, err)
+	}
+	if err := x.UnlockWith("hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	e, err := x.GetByDay(day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Metadata.Title != "hidden title" || len(e.Metadata.Tags) != 1 || e.Content != "hidden content\n" {
+		t.Errorf("expected the metadata to be opened but got %v", e)
+	}
+}
+
+func TestEncryptHistory(t *testing.T) {
+	dir := t.TempDir()
+	x, err := New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+
+	day := time.Date(2021, 6, 22, 12, 0, 0, 0, time.UTC)
+	e, err := x.CreateOrUpdateNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", Title: "plans", CreationTimestamp: day}, Content: "- [ ] secret plan\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := e.Metadata.ID
+	n := *e
+	n.Content = "- [x] secret plan\n"
+	if _, err := x.CreateOrUpdateNote(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	// what jot encrypt does
+	if err := x.SetPassphrase("hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	sealed := n
+	sealed.Metadata.Labels = map[string]string{LabelEncrypted: SealContent}
+	if _, err := x.CreateOrUpdateNote(&sealed); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.SealHistory(id); err != nil {
+		t.Fatal(err)
+	}
+
+	files := 0
+	err = filepath.Walk(filepath.Join(dir, HistoryDirectory), func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		files++
+		b, _, err := x.readRevision(id, filepath.Base(p))
+		if err != nil {
+			return err
+		}
+		if strings.Contains(string(b), "secret plan") || !isSealed(b) {
+			t.Errorf("expected %s to be sealed but got %s", p, b)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files == 0 {
+		t.Fatal("expected the history to be kept")
+	}
+
+	// and can still be read
+	revisions, err := x.Revisions(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := revisions[len(revisions)-1]; last.Note.Content != "- [ ] secret plan\n" {
+		t.Errorf("expected the first revision to be opened but got %q", last.Note.Content)
+	}
+}
+
+func TestEncryptTampered(t *testing.T) {
+	x, err := New(t.TempDir(), false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	if err := x.SetPassphrase("hunter2"); err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2021, 6, 22, 12, 0, 0, 0, time.UTC)
+	files := map[string][]byte{}
+	for i, mode := range []string{SealContent, SealContent, SealMetadata} {
+		e := &v1.Note{Metadata: v1.NoteMetadata{Author: "a", Title: "plans", CreationTimestamp: day.AddDate(0, 0, i), Labels: map[string]string{LabelEncrypted: mode}}, Content: "- [ ] secret plan\n"}
+		if _, err := x.CreateOrUpdateNote(e); err != nil {
+			t.Fatal(err)
+		}
+		rel := x.fileName(e.Metadata.ID)
+		if files[rel], err = ioutil.ReadFile(path.Join(x.Directory, rel)); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := x.decode(rel, day, files[rel]); err != nil {
+			t.Fatalf("expected %s to be opened but got %v", rel, err)
+		}
+	}
+
+	body := func(b []byte) string { return string(b[strings.Index(string(b), sealedBegin):]) }
+	front := func(b []byte) string { return string(b[:strings.Index(string(b), sealedBegin)]) }
+	for name, b := range map[string]string{
+		"moved to another note":     front(files["2021-06-22.md"]) + body(files["2021-06-23.md"]),
+		"with its title changed":    strings.Replace(string(files["2021-06-22.md"]), "title: plans", "title: other", 1),
+		"with its metadata sealed":  front(files["2021-06-23.md"]) + body(files["2021-06-24.md"]),
+		"with its creation changed": strings.Replace(string(files["2021-06-24.md"]), "2021-06-24T12", "2021-06-24T13", 1),
+	} {
+		if _, _, err := x.decode("2021-06-22.md", day, []byte(b)); err == nil {
+			t.Errorf("expected a sealed note %s not to be opened", name)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package fs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/user"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/byxorna/jot/pkg/types"
+	"github.com/byxorna/jot/pkg/types/v1"
+	"github.com/byxorna/jot/pkg/types/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatter is the format of the metadata block at the head of a note
+type FrontMatter string
+
+const (
+	FrontMatterNone FrontMatter = "none"
+	FrontMatterYAML FrontMatter = "yaml"
+	FrontMatterTOML FrontMatter = "toml"
+	FrontMatterJSON FrontMatter = "json"
+)
+
+var (
+	// fenced front matter formats, by the line opening and closing the block
+	frontMatterFences = map[string]FrontMatter{
+		"---": FrontMatterYAML,
+		"+++": FrontMatterTOML,
+	}
+)
+
+// splitFrontMatter separates the front matter from the markdown content. Only
+// a block at the very start of the file is front matter, so horizontal rules
+// and fences further down are left alone.
+func splitFrontMatter(b []byte) (FrontMatter, []byte, string, error) {
+	b = bytes.TrimPrefix(b, []byte("\ufeff"))
+
+	if bytes.HasPrefix(b, []byte("{")) {
+		var raw json.RawMessage
+		dec := json.NewDecoder(bytes.NewReader(b))
+		if err := dec.Decode(&raw); err != nil {
+			return FrontMatterJSON, nil, "", fmt.Errorf("unable to find the end of the json front matter: %w", err)
+		}
+		// drop the rest of the line closing the object
+		_, content := cutLine(string(b[dec.InputOffset():]))
+		return FrontMatterJSON, raw, content, nil
+	}
+
+	firstLine, rest := cutLine(string(b))
+	format, ok := frontMatterFences[firstLine]
+	if !ok {
+		return FrontMatterNone, nil, string(b), nil
+	}
+
+	var metadata strings.Builder
+	for rest != "" {
+		var line string
+		line, rest = cutLine(rest)
+		if line == firstLine {
+			return format, []byte(metadata.String()), rest, nil
+		}
+		metadata.WriteString(line + "\n")
+	}
+	return format, nil, "", fmt.Errorf("%s front matter is not closed by %s: %w", format, firstLine, ErrUnableToFindMetadataSection)
+}
+
+// cutLine returns the first line of s without its line ending, and the rest
+func cutLine(s string) (string, string) {
+	i := strings.Index(s, "\n")
+	if i < 0 {
+		return strings.TrimSuffix(s, "\r"), ""
+	}
+	return strings.TrimSuffix(s[:i], "\r"), s[i+1:]
+}
+
+// decodeNote parses a note from its front matter and markdown content. Files
+// without front matter, like those written by other apps, are adopted by
+// inferring the metadata from the file name and modification time; inferred
+// is true when the ID was not read from the file. Front matter of an older
+// schema is upgraded to the current one.
+func decodeNote(l *layout, name string, modTime time.Time, b []byte) (e *v1.Note, inferred bool, err error) {
+	e, err = parseNote(b)
+	if err != nil {
+		return nil, false, err
+	}
+
+	inferred = e.Metadata.ID == 0
+	inferMetadata(l, e, name, modTime)
+
+	if err := e.Validate(); err != nil {
+		return nil, false, err
+	}
+
+	return e, inferred, nil
+}
+
+// parseNote parses a note as it is stored, without inferring the metadata
+// missing from its front matter
+func parseNote(b []byte) (*v1.Note, error) {
+	format, metadata, content, err := splitFrontMatter(b)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse metadata section: %w", err)
+	}
+
+	raw, err := decodeFrontMatter(format, metadata)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := types.Upgrade(raw, v2.Kind, SchemaVersion); err != nil {
+		return nil, err
+	}
+	e := &v1.Note{Content: content}
+	if err := v2.Decode(raw, e); err != nil {
+		return nil, fmt.Errorf("unable to deserialize %s metadata: %w", format, err)
+	}
+	return e, nil
+}
+
+// decodeFrontMatter returns the fields of the front matter, as they are in
+// whatever version of the schema the note is stored in
+func decodeFrontMatter(format FrontMatter, metadata []byte) (map[string]interface{}, error) {
+	raw := map[string]interface{}{}
+	var err error
+	switch format {
+	case FrontMatterYAML:
+		err = yaml.Unmarshal(metadata, &raw)
+	case FrontMatterTOML:
+		_, err = toml.Decode(string(metadata), &raw)
+	case FrontMatterJSON:
+		err = json.Unmarshal(metadata, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to deserialize %s metadata: %w", format, err)
+	}
+	if raw == nil {
+		// an empty yaml block
+		raw = map[string]interface{}{}
+	}
+	return raw, nil
+}
+
+// inferMetadata fills in whatever metadata the note is missing. Daily entries
+// are dated by their path, and anything else by when it was last modified.
+func inferMetadata(l *layout, e *v1.Note, name string, modTime time.Time) {
+	m := &e.Metadata
+
+	if m.CreationTimestamp.IsZero() {
+		m.CreationTimestamp = modTime
+		if d, err := time.Parse(l.daily, name); err == nil {
+			// file names are the UTC day of the ID, so noon keeps the ID and
+			// file name in agreement
+			m.CreationTimestamp = d.Add(12 * time.Hour)
+		}
+	}
+
+	if m.ID == 0 {
+		m.ID = v1.ID(m.CreationTimestamp.Unix())
+	}
+
+	if m.Title == "" {
+		m.Title = v1.FirstHeading(e.Content)
+	}
+	if m.Title == "" && name != "" {
+		m.Title = strings.TrimSuffix(path.Base(name), path.Ext(name))
+	}
+
+	if m.Author == "" {
+		m.Author = "unknown"
+		if u, err := user.Current(); err == nil {
+			m.Author = u.Username
+		}
+	}
+
+	if m.UID == "" {
+		m.UID = v2.InferredUID(*m)
+	}
+}
@@ -0,0 +1,52 @@
+package fs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+func TestDecodeNote(t *testing.T) {
+	flat := &layout{daily: StorageFilenameFormat}
+	modTime := time.Date(2021, 6, 22, 16, 10, 13, 0, time.UTC)
+	testcases := []struct {
+		name     string
+		input    string
+		id       v1.ID
+		title    string
+		content  string
+		inferred bool
+	}{
+		{"2021-06-21.md", "---\nid: 1624308406\nauthor: a\ntitle: a --- b\ncreated: 2021-06-21T16:46:46-04:00\n---\nabove\n\n---\n\nbelow\n", 1624308406, "a --- b", "above\n\n---\n\nbelow\n", false},
+		{"2021-06-21.md", "---\r\nid: 1624308406\r\nauthor: a\r\ncreated: 2021-06-21T16:46:46-04:00\r\n---\r\nwindows\r\n", 1624308406, "2021-06-21", "windows\r\n", false},
+		{"toml.md", "+++\nid = 1624308406\nauthor = \"a\"\ntitle = \"toml\"\ncreated = 2021-06-21T16:46:46-04:00\ntags = [\"x\"]\n+++\n# Heading\n", 1624308406, "toml", "# Heading\n", false},
+		{"json.md", "{\"id\": 1624308406, \"author\": \"a\", \"created\": \"2021-06-21T16:46:46-04:00\"}\n# From JSON\n", 1624308406, "From JSON", "# From JSON\n", false},
+		{"obsidian.md", "---\ntags: [x]\n---\ntext\n", v1.ID(modTime.Unix()), "obsidian", "text\n", true},
+		{"2021-06-20.md", "```\n# not a title\n```\n# Plain\n---\n", 1624190400, "Plain", "```\n# not a title\n```\n# Plain\n---\n", true},
+		{"phone note.md", "- [ ] milk\n", v1.ID(modTime.Unix()), "phone note", "- [ ] milk\n", true},
+	}
+
+	for _, tc := range testcases {
+		e, inferred, err := decodeNote(flat, tc.name, modTime, []byte(tc.input))
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+			continue
+		}
+		if e.Metadata.ID != tc.id || e.Metadata.Title != tc.title || e.Content != tc.content || inferred != tc.inferred {
+			t.Errorf("%s: expected id %d title %q content %q inferred %t but got id %d title %q content %q inferred %t",
+				tc.name, tc.id, tc.title, tc.content, tc.inferred, e.Metadata.ID, e.Metadata.Title, e.Content, inferred)
+		}
+	}
+
+	for _, input := range []string{
+		"---\nid: 1\n",
+		"+++\nid = 1\n---\n",
+		"{\"id\": 1\n",
+		"---\nid: [\n---\n",
+	} {
+		if _, _, err := decodeNote(flat, "bad.md", modTime, []byte(input)); err == nil {
+			t.Errorf("expected %q to fail to parse", input)
+		}
+	}
+}
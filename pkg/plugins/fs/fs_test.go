@@ -1,18 +1,47 @@
 package fs
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
 var (
 	minEntries = 3
+	fixtures   = "../../../test/notes"
 )
 
+// copyFixtures copies the notes in fixtures to a temporary directory, so the
+// files a store makes when it is opened, like its index, are not left behind
+func copyFixtures(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	infos, err := os.ReadDir(fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range infos {
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(fixtures, info.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, info.Name()), b, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
 func TestNext(t *testing.T) {
-	loader, err := New("../../../test/notes", false)
+	loader, err := New(copyFixtures(t), false, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer loader.Close()
 
 	entries, err := loader.ListAll()
 	if err != nil {
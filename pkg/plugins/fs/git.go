@@ -0,0 +1,464 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/user"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+const (
+	// SettingGit keeps the notebook in a git repository, committing every
+	// change to a note. A repository is created in the notebook directory if
+	// it is not the top of one already.
+	SettingGit = "git"
+	// SettingGitRemote is the remote of the repository to pull from when the
+	// notebook is opened, and to push to after every commit
+	SettingGitRemote = "gitRemote"
+
+	// gitIgnore keeps what every device keeps for itself out of the repository
+	gitIgnore = `# kept by jot on every device
+.index.json
+.index.json.tmp
+.history/
+.trash/
+`
+)
+
+var (
+	ErrNotGit = errors.New("notebook is not kept in git")
+
+	// GitTimeout is how long a git command may run before it is killed, like
+	// a pull or push to a remote that cannot be reached
+	GitTimeout = time.Minute
+	// PushWait is how long closing a notebook waits for its commits to be
+	// pushed
+	PushWait = 10 * time.Second
+)
+
+// GitCommit is a commit that changed a note
+type GitCommit struct {
+	Hash    string
+	Author  string
+	Time    time.Time
+	Subject string
+}
+
+// BlameLine is a line of a note, with the commit that last changed it
+type BlameLine struct {
+	GitCommit
+	Line string
+}
+
+// gitRepo runs git in the notebook directory, one command at a time
+type gitRepo struct {
+	sync.Mutex
+	dir    string
+	remote string
+	env    []string
+
+	// pushes run in the background, one at a time. pushPending is set when a
+	// commit is made while a push is running, so it is pushed too.
+	pushes      sync.WaitGroup
+	pushing     bool
+	pushPending bool
+}
+
+// openGit makes sure dir is the top of a git repository, creating one with the
+// notes already in dir if it is not. A repository dir is merely inside of, like
+// one of dotfiles, is left alone.
+func openGit(dir, remote string) (*gitRepo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("%s is kept in git, but git is not installed: %w", dir, err)
+	}
+	g := &gitRepo{dir: dir, remote: remote}
+
+	// commits are made even if the user never told git who they are
+	if _, err := g.run("config", "user.email"); err != nil {
+		name := "jot"
+		if u, err := user.Current(); err == nil {
+			name = u.Username
+		}
+		host, _ := os.Hostname()
+		g.env = []string{
+			"GIT_AUTHOR_NAME=" + name, "GIT_AUTHOR_EMAIL=" + name + "@" + host,
+			"GIT_COMMITTER_NAME=" + name, "GIT_COMMITTER_EMAIL=" + name + "@" + host,
+		}
+	}
+
+	if top, err := g.run("rev-parse", "--show-toplevel"); err == nil && sameDir(strings.TrimSpace(top), dir) {
+		return g, g.ignore()
+	}
+	if _, err := g.run("init", "-q"); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, ".gitignore"), []byte(gitIgnore), 0644); err != nil {
+		return nil, fmt.Errorf("unable to create a git repository in %s: %w", dir, err)
+	}
+	if _, err := g.run("add", "-A", "."); err != nil {
+		return nil, err
+	}
+	if _, err := g.run("commit", "-q", "-m", "Start keeping notes in git"); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// sameDir returns whether the paths are the same directory, through symlinks
+func sameDir(a, b string) bool {
+	a, errA := filepath.EvalSymlinks(a)
+	b, errB := filepath.EvalSymlinks(b)
+	return errA == nil && errB == nil && a == b
+}
+
+// ignore adds what is missing of gitIgnore to the .gitignore of a repository
+// that was not created by jot, and commits it
+func (g *gitRepo) ignore() error {
+	fn := filepath.Join(g.dir, ".gitignore")
+	b, err := ioutil.ReadFile(fn)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to read %s: %w", fn, err)
+	}
+	have := map[string]bool{}
+	for _, l := range strings.Split(string(b), "\n") {
+		have[strings.TrimSpace(l)] = true
+	}
+	lines := strings.Split(strings.TrimSpace(gitIgnore), "\n")
+	missing := []string{}
+	for _, l := range lines[1:] {
+		if !have[l] {
+			missing = append(missing, l)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if len(b) > 0 && !bytes.HasSuffix(b, []byte("\n")) {
+		b = append(b, '\n')
+	}
+	// the first line tells what the rest are
+	b = append(b, strings.Join(append(lines[:1], missing...), "\n")+"\n"...)
+	if err := ioutil.WriteFile(fn, b, 0644); err != nil {
+		return fmt.Errorf("unable to write %s: %w", fn, err)
+	}
+	return g.commit("Keep what jot keeps on every device out of git", ".gitignore")
+}
+
+// run runs git with args in the notebook directory, returning its output. It
+// is killed if it runs for longer than GitTimeout, and never prompts for
+// credentials.
+func (g *gitRepo) run(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), GitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", g.dir}, args...)...)
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), g.env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// branch is the branch checked out, which is pulled and pushed
+func (g *gitRepo) branch() (string, error) {
+	out, err := g.run("symbolic-ref", "--short", "HEAD")
+	return strings.TrimSpace(out), err
+}
+
+// show returns the file rel as of the last commit, or nil if it was not
+// committed
+func (g *gitRepo) show(rel string) []byte {
+	out, err := g.run("show", "HEAD:./"+rel)
+	if err != nil {
+		return nil
+	}
+	return []byte(out)
+}
+
+// commit commits the files at rels as they are on disk, including their
+// removal. Nothing is committed if none of them changed.
+func (g *gitRepo) commit(message string, rels ...string) error {
+	g.Lock()
+	defer g.Unlock()
+
+	paths := []string{}
+	for _, rel := range rels {
+		if _, err := os.Stat(filepath.Join(g.dir, rel)); err == nil {
+			if _, err := g.run("add", "--", rel); err != nil {
+				return err
+			}
+			paths = append(paths, rel)
+			continue
+		}
+		// a removed file is committed only if it was committed before
+		if out, err := g.run("ls-files", "--", rel); err == nil && strings.TrimSpace(out) != "" {
+			if _, err := g.run("rm", "-q", "--cached", "--", rel); err != nil {
+				return err
+			}
+			paths = append(paths, rel)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	if _, err := g.run(append([]string{"diff", "--cached", "--quiet", "--"}, paths...)...); err == nil {
+		// nothing changed
+		return nil
+	}
+	_, err := g.run(append([]string{"commit", "-q", "-m", message, "--"}, paths...)...)
+	return err
+}
+
+// pull rebases the commits of the notebook onto those of the remote
+func (g *gitRepo) pull() error {
+	if g.remote == "" {
+		return nil
+	}
+	g.Lock()
+	defer g.Unlock()
+
+	branch, err := g.branch()
+	if err != nil {
+		return err
+	}
+	if _, err := g.run("fetch", "-q", g.remote); err != nil {
+		return err
+	}
+	upstream := g.remote + "/" + branch
+	if _, err := g.run("rev-parse", "--verify", "--quiet", upstream); err != nil {
+		// nothing was pushed yet
+		return nil
+	}
+	if _, err := g.run("rebase", "-q", "--autostash", upstream); err != nil {
+		_, _ = g.run("rebase", "--abort")
+		return fmt.Errorf("unable to pull %s, the same notes were changed on both sides: %w", upstream, err)
+	}
+	return nil
+}
+
+// push pushes the branch to the remote. It is not pulled while notes are
+// being written, so a push rejected because the remote has new commits only
+// goes through once the notebook is opened again.
+func (g *gitRepo) push() error {
+	g.Lock()
+	defer g.Unlock()
+	branch, err := g.branch()
+	if err != nil {
+		return err
+	}
+	_, err = g.run("push", "-q", g.remote, "HEAD:"+branch)
+	return err
+}
+
+// waitForPushes waits for the pushes running in the background, for at most
+// d. It returns whether they are done.
+func (g *gitRepo) waitForPushes(d time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		g.pushes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
+
+// schedulePush pushes in the background, reporting failures with report
+func (g *gitRepo) schedulePush(report func(error)) {
+	if g.remote == "" {
+		return
+	}
+	g.Lock()
+	defer g.Unlock()
+	if g.pushing {
+		g.pushPending = true
+		return
+	}
+	g.pushing = true
+	g.pushes.Add(1)
+	go func() {
+		defer g.pushes.Done()
+		for {
+			if err := g.push(); err != nil {
+				report(err)
+			}
+			g.Lock()
+			if !g.pushPending {
+				g.pushing = false
+				g.Unlock()
+				return
+			}
+			g.pushPending = false
+			g.Unlock()
+		}
+	}()
+}
+
+// commitMessage describes the change to the note in the file rel, like
+// "2021-06-22: +2 tasks, 3 completed". Old or current is nil if the note was
+// created or removed. The tasks of sealed notes are not told.
+func commitMessage(rel string, old, current *v1.Note, sealed bool) string {
+	name := strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+	switch {
+	case old == nil && current == nil:
+		return name + ": changed"
+	case old == nil:
+		return name + ": created"
+	case current == nil:
+		return name + ": removed"
+	}
+	if sealed {
+		return name + ": edited"
+	}
+
+	o, c := v1.TaskList(old.Content), v1.TaskList(current.Content)
+	parts := []string{}
+	if d := c.Total - o.Total; d != 0 {
+		parts = append(parts, fmt.Sprintf("%+d tasks", d))
+	}
+	switch d := c.Checked - o.Checked; {
+	case d > 0:
+		parts = append(parts, fmt.Sprintf("%d completed", d))
+	case d < 0:
+		parts = append(parts, fmt.Sprintf("%d unchecked", -d))
+	}
+	if len(parts) == 0 {
+		return name + ": edited"
+	}
+	return name + ": " + strings.Join(parts, ", ")
+}
+
+// gitNote parses a version of the note in the file rel, if there is one
+func (x *Store) gitNote(rel string, b []byte) *v1.Note {
+	if b == nil {
+		return nil
+	}
+	e, _, err := x.decode(rel, time.Now(), b)
+	if err != nil {
+		// no longer a note, but still a change
+		return &v1.Note{}
+	}
+	return e
+}
+
+// gitCommit commits the notes in the files at rels, if the notebook is kept in
+// git, and pushes them in the background. The store must not be locked, as
+// reads would wait on git.
+func (x *Store) gitCommit(rels ...string) error {
+	if x.git == nil {
+		return nil
+	}
+	for _, rel := range rels {
+		old := x.git.show(rel)
+		current, err := ioutil.ReadFile(filepath.Join(x.Directory, rel))
+		if err != nil {
+			current = nil
+		}
+		sealed := isSealed(old) || isSealed(current)
+		message := commitMessage(rel, x.gitNote(rel, old), x.gitNote(rel, current), sealed)
+		if err := x.git.commit(message, rel); err != nil {
+			return fmt.Errorf("unable to commit %s: %w", rel, err)
+		}
+	}
+	x.git.schedulePush(func(err error) {
+		x.publish(diskChange{Kind: diskError, File: ".git", Err: fmt.Errorf("unable to push: %w", err)})
+	})
+	return nil
+}
+
+// IsGit returns whether the notebook is kept in git
+func (x *Store) IsGit() bool {
+	return x.git != nil
+}
+
+// PullError is why the notebook could not be pulled from its remote when it
+// was opened, if it could not
+func (x *Store) PullError() error {
+	return x.pullErr
+}
+
+// GitLog returns the commits that changed the note, newest first
+func (x *Store) GitLog(id v1.ID) ([]*GitCommit, error) {
+	if x.git == nil {
+		return nil, ErrNotGit
+	}
+	x.Lock()
+	rel := x.fileName(id)
+	x.Unlock()
+
+	out, err := x.git.run("log", "--follow", "--format=%H%x00%an%x00%at%x00%s", "--", rel)
+	if err != nil {
+		return nil, err
+	}
+	commits := []*GitCommit{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.SplitN(line, "\x00", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		at, _ := strconv.ParseInt(fields[2], 10, 64)
+		commits = append(commits, &GitCommit{Hash: fields[0], Author: fields[1], Time: time.Unix(at, 0), Subject: fields[3]})
+	}
+	return commits, nil
+}
+
+// GitBlame returns the lines of the note as committed, with the commit that
+// last changed each of them
+func (x *Store) GitBlame(id v1.ID) ([]*BlameLine, error) {
+	if x.git == nil {
+		return nil, ErrNotGit
+	}
+	x.Lock()
+	rel := x.fileName(id)
+	x.Unlock()
+
+	out, err := x.git.run("blame", "--line-porcelain", "HEAD", "--", rel)
+	if err != nil {
+		return nil, err
+	}
+
+	// every line is a header naming the commit, then the details of the
+	// commit, then the line itself
+	lines := []*BlameLine{}
+	var current *BlameLine
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case current == nil:
+			if fields := strings.Fields(line); len(fields) >= 3 {
+				current = &BlameLine{GitCommit: GitCommit{Hash: fields[0]}}
+			}
+		case strings.HasPrefix(line, "\t"):
+			current.Line = line[1:]
+			lines = append(lines, current)
+			current = nil
+		case strings.HasPrefix(line, "author "):
+			current.Author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-time "):
+			at, _ := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64)
+			current.Time = time.Unix(at, 0)
+		case strings.HasPrefix(line, "summary "):
+			current.Subject = strings.TrimPrefix(line, "summary ")
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,231 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+func TestGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	remote := t.TempDir()
+	git(t, remote, "init", "-q", "--bare")
+
+	dir := t.TempDir()
+	settings := map[string]string{SettingGit: "true", SettingGitRemote: "origin"}
+	x, err := New(dir, false, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.PullError() == nil {
+		t.Errorf("expected pulling from a missing remote to fail")
+	}
+	git(t, dir, "remote", "add", "origin", remote)
+
+	day := time.Date(2021, 6, 22, 12, 0, 0, 0, time.UTC)
+	e, err := x.CreateOrUpdateNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", Title: "first", CreationTimestamp: day}, Content: "- [ ] one\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := *e
+	n.Content = "- [x] one\n- [ ] two\n- [ ] three\n"
+	if _, err := x.CreateOrUpdateNote(&n); err != nil {
+		t.Fatal(err)
+	}
+	// writing the same note again commits nothing
+	if _, err := x.CreateOrUpdateNote(&n); err != nil {
+		t.Fatal(err)
+	}
+	x.Close()
+
+	if log := git(t, remote, "log", "--format=%s"); log != "2021-06-22: +2 tasks, 1 completed\n2021-06-22: created\nStart keeping notes in git\n" {
+		t.Fatalf("expected every write to be committed and pushed but got %q", log)
+	}
+	if status := git(t, dir, "status", "--porcelain"); status != "" {
+		t.Errorf("expected nothing left to commit but got %q", status)
+	}
+
+	// changed on another device, and pulled when opened again
+	other := t.TempDir()
+	git(t, other, "clone", "-q", remote, ".")
+	y, err := New(other, false, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := y.GetByID(e.Metadata.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := *o
+	m.Content = "- [x] one\n- [x] two\n- [ ] three\n"
+	if _, err := y.CreateOrUpdateNote(&m); err != nil {
+		t.Fatal(err)
+	}
+	y.Close()
+
+	x, err = New(dir, false, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	if err := x.PullError(); err != nil {
+		t.Fatal(err)
+	}
+	if e, err := x.GetByID(e.Metadata.ID, false); err != nil || e.Content != m.Content {
+		t.Fatalf("expected the change to be pulled but got %v, %v", e, err)
+	}
+
+	// edited outside of jot, and committed once the watcher picks it up
+	changes := x.Watch()
+	edited := "---\nid: 1624363200\ntitle: first\n---\n- [x] one\n- [x] two\n- [x] three\n"
+	if err := ioutil.WriteFile(path.Join(dir, "2021-06-22.md"), []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case c := <-changes:
+		if c.Kind != db.DocUpdated {
+			t.Fatalf("expected the note to be updated but got %v", c)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the edit to be picked up")
+	}
+
+	commits, err := x.GitLog(e.Metadata.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subjects := []string{}
+	for _, c := range commits {
+		subjects = append(subjects, c.Subject)
+	}
+	if strings.Join(subjects, "|") != "2021-06-22: 1 completed|2021-06-22: 1 completed|2021-06-22: +2 tasks, 1 completed|2021-06-22: created" {
+		t.Errorf("expected the log of the note newest first but got %q", subjects)
+	}
+
+	blame, err := x.GitBlame(e.Metadata.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := blame[len(blame)-1]
+	if last.Line != "- [x] three" || last.Hash != commits[0].Hash || last.Subject != commits[0].Subject {
+		t.Errorf("expected the last line to be blamed on the last commit but got %+v", last)
+	}
+}
+
+func TestGitInsideAnotherRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	outer := t.TempDir()
+	git(t, outer, "init", "-q")
+	git(t, outer, "-c", "user.name=a", "-c", "user.email=a@example.com", "commit", "-q", "--allow-empty", "-m", "dotfiles")
+
+	dir := path.Join(outer, "notes")
+	x, err := New(dir, true, map[string]string{SettingGit: "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2021, 6, 22, 12, 0, 0, 0, time.UTC)
+	if _, err := x.CreateOrUpdateNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", CreationTimestamp: day}, Content: "- [ ] one\n"}); err != nil {
+		t.Fatal(err)
+	}
+	x.Close()
+
+	if log := git(t, outer, "log", "--format=%s"); log != "dotfiles\n" {
+		t.Errorf("expected the repository the notebook is in to be left alone but got %q", log)
+	}
+	if log := git(t, dir, "log", "--format=%s"); log != "2021-06-22: created\nStart keeping notes in git\n" {
+		t.Errorf("expected the notebook to be kept in a repository of its own but got %q", log)
+	}
+}
+
+func TestGitIgnoredInExistingRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	if err := ioutil.WriteFile(path.Join(dir, ".gitignore"), []byte("*.swp\n.trash/"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	x, err := New(dir, false, map[string]string{SettingGit: "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Close()
+
+	b, err := ioutil.ReadFile(path.Join(dir, ".gitignore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "*.swp\n.trash/\n# kept by jot on every device\n.index.json\n.index.json.tmp\n.history/\n"; string(b) != want {
+		t.Errorf("expected what is missing to be ignored but got %q", b)
+	}
+	if status := git(t, dir, "status", "--porcelain", ".gitignore"); status != "" {
+		t.Errorf("expected .gitignore to be committed but got %q", status)
+	}
+
+	// nothing is added once it is all ignored
+	x, err = New(dir, false, map[string]string{SettingGit: "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Close()
+	if n := strings.Count(git(t, dir, "log", "--format=%s"), "\n"); n != 1 {
+		t.Errorf("expected a single commit but got %d", n)
+	}
+}
+
+func TestGitUnreachableRemote(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	defer func(timeout, wait time.Duration) { GitTimeout, PushWait = timeout, wait }(GitTimeout, PushWait)
+	GitTimeout, PushWait = 500*time.Millisecond, 100*time.Millisecond
+	defer os.Setenv("GIT_SSH_COMMAND", os.Getenv("GIT_SSH_COMMAND"))
+	os.Setenv("GIT_SSH_COMMAND", "sleep 10; false")
+
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	git(t, dir, "remote", "add", "origin", "ssh://example.invalid/notes")
+	x, err := New(dir, false, map[string]string{SettingGit: "true", SettingGitRemote: "origin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := x.PullError(); err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("expected the pull to be given up on but got %v", err)
+	}
+
+	day := time.Date(2021, 6, 22, 12, 0, 0, 0, time.UTC)
+	if _, err := x.CreateOrUpdateNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", CreationTimestamp: day}, Content: "- [ ] one\n"}); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := x.Close(); err == nil {
+		t.Error("expected closing to tell the commits are not pushed")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("expected closing to give up on the push but it took %s", d)
+	}
+	// the push is killed after GitTimeout
+	x.git.pushes.Wait()
+}
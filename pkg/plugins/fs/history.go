@@ -0,0 +1,219 @@
+package fs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+const (
+	// HistoryDirectory keeps the revisions of every note, within the notebook
+	// directory. Like every dot directory, it is not loaded.
+	HistoryDirectory = ".history"
+	revisionSuffix   = ".md.gz"
+)
+
+var (
+	// HistoryLimit is how many revisions are kept per note, dropping the oldest
+	HistoryLimit = 100
+)
+
+// Revision is a note as it was at some point, like before it was edited
+type Revision struct {
+	// Time is when the note was last modified in this revision, to the second
+	Time time.Time
+	Note *v1.Note
+}
+
+// historyDirectory is where the revisions of the note are kept. They are kept
+// by ID, so they follow the note when it is renamed or moved.
+func (x *Store) historyDirectory(id v1.ID) string {
+	return filepath.Join(x.Directory, HistoryDirectory, fmt.Sprintf("%d", id))
+}
+
+// revisionFiles returns the names of the revisions of the note, in the order
+// they were recorded
+func (x *Store) revisionFiles(id v1.ID) ([]string, error) {
+	infos, err := ioutil.ReadDir(x.historyDirectory(id))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the history of %d: %w", id, err)
+	}
+
+	names := []string{}
+	for _, info := range infos {
+		if _, ok := recordedAt(info.Name()); ok && !info.IsDir() {
+			names = append(names, info.Name())
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ni, _ := recordedAt(names[i])
+		nj, _ := recordedAt(names[j])
+		return ni < nj
+	})
+	return names, nil
+}
+
+// recordedAt parses when a revision was recorded from its file name, in
+// nanoseconds
+func recordedAt(name string) (int64, bool) {
+	if !strings.HasSuffix(name, revisionSuffix) {
+		return 0, false
+	}
+	ns, err := strconv.ParseInt(strings.TrimSuffix(name, revisionSuffix), 10, 64)
+	return ns, err == nil
+}
+
+// readRevision returns the note as it was in the revision, and when it was
+// last modified then
+func (x *Store) readRevision(id v1.ID, name string) ([]byte, time.Time, error) {
+	f, err := os.Open(filepath.Join(x.historyDirectory(id), name))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("unable to read revision %s of %d: %w", name, id, err)
+	}
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	return b, r.ModTime, err
+}
+
+// record keeps b as a revision of the note last modified at modTime, unless it
+// is the same as the latest revision. It does not lock the store, so it can be
+// used while writing.
+func (x *Store) record(id v1.ID, b []byte, modTime time.Time) error {
+	names, err := x.revisionFiles(id)
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		latest, _, err := x.readRevision(id, names[len(names)-1])
+		if err == nil && bytes.Equal(latest, b) {
+			return nil
+		}
+	}
+
+	dir := x.historyDirectory(id)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("unable to record revision of %d: %w", id, err)
+	}
+
+	// revisions are named by when they are recorded, which orders them even
+	// when the modification times of synced notes do not
+	ns := time.Now().UnixNano()
+	var f *os.File
+	for {
+		f, err = os.OpenFile(filepath.Join(dir, fmt.Sprintf("%d%s", ns, revisionSuffix)), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if !os.IsExist(err) {
+			break
+		}
+		ns++
+	}
+	if err != nil {
+		return fmt.Errorf("unable to record revision of %d: %w", id, err)
+	}
+	defer f.Close()
+
+	if err := writeRevision(f, b, modTime); err != nil {
+		return fmt.Errorf("unable to record revision of %d: %w", id, err)
+	}
+
+	for len(names) >= HistoryLimit && len(names) > 0 {
+		os.Remove(filepath.Join(dir, names[0]))
+		names = names[1:]
+	}
+	return nil
+}
+
+// writeRevision compresses b, a note last modified at modTime, into f
+func writeRevision(f *os.File, b []byte, modTime time.Time) error {
+	w := gzip.NewWriter(f)
+	w.ModTime = modTime
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// Snapshot records the note as it is on disk as a revision, if it changed
+// since the latest revision. Write does this on its own; Snapshot is for
+// notes about to be changed some other way, like in an editor.
+func (x *Store) Snapshot(id v1.ID) error {
+	x.Lock()
+	targetpath := x.fullStoragePathID(id)
+	x.Unlock()
+
+	finfo, err := os.Stat(targetpath)
+	if os.IsNotExist(err) {
+		// nothing to keep
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to snapshot %d: %w", id, err)
+	}
+	b, err := ioutil.ReadFile(targetpath)
+	if err != nil {
+		return fmt.Errorf("unable to snapshot %d: %w", id, err)
+	}
+	return x.record(id, b, finfo.ModTime())
+}
+
+// Revisions returns the revisions of the note, newest first. The history of
+// deleted notes is kept, so it can be read as long as the ID is known.
+func (x *Store) Revisions(id v1.ID) ([]*Revision, error) {
+	x.Lock()
+	rel := x.fileName(id)
+	x.Unlock()
+
+	names, err := x.revisionFiles(id)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]*Revision, 0, len(names))
+	for i := len(names) - 1; i >= 0; i-- {
+		b, t, err := x.readRevision(id, names[i])
+		if err != nil {
+			return nil, err
+		}
+		e, _, err := x.decode(rel, t, b)
+		if err != nil {
+			return nil, &LoadError{File: filepath.Join(HistoryDirectory, fmt.Sprintf("%d", id), names[i]), Err: err}
+		}
+		if e.Tasks != nil {
+			return nil, ErrLocked
+		}
+		e.Metadata.ID = id
+		revisions = append(revisions, &Revision{Time: t, Note: e})
+	}
+	return revisions, nil
+}
+
+// RestoreRevision brings back the content the note had in the revision. The
+// note keeps its current metadata, and the content being replaced is recorded
+// as a revision like any other, so restoring can be undone.
+func (x *Store) RestoreRevision(id v1.ID, r *Revision) (*v1.Note, error) {
+	e, err := x.GetByID(id, false)
+	if err != nil {
+		return nil, fmt.Errorf("unable to restore %d: %w", id, err)
+	}
+
+	n := *e
+	n.Content = r.Note.Content
+	return x.CreateOrUpdateNote(&n)
+}
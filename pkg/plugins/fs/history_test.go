@@ -0,0 +1,102 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+func TestHistory(t *testing.T) {
+	dir := t.TempDir()
+	x, err := New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+
+	day := time.Date(2021, 6, 22, 12, 0, 0, 0, time.UTC)
+	e, err := x.CreateOrUpdateNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", Title: "first", CreationTimestamp: day}, Content: "- [ ] one\n\nnotes\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := e.Metadata.ID
+
+	// changed in an editor, which Write keeps before overwriting it
+	edited := "---\nid: 1624363200\ntitle: first\n---\n- [x] one\n\nnotes\n"
+	if err := ioutil.WriteFile(path.Join(dir, "2021-06-22.md"), []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(path.Join(dir, "2021-06-22.md"), later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	// merged with the edit, which is recorded before
+	n := *e
+	n.Content = "- [ ] one\n\nnotes\n- [ ] two\n"
+	if _, err := x.CreateOrUpdateNote(&n); err != nil {
+		t.Fatal(err)
+	}
+	// writing the same note again records nothing new
+	if _, err := x.CreateOrUpdateNote(&n); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Snapshot(id); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := x.Revisions(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := []string{}
+	for _, r := range revisions {
+		contents = append(contents, r.Note.Content)
+	}
+	if len(contents) != 3 || contents[0] != "- [x] one\n\nnotes\n- [ ] two\n" || contents[1] != "- [x] one\n\nnotes\n" || contents[2] != "- [ ] one\n\nnotes\n" {
+		t.Fatalf("expected 3 revisions newest first but got %q", contents)
+	}
+	if !revisions[1].Time.Equal(later.Truncate(time.Second)) {
+		t.Errorf("expected the edited revision at %v but got %v", later, revisions[1].Time)
+	}
+
+	restored, err := x.RestoreRevision(id, revisions[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Content != "- [x] one\n\nnotes\n" || restored.Metadata.Title != "first" {
+		t.Errorf("expected the edited content to be restored but got %v", restored)
+	}
+	if revisions, err := x.Revisions(id); err != nil || len(revisions) != 4 {
+		t.Errorf("expected restoring to be recorded as a revision but got %d, %v", len(revisions), err)
+	}
+
+	// the oldest revisions make room for new ones
+	defer func(limit int) { HistoryLimit = limit }(HistoryLimit)
+	HistoryLimit = 2
+	n.Content = "- [ ] three\n"
+	if _, err := x.CreateOrUpdateNote(&n); err != nil {
+		t.Fatal(err)
+	}
+	revisions, err = x.Revisions(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].Note.Content != "- [ ] three\n" || revisions[1].Note.Content != "- [x] one\n\nnotes\n" {
+		t.Errorf("expected the 2 newest revisions to be kept but got %v", revisions)
+	}
+
+	// the history is not loaded as notes
+	reloaded, err := New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reloaded.Close()
+	if l, err := reloaded.ListAll(); err != nil || len(l) != 1 {
+		t.Errorf("expected only the note to be loaded but got %v, %v", l, err)
+	}
+}
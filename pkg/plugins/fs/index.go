@@ -0,0 +1,191 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+const (
+	// IndexFile keeps the metadata of the notes of a notebook, within the
+	// notebook directory, so that startup need not read every note. Like every
+	// dotfile, it is not loaded.
+	IndexFile    = ".index.json"
+	indexVersion = 2
+)
+
+// index is what is known of the notes of a notebook, by file relative to
+// Directory
+type index struct {
+	Version int                    `json:"version"`
+	Notes   map[string]*indexEntry `json:"notes"`
+}
+
+// indexEntry is the note in a file as of when the file was last read. It is
+// valid as long as the file keeps its modification time and size, or its hash.
+type indexEntry struct {
+	ModTime  time.Time         `json:"modTime"`
+	Size     int64             `json:"size"`
+	Hash     string            `json:"hash"`
+	Inferred bool              `json:"inferred,omitempty"`
+	Metadata v1.NoteMetadata   `json:"metadata"`
+	Tasks    v1.TaskListStatus `json:"tasks"`
+}
+
+func (x *Store) indexFile() string {
+	return filepath.Join(x.Directory, IndexFile)
+}
+
+func hashNote(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// readIndex returns the entries of the index of the notebook. An index that
+// is missing, unreadable or of another version is as good as empty, because
+// the notes are read again.
+func (x *Store) readIndex() map[string]*indexEntry {
+	idx := index{}
+	b, err := ioutil.ReadFile(x.indexFile())
+	if err != nil || json.Unmarshal(b, &idx) != nil || idx.Version != indexVersion || idx.Notes == nil {
+		return map[string]*indexEntry{}
+	}
+	return idx.Notes
+}
+
+// saveIndex writes the index if it changed since it was read or saved
+func (x *Store) saveIndex() error {
+	x.Lock()
+	defer x.Unlock()
+	if !x.indexDirty {
+		return nil
+	}
+
+	b, err := json.Marshal(index{Version: indexVersion, Notes: x.index})
+	if err != nil {
+		return fmt.Errorf("unable to encode the index of %s: %w", x.Directory, err)
+	}
+	// write a whole new index, so a crash cannot leave half of one
+	tmp := x.indexFile() + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return fmt.Errorf("unable to save the index of %s: %w", x.Directory, err)
+	}
+	if err := os.Rename(tmp, x.indexFile()); err != nil {
+		return fmt.Errorf("unable to save the index of %s: %w", x.Directory, err)
+	}
+	x.indexDirty = false
+	return nil
+}
+
+// indexNote records the note read from or written to the file rel. Only what
+// is readable in the file of a sealed note is recorded. The store must be
+// locked.
+func (x *Store) indexNote(rel string, finfo os.FileInfo, b []byte, e *v1.Note, inferred bool) {
+	entry := &indexEntry{
+		ModTime:  finfo.ModTime(),
+		Size:     finfo.Size(),
+		Hash:     hashNote(b),
+		Inferred: inferred,
+		Metadata: e.Metadata,
+		Tasks:    v1.TaskList(e.Content),
+	}
+	if isSealed(b) {
+		entry.Metadata, entry.Tasks = sealedMetadata(e, x.sealOf(e)), v1.TaskListStatus{}
+	}
+	x.index[rel] = entry
+	x.indexDirty = true
+}
+
+// loadFromIndex lists the note in the file rel from the index, without
+// reading its content, if the index is still valid for the file. Files
+// touched without being changed, like by some sync clients, are only hashed.
+func (x *Store) loadFromIndex(rel string, finfo os.FileInfo) bool {
+	entry, ok := x.index[rel]
+	if !ok || entry.Size != finfo.Size() {
+		return false
+	}
+	if !entry.ModTime.Equal(finfo.ModTime()) {
+		b, err := ioutil.ReadFile(filepath.Join(x.Directory, rel))
+		if err != nil || hashNote(b) != entry.Hash {
+			return false
+		}
+		entry.ModTime = finfo.ModTime()
+		x.indexDirty = true
+	}
+
+	id := entry.Metadata.ID
+	if _, taken := x.files[id]; taken {
+		return false
+	}
+	tasks := entry.Tasks
+	x.entries[id] = &v1.Note{Metadata: entry.Metadata, Tasks: &tasks}
+	x.files[id] = rel
+	x.mtimeMap[id] = finfo.ModTime()
+	return true
+}
+
+// loaded reads the content of e if it was listed from the index. Notes that
+// were listed are never changed, as they are read without the lock, so the
+// note read replaces e in the store and is returned instead.
+func (x *Store) loaded(e *v1.Note) (*v1.Note, error) {
+	id := e.Metadata.ID
+	x.Lock()
+	if cur, ok := x.entries[id]; ok && cur.Tasks == nil {
+		// read since e was listed
+		e = cur
+	}
+	stub := e.Tasks != nil
+	fn := x.fullStoragePathID(id)
+	x.Unlock()
+	if !stub {
+		return e, nil
+	}
+
+	n, err := x.LoadFromFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	if n.Tasks != nil {
+		return nil, fmt.Errorf("%d: %w", id, ErrLocked)
+	}
+	if n.Metadata.ID != id {
+		// the file holds another note since it was indexed
+		return n, nil
+	}
+
+	x.Lock()
+	defer x.Unlock()
+	if x.entries[id] == e {
+		x.entries[id] = n
+		x.sorted = nil
+	}
+	return n, nil
+}
+
+// LoadAll reads the content of every note listed from the index, for
+// searching. Sealed notes of a locked notebook are left out.
+func (x *Store) LoadAll() error {
+	x.Lock()
+	stubs := []*v1.Note{}
+	for _, e := range x.entries {
+		if e.Tasks != nil {
+			stubs = append(stubs, e)
+		}
+	}
+	x.Unlock()
+
+	for _, e := range stubs {
+		if _, err := x.loaded(e); err != nil && !errors.Is(err, ErrLocked) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package fs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+func TestIndex(t *testing.T) {
+	dir := t.TempDir()
+	x, err := New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2021, 6, 22, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		e := &v1.Note{Metadata: v1.NoteMetadata{Author: "a", Tags: []string{"work"}, CreationTimestamp: day.AddDate(0, 0, i)}, Content: "- [x] one\n- [ ] two\n"}
+		if _, err := x.CreateOrUpdateNote(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(path.Join(dir, "plain.md"), []byte("# Plain\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.LoadFromFile(path.Join(dir, "plain.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path.Join(dir, IndexFile)); err != nil {
+		t.Fatalf("expected the index to be saved: %v", err)
+	}
+
+	// changed, touched without changes and removed since indexed
+	if err := ioutil.WriteFile(path.Join(dir, "2021-06-23.md"), []byte("---\nid: 1624449600\nauthor: a\ncreated: 2021-06-23T12:00:00Z\n---\n- [x] one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path.Join(dir, "2021-06-24.md"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path.Join(dir, "2021-06-22.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	x, err = New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+
+	notes, err := x.ListAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 3 {
+		t.Fatalf("expected 3 notes but got %d", len(notes))
+	}
+	byFile := map[string]*v1.Note{}
+	for _, e := range notes {
+		byFile[x.fileName(e.Metadata.ID)] = e
+	}
+
+	changed, touched, plain := byFile["2021-06-23.md"], byFile["2021-06-24.md"], byFile["plain.md"]
+	if changed == nil || changed.Tasks != nil || changed.Content != "- [x] one\n" {
+		t.Errorf("expected the changed note to be read again but got %+v", changed)
+	}
+	if touched == nil || touched.Tasks == nil || touched.Content != "" {
+		t.Fatalf("expected the touched note to be listed from the index but got %+v", touched)
+	}
+	if s := touched.NoteTaskStatus(v1.TaskStyleDiscrete); s != "1/2" || len(touched.Metadata.Tags) != 1 {
+		t.Errorf("expected the tasks and tags of the touched note from the index but got %s, %v", s, touched.Metadata.Tags)
+	}
+	if plain == nil || plain.Metadata.Title != "Plain" {
+		t.Errorf("expected the plain note to keep its inferred metadata but got %+v", plain)
+	}
+
+	// reading the content replaces the listed note, which is left as it was
+	e, err := x.GetByID(touched.Metadata.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e == touched || e.Tasks != nil || e.Content != "- [x] one\n- [ ] two\n" {
+		t.Errorf("expected the content to be read but got %+v", e)
+	}
+	if touched.Tasks == nil || touched.Content != "" {
+		t.Errorf("expected the listed note to be left as it was but got %+v", touched)
+	}
+	if again, err := x.GetByID(touched.Metadata.ID, false); err != nil || again != e {
+		t.Errorf("expected the note read to be kept but got %+v, %v", again, err)
+	}
+	if err := x.LoadAll(); err != nil {
+		t.Fatal(err)
+	}
+	if plain, err = x.GetByID(plain.Metadata.ID, false); err != nil || plain.Tasks != nil || plain.Content != "# Plain\n" {
+		t.Errorf("expected LoadAll to read every note but got %+v, %v", plain, err)
+	}
+
+	idx := x.readIndex()
+	if _, ok := idx["2021-06-22.md"]; ok || len(idx) != 3 {
+		t.Errorf("expected the removed note to be dropped from the index but got %v", idx)
+	}
+	if !idx["2021-06-24.md"].ModTime.Equal(later) {
+		t.Errorf("expected the index to follow the touched note to %v but got %v", later, idx["2021-06-24.md"].ModTime)
+	}
+}
+
+// TestIndexConcurrentReads reads notes listed from the index while their
+// content is read, which is run with -race
+func TestIndexConcurrentReads(t *testing.T) {
+	dir := t.TempDir()
+	x, err := New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2021, 6, 22, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 20; i++ {
+		e := &v1.Note{Metadata: v1.NoteMetadata{Author: "a", CreationTimestamp: day.AddDate(0, 0, i)}, Content: "- [ ] one\n"}
+		if _, err := x.CreateOrUpdateNote(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	x, err = New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	notes, err := x.ListAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		for _, e := range notes {
+			if _, err := x.GetByID(e.Metadata.ID, false); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- x.LoadAll()
+	}()
+	go func() {
+		for range notes {
+			if _, err := x.ListAll(); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	for _, e := range notes {
+		if e.Content != "" || e.Tasks == nil {
+			t.Errorf("expected %d to be listed from the index but got %+v", e.Metadata.ID, e)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkNew measures startup on a journal of 10k daily entries, when every
+// note is read and when the notes are listed from the index
+func BenchmarkNew(b *testing.B) {
+	dir := b.TempDir()
+	day := time.Date(1990, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 10000; i++ {
+		e := &v1.Note{
+			Metadata: v1.NoteMetadata{ID: v1.ID(day.AddDate(0, 0, i).Unix()), Author: "a", Tags: []string{"work"}, CreationTimestamp: day.AddDate(0, 0, i)},
+			Content:  fmt.Sprintf("# Day %d\n\n- [x] one\n- [ ] two\n\nSome notes about the day.\n", i),
+		}
+		bytes, err := encodeNote(e)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path.Join(dir, e.Metadata.CreationTimestamp.Format(StorageFilenameFormat)), bytes, 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	open := func(b *testing.B) {
+		x, err := New(dir, false, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if n := x.Count(); n != 10000 {
+			b.Fatalf("expected 10000 notes but got %d", n)
+		}
+		x.Close()
+	}
+
+	b.Run("read", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			os.Remove(path.Join(dir, IndexFile))
+			open(b)
+		}
+	})
+	b.Run("indexed", func(b *testing.B) {
+		open(b)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			open(b)
+		}
+	})
+}
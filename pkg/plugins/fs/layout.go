@@ -0,0 +1,203 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// Settings of a notes section, from config.Section.Settings
+const (
+	// SettingDirectory is where the notebook is stored, relative to the notes
+	// directory. Defaults to the notes directory itself.
+	SettingDirectory = "directory"
+	// SettingLayout is the time layout of the path of daily entries within the
+	// notebook, like 2006/01/2006-01-02.md. Defaults to StorageFilenameFormat.
+	SettingLayout = "layout"
+	// SettingExclude is a comma separated list of subdirectories of the notebook
+	// that hold other notebooks
+	SettingExclude = "exclude"
+	// SettingEncrypted seals every note of the notebook with its passphrase,
+	// either SealContent or SealMetadata. Notes can also be sealed one by one
+	// with LabelEncrypted.
+	SettingEncrypted = "encrypted"
+)
+
+// layout is where a notebook keeps its files
+type layout struct {
+	directory string
+	// daily is the time layout of daily entry paths, relative to directory
+	daily string
+	// exclude are the subdirectories of directory holding other notebooks
+	exclude map[string]bool
+}
+
+// NotebookDirectory returns the directory the notebook configured by settings
+// is stored in, within the notes directory dir
+func NotebookDirectory(dir string, settings map[string]string) (string, error) {
+	expandedPath, err := homedir.Expand(dir)
+	if err != nil {
+		return "", err
+	}
+
+	sub, err := homedir.Expand(settings[SettingDirectory])
+	if err != nil {
+		return "", err
+	}
+	if filepath.IsAbs(sub) {
+		return filepath.Clean(sub), nil
+	}
+	return filepath.Join(expandedPath, sub), nil
+}
+
+func newLayout(dir string, settings map[string]string) (*layout, error) {
+	directory, err := NotebookDirectory(dir, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	l := layout{
+		directory: directory,
+		daily:     StorageFilenameFormat,
+		exclude:   map[string]bool{},
+	}
+	if daily := settings[SettingLayout]; daily != "" {
+		if err := validateDailyLayout(daily); err != nil {
+			return nil, err
+		}
+		l.daily = daily
+	}
+	for _, ex := range strings.Split(settings[SettingExclude], ",") {
+		if ex = strings.TrimSpace(ex); ex != "" {
+			l.exclude[filepath.Clean(ex)] = true
+		}
+	}
+	return &l, nil
+}
+
+// validateDailyLayout makes sure every day gets its own markdown file
+func validateDailyLayout(daily string) error {
+	if path.Ext(daily) != path.Ext(StorageGlob) {
+		return fmt.Errorf("layout %q must end in %s", daily, path.Ext(StorageGlob))
+	}
+	d := time.Date(2021, 6, 22, 0, 0, 0, 0, time.UTC)
+	if parsed, err := time.Parse(daily, d.Format(daily)); err != nil || !parsed.Equal(d) {
+		return fmt.Errorf("layout %q must include the year, month and day", daily)
+	}
+	return nil
+}
+
+// dailyFile returns the path of the daily entry with id, relative to the
+// notebook directory
+func (l *layout) dailyFile(id int64) string {
+	return time.Unix(id, int64(0)).UTC().Format(l.daily)
+}
+
+// isDaily returns whether rel is the path of a daily entry
+func (l *layout) isDaily(rel string) bool {
+	_, err := time.Parse(l.daily, filepath.ToSlash(rel))
+	return err == nil
+}
+
+// walk calls fn with the path relative to the notebook directory of every
+// file and subdirectory, leaving out dotfiles and other notebooks
+func (l *layout) walk(fn func(rel string, info os.FileInfo) error) error {
+	return filepath.Walk(l.directory, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(l.directory, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if strings.HasPrefix(info.Name(), ".") || (info.IsDir() && l.exclude[rel]) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return fn(filepath.ToSlash(rel), info)
+	})
+}
+
+// Move is a file moved by MigrateLayout, relative to the notebook directory
+type Move struct {
+	From string
+	To   string
+}
+
+// MigrateLayout moves the daily entries of the notebook configured by settings
+// from the paths of the from layout to those of the configured layout. Nothing
+// is moved if any of the files would collide. Named notes stay where they are.
+func MigrateLayout(dir string, settings map[string]string, from string, dryRun bool) ([]Move, error) {
+	if err := validateDailyLayout(from); err != nil {
+		return nil, err
+	}
+	l, err := newLayout(dir, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	moves := []Move{}
+	existing := map[string]bool{}
+	err = l.walk(func(rel string, info os.FileInfo) error {
+		if info.IsDir() {
+			return nil
+		}
+		existing[rel] = true
+		if d, err := time.Parse(from, rel); err == nil {
+			if to := d.Format(l.daily); to != rel {
+				moves = append(moves, Move{From: rel, To: to})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", l.directory, err)
+	}
+	sort.Slice(moves, func(i, j int) bool { return moves[i].From < moves[j].From })
+
+	targets := map[string]string{}
+	for _, m := range moves {
+		if other, ok := targets[m.To]; ok {
+			return nil, fmt.Errorf("both %s and %s would move to %s", other, m.From, m.To)
+		}
+		if existing[m.To] {
+			return nil, fmt.Errorf("unable to move %s to %s: %w", m.From, m.To, os.ErrExist)
+		}
+		targets[m.To] = m.From
+	}
+
+	if dryRun {
+		return moves, nil
+	}
+
+	for _, m := range moves {
+		target := filepath.Join(l.directory, m.To)
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(filepath.Join(l.directory, m.From), target); err != nil {
+			return nil, err
+		}
+		l.removeEmptyDirs(filepath.Dir(m.From))
+	}
+	return moves, nil
+}
+
+// removeEmptyDirs removes rel and its parents, up to the notebook directory, as
+// long as they are empty
+func (l *layout) removeEmptyDirs(rel string) {
+	for rel != "." && rel != "/" && rel != "" {
+		if err := os.Remove(filepath.Join(l.directory, rel)); err != nil {
+			return
+		}
+		rel = filepath.Dir(rel)
+	}
+}
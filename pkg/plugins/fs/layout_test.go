@@ -0,0 +1,68 @@
+package fs
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+func TestMigrateLayout(t *testing.T) {
+	dir := t.TempDir()
+	settings := map[string]string{SettingLayout: "2006/01/2006-01-02.md", SettingExclude: "work"}
+
+	// 1624308406 is 2021-06-21T20:46:46Z
+	writeFixture(t, dir, "2021-06-21.md", "---\nid: 1624308406\nauthor: a\ncreated: 2021-06-21T16:46:46-04:00\n---\nok\n")
+	writeFixture(t, dir, "2021-07-01.md", "plain\n")
+	writeFixture(t, dir, "q3-planning.md", "# Q3 planning\n")
+	writeFixture(t, dir, "work/2021-06-21.md", "another notebook\n")
+
+	moves, err := MigrateLayout(dir, settings, StorageFilenameFormat, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moves) != 2 || moves[0] != (Move{From: "2021-06-21.md", To: "2021/06/2021-06-21.md"}) {
+		t.Fatalf("unexpected moves %v", moves)
+	}
+	if _, err := os.Stat(path.Join(dir, "2021-06-21.md")); err != nil {
+		t.Fatalf("expected a dry run not to move anything: %v", err)
+	}
+
+	if _, err := MigrateLayout(dir, settings, StorageFilenameFormat, false); err != nil {
+		t.Fatal(err)
+	}
+
+	x, err := New(dir, false, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	if x.Count() != 3 {
+		t.Fatalf("expected the other notebook to be excluded, but loaded %d notes", x.Count())
+	}
+	e, err := x.GetByDay(time.Date(2021, 6, 21, 20, 0, 0, 0, time.UTC))
+	if err != nil || e.Metadata.ID != 1624308406 {
+		t.Fatalf("expected the migrated entry for 2021-06-21 but got %v, %v", e, err)
+	}
+	if p := x.StoragePathDoc(e.Identifier()); p != path.Join(dir, "2021/06/2021-06-21.md") {
+		t.Errorf("unexpected storage path %s", p)
+	}
+
+	// new entries are written into the layout
+	n, err := x.CreateOrUpdateNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", CreationTimestamp: time.Unix(1627819200, 0)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := x.StoragePathDoc(n.Identifier()); p != path.Join(dir, "2021/08/2021-08-01.md") {
+		t.Errorf("unexpected storage path %s", p)
+	}
+
+	if _, err := MigrateLayout(dir, settings, "2006/01/2006-01-02.md", false); err != nil {
+		t.Errorf("expected migrating to the current layout to be a noop: %v", err)
+	}
+	if _, err := New(dir, false, map[string]string{SettingLayout: "2006-01.md"}); err == nil {
+		t.Errorf("expected a layout without the day to be rejected")
+	}
+}
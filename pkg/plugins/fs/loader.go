@@ -1,21 +1,25 @@
 package fs
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/byxorna/jot/pkg/db"
 	"github.com/byxorna/jot/pkg/types"
 	"github.com/byxorna/jot/pkg/types/v1"
+	"github.com/byxorna/jot/pkg/types/v2"
 	"github.com/fsnotify/fsnotify"
 	"github.com/go-playground/validator"
 	"github.com/mitchellh/go-homedir"
@@ -27,34 +31,89 @@ var (
 	StorageGlob           = "*.md"
 
 	ErrUnableToFindMetadataSection = fmt.Errorf("unable to find metadata yaml at header of note")
+	ErrNoteExists                  = fmt.Errorf("note already exists")
+
+	// SchemaVersion is the schema notes are stored in. Notes stored in an
+	// older one are upgraded when they are loaded, and stored in this one when
+	// they are next written.
+	SchemaVersion = v2.APIVersion
+
+	slugInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
 
 	docTypes = types.NewDocTypeSet(types.NoteDoc)
 )
 
+// LoadError is returned by New when a note in the directory cannot be loaded
+type LoadError struct {
+	File string
+	Err  error
+}
+
+func (e *LoadError) Error() string { return fmt.Sprintf("unable to load %s: %v", e.File, e.Err) }
+func (e *LoadError) Unwrap() error { return e.Err }
+
 type Store struct {
 	*sync.Mutex
 
-	Directory string `yaml"directory" validate:"required,dir"`
+	Directory string `yaml:"directory" validate:"required,dir"`
 
-	status   v1.SyncStatus `validate:"required"`
-	entries  map[v1.ID]*v1.Note
+	status  v1.SyncStatus `validate:"required"`
+	layout  *layout
+	entries map[v1.ID]*v1.Note
+	// files is the path of the file each note was loaded from or written to,
+	// relative to Directory, because named notes are not named after their ID
+	// like daily entries
+	files    map[v1.ID]string
 	mtimeMap map[v1.ID]time.Time
+	// bases are the notes as last loaded or written, to merge the changes made
+	// on disk since into writes
+	bases map[v1.ID]base
+	// index is saved to IndexFile, to list the notes at startup without
+	// reading them
+	index      map[string]*indexEntry
+	indexDirty bool
+	// sorted caches ListAll until the notes change
+	sorted []*v1.Note
+	// sealing is how notes are encrypted unless they say otherwise, see
+	// SettingEncrypted. key is derived from the passphrase by UnlockWith.
+	sealing string
+	key     atomic.Value
+	// git commits every change to a note, if the notebook is kept in git.
+	// pullErr is why it could not be pulled when it was opened. uncommitted
+	// are the files written while the store is locked, which are committed
+	// once it is unlocked.
+	git         *gitRepo
+	pullErr     error
+	uncommitted []string
+
 	watcher  *fsnotify.Watcher
+	watching chan struct{}
+	// changes are the notes added, updated and removed, by the Store or on
+	// disk, for Watch
+	changes *db.Watchers
 }
 
-func New(dir string, createDirIfMissing bool) (*Store, error) {
-	expandedPath, err := homedir.Expand(dir)
+// New loads the notebook in dir configured by settings (see SettingDirectory,
+// SettingLayout and SettingExclude), including all of its subdirectories
+func New(dir string, createDirIfMissing bool, settings map[string]string) (*Store, error) {
+	l, err := newLayout(dir, settings)
 	if err != nil {
 		return nil, err
 	}
 
 	s := Store{
 		Mutex:     &sync.Mutex{},
-		Directory: expandedPath,
+		Directory: l.directory,
+		layout:    l,
 		status:    v1.StatusUninitialized,
 		entries:   map[v1.ID]*v1.Note{},
+		files:     map[v1.ID]string{},
 		mtimeMap:  map[v1.ID]time.Time{},
+		bases:     map[v1.ID]base{},
+		sealing:   settings[SettingEncrypted],
+		changes:   &db.Watchers{},
 	}
+	s.index = s.readIndex()
 
 	{ // ensure the notes directory is created. TODO should this be part of the fs storage provider
 		expandedPath, err := homedir.Expand(s.Directory)
@@ -64,7 +123,7 @@ func New(dir string, createDirIfMissing bool) (*Store, error) {
 
 		finfo, err := os.Stat(expandedPath)
 		if err != nil || !finfo.IsDir() {
-			err := os.Mkdir(expandedPath, 0700)
+			err := os.MkdirAll(expandedPath, 0700)
 			if err != nil {
 				return nil, fmt.Errorf("error creating %s: %w", s.Directory, err)
 			}
@@ -75,18 +134,45 @@ func New(dir string, createDirIfMissing bool) (*Store, error) {
 			return nil, fmt.Errorf("error validating storage provider: %w", err)
 		}
 
-		// Load up all the files we can find at startup
-		noteFiles, err := filepath.Glob(path.Join(expandedPath, StorageGlob))
+		// pull before loading, so the notes changed elsewhere are loaded
+		if settings[SettingGit] == "true" {
+			if s.git, err = openGit(expandedPath, settings[SettingGitRemote]); err != nil {
+				return nil, err
+			}
+			s.pullErr = s.git.pull()
+		}
+
+		// Load up all the files we can find at startup, reading only those
+		// that changed since they were indexed
+		seen := map[string]bool{}
+		err = l.walk(func(rel string, info os.FileInfo) error {
+			if matched, _ := path.Match(StorageGlob, info.Name()); info.IsDir() || !matched {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+			seen[rel] = true
+			if s.loadFromIndex(rel, info) {
+				return nil
+			}
+			fn := filepath.Join(expandedPath, rel)
+			//fmt.Fprintf(os.Stderr, "loading %s\n", fn)
+			if _, err := s.LoadFromFile(fn); err != nil {
+				return &LoadError{File: fn, Err: err}
+			}
+			return nil
+		})
 		if err != nil {
 			return nil, err
 		}
-		for _, fn := range noteFiles {
-			//fmt.Fprintf(os.Stderr, "loading %s\n", fn)
-			e, err := s.LoadFromFile(fn)
-			if err != nil {
-				return nil, err
+
+		for rel := range s.index {
+			if !seen[rel] {
+				delete(s.index, rel)
+				s.indexDirty = true
 			}
-			s.entries[e.Metadata.ID] = e
+		}
+		if err := s.saveIndex(); err != nil {
+			return nil, err
 		}
 	}
 
@@ -99,62 +185,6 @@ func New(dir string, createDirIfMissing bool) (*Store, error) {
 	return &s, nil
 }
 
-func (x *Store) startWatcher() error {
-	if x.watcher != nil {
-		_ = x.watcher.Close()
-	}
-
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return err
-	}
-
-	err = watcher.Add(x.Directory)
-	if err != nil {
-		return fmt.Errorf("unable to watch %s: %w", x.Directory, err)
-	}
-
-	x.watcher = watcher
-
-	go func() {
-		for {
-			select {
-			case event, ok := <-watcher.Events:
-				if !ok {
-					return
-				}
-
-				//fmt.Fprintf(os.Stderr, "event: %v\n", event)
-				if event.Op&fsnotify.Write == fsnotify.Write || event.Op&fsnotify.Create == fsnotify.Create {
-					//fmt.Fprintln(os.Stderr, "modified file:", event.Name)
-					entries, _ := x.ListAll()
-					for _, e := range entries {
-						id, err := strconv.ParseInt(e.Identifier().String(), 10, 64)
-						if err != nil {
-							id = e.Created().Unix()
-						}
-						expectedFileName := id2File(id)
-						if expectedFileName == path.Base(event.Name) {
-							//fmt.Fprintf(os.Stderr, "reconciling %s\n", event.Name)
-							_, err := x.Reconcile(e.Identifier())
-							if err != nil {
-								// TODO: do something better
-								fmt.Fprintf(os.Stderr, "error reconciling %d: %v\n", int64(e.Metadata.ID), err)
-							}
-						}
-					}
-				}
-			case err, ok := <-watcher.Errors:
-				if !ok {
-					return
-				}
-				fmt.Println("error:", err)
-			}
-		}
-	}()
-	return nil
-}
-
 func (x *Store) Validate() error {
 	validate := validator.New()
 	err := validate.Struct(*x)
@@ -170,7 +200,10 @@ func parseID(id string) (int64, error) {
 	return id64, err
 }
 
-func (x *Store) Get(id types.DocIdentifier, hardread bool) (db.Doc, error) {
+func (x *Store) Get(ctx context.Context, id types.DocIdentifier, hardread bool) (db.Doc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	id64, err := parseID(id.String())
 	if err != nil {
 		return nil, err
@@ -189,34 +222,170 @@ func (x *Store) GetByID(id v1.ID, hardread bool) (*v1.Note, error) {
 		return n, nil
 	}
 
+	x.Lock()
 	e, ok := x.entries[id]
+	x.Unlock()
 	if !ok {
 		return nil, db.ErrNoNoteFound
 	}
-	return e, nil
+	return x.loaded(e)
+}
+
+// GetByDay returns the daily entry created on the same day as t
+func (x *Store) GetByDay(t time.Time) (*v1.Note, error) {
+	entries, err := x.ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	expectedFilename := t.Format(StorageFilenameFormat)
+	for _, e := range entries {
+		if !x.IsDaily(e.Metadata.ID) {
+			continue
+		}
+		if e.Metadata.CreationTimestamp.Format(StorageFilenameFormat) == expectedFilename {
+			return x.loaded(e)
+		}
+	}
+	return nil, db.ErrNoNoteFound
 }
 
 func (x *Store) CreateOrUpdateNote(e *v1.Note) (*v1.Note, error) {
 	x.Lock()
-	defer x.Unlock()
+	x.assignID(e)
+
+	// TODO: union tags and labels with defaults
 
+	if err := x.write(e); err != nil {
+		x.Unlock()
+		return nil, fmt.Errorf("unable to store note %d: %w", e.Metadata.ID, err)
+	}
+
+	kind := db.DocAdded
+	if _, ok := x.entries[e.Metadata.ID]; ok {
+		kind = db.DocUpdated
+	}
+	x.changes.Publish(db.Change{Kind: kind, ID: e.Identifier(), Status: x.status})
+	x.entries[e.Metadata.ID] = e
+	x.sorted = nil
+	if _, ok := x.files[e.Metadata.ID]; !ok {
+		x.files[e.Metadata.ID] = x.layout.dailyFile(int64(e.Metadata.ID))
+	}
+
+	return e, x.unlockAndCommit()
+}
+
+// CreateNamedNote stores a new note in a file named after name instead of the
+// day it was created, so any number of notes can be created on the same day
+func (x *Store) CreateNamedNote(e *v1.Note, name string) (*v1.Note, error) {
+	fn, err := NamedFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if x.layout.isDaily(fn) {
+		return nil, fmt.Errorf("%s is reserved for the daily entry", fn)
+	}
+
+	x.Lock()
+	for _, f := range x.files {
+		if f == fn {
+			x.Unlock()
+			return nil, fmt.Errorf("%s: %w", fn, ErrNoteExists)
+		}
+	}
+	if _, err := os.Stat(path.Join(x.Directory, fn)); err == nil {
+		x.Unlock()
+		return nil, fmt.Errorf("%s: %w", fn, ErrNoteExists)
+	}
+
+	e.Metadata.ID = 0
+	x.assignID(e)
+
+	x.files[e.Metadata.ID] = fn
+	if err := x.write(e); err != nil {
+		delete(x.files, e.Metadata.ID)
+		x.Unlock()
+		return nil, fmt.Errorf("unable to store note %s: %w", fn, err)
+	}
+
+	x.entries[e.Metadata.ID] = e
+	x.sorted = nil
+	x.changes.Publish(db.Change{Kind: db.DocAdded, ID: e.Identifier(), Status: x.status})
+
+	return e, x.unlockAndCommit()
+}
+
+// Capabilities tells that notes can be created, updated and deleted
+func (x *Store) Capabilities() db.Capability {
+	return db.CanCreate | db.CanUpdate | db.CanDelete
+}
+
+// Create stores a new note in a file named after its title, like jot new
+func (x *Store) Create(ctx context.Context, d db.Doc) (db.Doc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	e, ok := d.(*v1.Note)
+	if !ok {
+		return nil, fmt.Errorf("unable to store a %s in a notebook", d.DocType())
+	}
+	return x.CreateNamedNote(e, e.Title())
+}
+
+// Update stores a note already in the notebook
+func (x *Store) Update(ctx context.Context, d db.Doc) (db.Doc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	e, ok := d.(*v1.Note)
+	if !ok {
+		return nil, fmt.Errorf("unable to store a %s in a notebook", d.DocType())
+	}
+	if !x.HasNote(e.Metadata.ID) {
+		return nil, fmt.Errorf("%d: %w", e.Metadata.ID, db.ErrNoNoteFound)
+	}
+	return x.CreateOrUpdateNote(e)
+}
+
+// assignID defaults the creation time and ID of a new note, making sure the ID
+// is not already taken by another note created in the same second
+func (x *Store) assignID(e *v1.Note) {
 	if e.Metadata.CreationTimestamp.IsZero() {
 		e.Metadata.CreationTimestamp = time.Now()
 	}
 
 	if e.Metadata.ID == 0 {
-		e.Metadata.ID = v1.ID(e.Metadata.CreationTimestamp.Unix())
+		id := v1.ID(e.Metadata.CreationTimestamp.Unix())
+		for x.hasNote(id) {
+			id++
+		}
+		e.Metadata.ID = id
 	}
+}
 
-	// TODO: union tags and labels with defaults
+// Slug returns name lowercased with runs of anything but letters and digits
+// replaced by dashes
+func Slug(name string) string {
+	return strings.Trim(slugInvalidChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
 
-	if err := x.Write(e); err != nil {
-		return nil, fmt.Errorf("unable to store note %d: %w", e.Metadata.ID, err)
+// NamedFile returns the file name a note named name is stored in
+func NamedFile(name string) (string, error) {
+	slug := Slug(name)
+	if slug == "" {
+		return "", fmt.Errorf("unable to name a note %q", name)
 	}
 
-	x.entries[e.Metadata.ID] = e
+	fn := slug + path.Ext(StorageGlob)
+	if _, err := time.Parse(StorageFilenameFormat, fn); err == nil {
+		return "", fmt.Errorf("%s is reserved for the daily entry", fn)
+	}
+	return fn, nil
+}
 
-	return e, nil
+// IsDaily returns whether the note is a daily entry, rather than a named note
+func (x *Store) IsDaily(id v1.ID) bool {
+	return x.layout.isDaily(x.fileName(id))
 }
 
 func (x *Store) LoadFromFile(fileName string) (*v1.Note, error) {
@@ -226,45 +395,97 @@ func (x *Store) LoadFromFile(fileName string) (*v1.Note, error) {
 	}
 	defer f.Close()
 
-	return x.LoadFromReader(f)
-}
-
-func (x *Store) LoadFromID(id v1.ID) (*v1.Note, error) {
-	return x.LoadFromFile(x.fullStoragePathID(id))
-}
+	finfo, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat %s: %w", fileName, err)
+	}
 
-func (x *Store) LoadFromReader(r io.Reader) (*v1.Note, error) {
-	var e v1.Note
+	bytes, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", fileName, err)
+	}
 
-	bytes, err := ioutil.ReadAll(r)
+	name := path.Base(fileName)
+	if rel, err := filepath.Rel(x.Directory, fileName); err == nil {
+		name = filepath.ToSlash(rel)
+	}
+	e, inferred, err := x.decode(name, finfo.ModTime(), bytes)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read: %w", err)
+		return nil, err
 	}
 
-	nChunks := 3
-	chunks := strings.SplitN(string(bytes), "---", nChunks)
+	x.Lock()
+	defer x.Unlock()
 
-	if len(chunks) != nChunks {
-		return nil, fmt.Errorf("unable to parse metadata section: %w", ErrUnableToFindMetadataSection)
+	if inferred {
+		// a file without an ID of its own keeps the ID it was first given, and
+		// must not shadow another note created in the same second
+		e.Metadata.ID = x.inferredID(name, e.Metadata.ID)
+	}
+	x.entries[e.Metadata.ID] = e
+	x.files[e.Metadata.ID] = name
+	x.mtimeMap[e.Metadata.ID] = finfo.ModTime()
+	x.indexNote(name, finfo, bytes, e, inferred)
+	x.sorted = nil
+	if _, ok := x.bases[e.Metadata.ID]; !ok && e.Tasks == nil {
+		x.bases[e.Metadata.ID] = base{modTime: finfo.ModTime(), content: e.Content}
 	}
 
-	err = yaml.Unmarshal([]byte(chunks[1]), &e.Metadata)
-	if err != nil {
-		return nil, fmt.Errorf("unable to deserialize metadata: %w", err)
+	return e, nil
+}
+
+// inferredID returns the ID of the note already loaded from name, or the first
+// ID from id that is not taken by another file
+func (x *Store) inferredID(name string, id v1.ID) v1.ID {
+	for existing, fn := range x.files {
+		if fn == name {
+			return existing
+		}
+	}
+	for {
+		if _, taken := x.files[id]; !taken {
+			return id
+		}
+		id++
 	}
+}
+
+func (x *Store) LoadFromID(id v1.ID) (*v1.Note, error) {
+	x.Lock()
+	fn := x.fullStoragePathID(id)
+	x.Unlock()
+	return x.LoadFromFile(fn)
+}
 
-	e.Content = chunks[2]
+// LoadFromReader parses a note, inferring any metadata it lacks as if it was
+// a file modified now
+func (x *Store) LoadFromReader(r io.Reader) (*v1.Note, error) {
+	bytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read: %w", err)
+	}
 
-	err = e.Validate()
+	e, _, err := decodeNote(x.layout, "", time.Now(), bytes)
 	if err != nil {
 		return nil, err
 	}
 
 	x.Lock()
 	defer x.Unlock()
-	x.entries[e.Metadata.ID] = &e
+	x.entries[e.Metadata.ID] = e
+	x.sorted = nil
 
-	return &e, nil
+	return e, nil
+}
+
+// encodeNote serializes a note as a yaml header followed by markdown content
+func encodeNote(e *v1.Note) ([]byte, error) {
+	metadata, err := yaml.Marshal(v2.Encode(e))
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal note metadata for %d: %w", e.Metadata.ID, err)
+	}
+
+	return []byte(fmt.Sprintf("---\n%s\n---\n", metadata) + strings.TrimRight(e.Content, "\n") + "\n"), nil
 }
 
 func (x *Store) StoragePath() string {
@@ -277,23 +498,57 @@ func (x *Store) StoragePathDoc(id types.DocIdentifier) string {
 	if err != nil {
 		return ""
 	}
+	x.Lock()
+	defer x.Unlock()
 	return x.fullStoragePathID(v1.ID(id64))
 }
 
-func id2File(id int64) string {
-	t := time.Unix(id, int64(0)).UTC()
-	return t.Format(StorageFilenameFormat)
+// fileName returns the path of the file the note is stored in, relative to
+// Directory, which follows the layout of daily entries unless it is a named note
+func (x *Store) fileName(id v1.ID) string {
+	if fn, ok := x.files[id]; ok {
+		return fn
+	}
+	return x.layout.dailyFile(int64(id))
 }
 
 func (x *Store) fullStoragePathID(id v1.ID) string {
-	fullPath := path.Join(x.Directory, id2File(int64(id)))
+	fullPath := path.Join(x.Directory, x.fileName(id))
 	return fullPath
 }
 
+// Write stores the note in its file, merging changes made to the file since
+// it was last read
 func (x *Store) Write(e *v1.Note) error {
+	x.Lock()
+	if err := x.write(e); err != nil {
+		x.Unlock()
+		return err
+	}
+	return x.unlockAndCommit()
+}
+
+// unlockAndCommit releases the lock, then commits the files written while it
+// was held, so the notes can be read while git runs
+func (x *Store) unlockAndCommit() error {
+	rels := x.uncommitted
+	x.uncommitted = nil
+	x.Unlock()
+	if err := x.gitCommit(rels...); err != nil {
+		x.Lock()
+		x.status = v1.StatusError
+		x.Unlock()
+		return err
+	}
+	return nil
+}
+
+// write stores the note in its file. The lock has already been claimed, and
+// the file is committed by unlockAndCommit.
+func (x *Store) write(e *v1.Note) error {
 	x.status = v1.StatusSynchronizing
 
-	targetpath := x.StoragePathDoc(e.Identifier())
+	targetpath := x.fullStoragePathID(e.Metadata.ID)
 	finfo, err := os.Stat(targetpath)
 	if err == nil && finfo.IsDir() {
 		err := os.RemoveAll(targetpath)
@@ -303,26 +558,57 @@ func (x *Store) Write(e *v1.Note) error {
 		}
 	}
 
-	f, err := os.OpenFile(targetpath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0644)
-	if err != nil {
+	if err := x.merge(e, targetpath); err != nil {
 		x.status = v1.StatusError
 		return err
 	}
-	defer f.Close()
 
-	metadata, err := yaml.Marshal(e.Metadata)
+	// keep what is about to be overwritten, in case it was never recorded.
+	// A note being sealed is kept sealed, along with the rest of its history,
+	// so its plain text is not left behind.
+	mode := x.sealOf(e)
+	sealing := mode != ""
+	if bytes, err := ioutil.ReadFile(targetpath); err == nil {
+		if finfo, err := os.Stat(targetpath); err == nil {
+			sealing = sealing && !isSealed(bytes)
+			if mode != "" {
+				bytes, err = x.sealRevision(e.Metadata.ID, x.fileName(e.Metadata.ID), bytes, finfo.ModTime(), mode)
+			}
+			if err == nil {
+				err = x.record(e.Metadata.ID, bytes, finfo.ModTime())
+			}
+			if err != nil {
+				x.status = v1.StatusError
+				return err
+			}
+		}
+	}
+	if sealing {
+		if err := x.sealHistory(e.Metadata.ID, x.fileName(e.Metadata.ID), mode); err != nil {
+			x.status = v1.StatusError
+			return err
+		}
+	}
+
+	b, err := x.encode(e)
 	if err != nil {
 		x.status = v1.StatusError
-		return fmt.Errorf("unable to marshal note metadata for %d: %w", e.Metadata.ID, err)
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(targetpath), 0700); err != nil {
+		x.status = v1.StatusError
+		return err
 	}
 
-	_, err = f.WriteString(fmt.Sprintf("---\n%s\n---\n", metadata))
+	f, err := os.OpenFile(targetpath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0644)
 	if err != nil {
 		x.status = v1.StatusError
-		return fmt.Errorf("unable to write note metadata for %d: %w", e.Metadata.ID, err)
+		return err
 	}
+	defer f.Close()
 
-	_, err = f.WriteString(e.Content + "\n")
+	_, err = f.Write(b)
 	if err != nil {
 		x.status = v1.StatusError
 		return fmt.Errorf("unable to write note %d: %w", e.Metadata.ID, err)
@@ -333,21 +619,39 @@ func (x *Store) Write(e *v1.Note) error {
 		return fmt.Errorf("unable to sync note %d: %w", e.Metadata.ID, err)
 	}
 
+	// the watcher need not reload what was just written
+	if finfo, err := f.Stat(); err == nil {
+		x.mtimeMap[e.Metadata.ID] = finfo.ModTime()
+		x.bases[e.Metadata.ID] = base{modTime: finfo.ModTime(), content: e.Content}
+		x.indexNote(x.fileName(e.Metadata.ID), finfo, b, e, false)
+		if err := x.record(e.Metadata.ID, b, finfo.ModTime()); err != nil {
+			x.status = v1.StatusError
+			return err
+		}
+	}
+
+	if x.git != nil {
+		x.uncommitted = append(x.uncommitted, x.fileName(e.Metadata.ID))
+	}
+
 	x.status = v1.StatusOK
 	return nil
 }
 
-// ListAll returns entries in newest to oldest order
+// ListAll returns entries in newest to oldest order. Notes listed from the
+// index have no content until it is read by Get or LoadAll.
 func (x *Store) ListAll() ([]*v1.Note, error) {
 	x.Lock()
 	defer x.Unlock()
 
-	sorted := []*v1.Note{}
-	for _, e := range x.entries {
-		sorted = append(sorted, e)
+	if x.sorted == nil {
+		x.sorted = make([]*v1.Note, 0, len(x.entries))
+		for _, e := range x.entries {
+			x.sorted = append(x.sorted, e)
+		}
+		sort.Sort(sort.Reverse(v1.ByCreationTimestampNoteList(x.sorted)))
 	}
-	sort.Sort(sort.Reverse(v1.ByCreationTimestampNoteList(sorted)))
-	return sorted, nil
+	return append([]*v1.Note{}, x.sorted...), nil
 }
 
 func (x *Store) idx(list []*v1.Note, id v1.ID) (int, error) {
@@ -376,7 +680,7 @@ func (x *Store) Next(id v1.ID) (*v1.Note, error) {
 	if nextIdx < 0 || nextIdx >= len(elements) || elements[nextIdx] == nil {
 		return nil, db.ErrNoNextNote
 	}
-	return elements[nextIdx], nil
+	return x.loaded(elements[nextIdx])
 }
 
 func (x *Store) Previous(id v1.ID) (*v1.Note, error) {
@@ -394,7 +698,7 @@ func (x *Store) Previous(id v1.ID) (*v1.Note, error) {
 	if prevIdx < 0 || prevIdx >= len(elements) || elements[prevIdx] == nil {
 		return nil, db.ErrNoPrevNote
 	}
-	return elements[prevIdx], nil
+	return x.loaded(elements[prevIdx])
 }
 
 func (x *Store) Count() int {
@@ -404,11 +708,21 @@ func (x *Store) Count() int {
 }
 
 func (x *Store) HasNote(id v1.ID) bool {
+	x.Lock()
+	defer x.Unlock()
+	return x.hasNote(id)
+}
+
+// hasNote returns whether the note is in the notebook. The lock has already
+// been claimed.
+func (x *Store) hasNote(id v1.ID) bool {
 	_, ok := x.entries[id]
 	return ok
 }
 
 func (x *Store) Status() v1.SyncStatus {
+	x.Lock()
+	defer x.Unlock()
 	return x.status
 }
 
@@ -432,14 +746,13 @@ func (x *Store) ReconcileID(id v1.ID) (*v1.Note, error) {
 		return e, nil
 	}
 
-	if e, ok := x.entries[id]; ok {
-		return e, nil
-	} else {
-		return nil, db.ErrNoNoteFound
-	}
+	return x.GetByID(id, false)
 }
 
 func (x *Store) ShouldReloadFromDisk(id v1.ID) bool {
+	x.Lock()
+	defer x.Unlock()
+
 	pth := x.fullStoragePathID(id)
 	finfo, err := os.Stat(pth)
 	if err != nil {
@@ -458,15 +771,21 @@ func (x *Store) DocType() types.DocType {
 	return types.NoteDoc
 }
 
-// List satisfies the DocBackend interface
-func (x *Store) List() ([]db.Doc, error) {
+// List returns the notes that are not archived, newest first. Notes are kept
+// in memory, so ctx is only checked before listing them.
+func (x *Store) List(ctx context.Context) ([]db.Doc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	l, err := x.ListAll()
 	if err != nil {
 		return nil, err
 	}
-	ret := make([]db.Doc, len(l))
-	for i, e := range l {
-		ret[i] = db.Doc(e)
+	ret := []db.Doc{}
+	for _, e := range l {
+		if !e.Metadata.Archived {
+			ret = append(ret, db.Doc(e))
+		}
 	}
 	return ret, nil
 }
@@ -1,14 +1,16 @@
 package fs
 
 import (
+	"context"
+	"errors"
 	"path"
+	"strconv"
 	"testing"
+	"time"
 
-	v1 "github.com/byxorna/jot/pkg/types/v1"
-)
-
-var (
-	fixtures = "../../../test/notes"
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/types"
+	"github.com/byxorna/jot/pkg/types/v1"
 )
 
 func TestShortStoragePath(t *testing.T) {
@@ -17,16 +19,106 @@ func TestShortStoragePath(t *testing.T) {
 		1625113859: "2021-07-01.md",
 	}
 
-	x, err := New(fixtures, false)
+	dir := copyFixtures(t)
+	x, err := New(dir, false, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer x.Close()
 
 	for input, shortExpectedOutput := range testcases {
-		actualOutput := x.StoragePath(v1.ID(input))
-		expectedOutput := path.Join(fixtures, shortExpectedOutput)
+		actualOutput := x.StoragePathDoc(types.DocIdentifier(strconv.FormatInt(input, 10)))
+		expectedOutput := path.Join(dir, shortExpectedOutput)
 		if expectedOutput != actualOutput {
 			t.Fatalf("Expected %d to yield a storage path of %v but got %v", input, expectedOutput, actualOutput)
 		}
 	}
 }
+
+func TestCreateNamedNote(t *testing.T) {
+	dir := t.TempDir()
+	x, err := New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+
+	now := time.Now()
+	daily, err := x.CreateOrUpdateNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", CreationTimestamp: now}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	named, err := x.CreateNamedNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", Title: "Q3 planning", CreationTimestamp: now}}, "Q3 planning!")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if named.Metadata.ID == daily.Metadata.ID {
+		t.Errorf("expected the named note to get its own id, but both are %d", named.Metadata.ID)
+	}
+	if p := x.StoragePathDoc(named.Identifier()); p != path.Join(dir, "q3-planning.md") {
+		t.Errorf("expected the named note to be stored in q3-planning.md but got %s", p)
+	}
+	if x.IsDaily(named.Metadata.ID) || !x.IsDaily(daily.Metadata.ID) {
+		t.Errorf("expected only %d to be a daily entry", daily.Metadata.ID)
+	}
+	if e, err := x.GetByDay(now); err != nil || e.Metadata.ID != daily.Metadata.ID {
+		t.Errorf("expected the daily entry for today but got %v, %v", e, err)
+	}
+	if _, err := x.CreateNamedNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a"}}, "q3 Planning"); !errors.Is(err, ErrNoteExists) {
+		t.Errorf("expected a second q3-planning.md to fail with %v but got %v", ErrNoteExists, err)
+	}
+	if _, err := NamedFile("2021-06-22"); err == nil {
+		t.Errorf("expected the name of a daily entry to be rejected")
+	}
+
+	// the file names survive reloading the directory
+	y, err := New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer y.Close()
+	if p := y.StoragePathDoc(named.Identifier()); p != path.Join(dir, "q3-planning.md") {
+		t.Errorf("expected the reloaded named note to be stored in q3-planning.md but got %s", p)
+	}
+}
+
+func TestDocBackendWrite(t *testing.T) {
+	dir := t.TempDir()
+	x, err := New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+
+	if !db.Can(x, db.CanCreate|db.CanUpdate|db.CanDelete) {
+		t.Fatalf("expected notes to be created, updated and deleted but got %v", x.Capabilities())
+	}
+
+	d, err := x.Create(context.Background(), &v1.Note{Metadata: v1.NoteMetadata{Author: "a", Title: "Reading list", CreationTimestamp: time.Now()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := x.StoragePathDoc(d.Identifier()); p != path.Join(dir, "reading-list.md") {
+		t.Errorf("expected the note to be named after its title but got %s", p)
+	}
+
+	e := *d.(*v1.Note)
+	e.Content = "- [ ] dune\n"
+	if _, err := x.Update(context.Background(), &e); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := x.Get(context.Background(), d.Identifier(), true); err != nil || got.UnformattedContent() != e.Content {
+		t.Errorf("expected the update to be stored but got %v, %v", got, err)
+	}
+	if _, err := x.Update(context.Background(), &v1.Note{Metadata: v1.NoteMetadata{ID: 1}}); !errors.Is(err, db.ErrNoNoteFound) {
+		t.Errorf("expected updating a missing note to fail with %v but got %v", db.ErrNoNoteFound, err)
+	}
+
+	if err := x.Delete(context.Background(), d.Identifier()); err != nil {
+		t.Fatal(err)
+	}
+	if x.HasNote(e.Metadata.ID) {
+		t.Errorf("expected %s to be deleted", d.Identifier())
+	}
+}
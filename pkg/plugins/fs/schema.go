@@ -0,0 +1,76 @@
+package fs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/byxorna/jot/pkg/types"
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+// Upgrade is a note stored in an older schema, rewritten in SchemaVersion by
+// Migrate. File is relative to the notebook directory.
+type Upgrade struct {
+	File string
+	From types.APIVersion
+	id   v1.ID
+}
+
+// Migrate rewrites every note whose front matter is in an older schema than
+// SchemaVersion. Notes are read in any schema, so this is only needed to stop
+// older versions of jot from reading a notebook, or to have every note keep
+// its UID in its file. Files without front matter are left as they are. Nothing
+// is written if a sealed note would need to be while the notebook is locked.
+func (x *Store) Migrate(dryRun bool) ([]Upgrade, error) {
+	notes, err := x.ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	upgrades := []Upgrade{}
+	for _, e := range notes {
+		x.Lock()
+		rel := x.fileName(e.Metadata.ID)
+		x.Unlock()
+
+		b, err := ioutil.ReadFile(filepath.Join(x.Directory, rel))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", rel, err)
+		}
+		format, metadata, _, err := splitFrontMatter(b)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %s: %w", rel, err)
+		}
+		if format == FrontMatterNone {
+			continue
+		}
+		raw, err := decodeFrontMatter(format, metadata)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %s: %w", rel, err)
+		}
+		if from := types.VersionOf(raw); from != SchemaVersion {
+			if x.IsSealed(e.Metadata.ID) && x.Locked() {
+				return nil, fmt.Errorf("unable to upgrade %s: %w", rel, ErrLocked)
+			}
+			upgrades = append(upgrades, Upgrade{File: rel, From: from, id: e.Metadata.ID})
+		}
+	}
+	sort.Slice(upgrades, func(i, j int) bool { return upgrades[i].File < upgrades[j].File })
+
+	if dryRun {
+		return upgrades, nil
+	}
+
+	for _, u := range upgrades {
+		e, err := x.GetByID(u.id, false)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", u.File, err)
+		}
+		if _, err := x.CreateOrUpdateNote(e); err != nil {
+			return nil, fmt.Errorf("unable to upgrade %s: %w", u.File, err)
+		}
+	}
+	return upgrades, nil
+}
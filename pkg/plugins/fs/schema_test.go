@@ -0,0 +1,85 @@
+package fs
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+	"github.com/byxorna/jot/pkg/types/v2"
+)
+
+func TestMigrate(t *testing.T) {
+	dir := t.TempDir()
+	v1Note := "---\nid: 1624308406\nauthor: a\ncreated: 2021-06-21T16:46:46-04:00\nlabels: {done: \"true\", points: \"3\", owner: me}\n---\nold\n"
+	writeFixture(t, dir, "2021-06-21.md", v1Note)
+	writeFixture(t, dir, "2021-06-22.md", "---\napiVersion: jot/v2\nkind: Note\nid: 1624395600\nuid: 0a\nauthor: a\ncreated: 2021-06-22 17:00:00 -0400\n---\ncurrent\n")
+	writeFixture(t, dir, "2021-06-23.md", "plain\n")
+
+	x, err := New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+
+	e, err := x.GetByID(1624308406, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Metadata.Labels["done"] != "true" || e.Metadata.Labels["points"] != "3" || e.Metadata.Labels["owner"] != "me" {
+		t.Errorf("unexpected labels %v", e.Metadata.Labels)
+	}
+	uid := v2.InferredUID(e.Metadata)
+	if e.Metadata.UID != uid {
+		t.Errorf("expected the inferred UID %s but got %s", uid, e.Metadata.UID)
+	}
+	if current, err := x.GetByID(1624395600, false); err != nil || current.Metadata.UID != "0a" || current.Metadata.CreationTimestamp.Hour() != 17 {
+		t.Errorf("unexpected v2 note %v, %v", current, err)
+	}
+
+	upgrades, err := x.Migrate(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(upgrades) != 1 || upgrades[0].File != "2021-06-21.md" || upgrades[0].From != v1.APIVersion {
+		t.Fatalf("unexpected upgrades %v", upgrades)
+	}
+	if b, _ := ioutil.ReadFile(path.Join(dir, "2021-06-21.md")); string(b) != v1Note {
+		t.Fatalf("expected a dry run not to write anything, but got\n%s", b)
+	}
+
+	if _, err := x.Migrate(false); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path.Join(dir, "2021-06-21.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"apiVersion: jot/v2\n", "kind: Note\n", "uid: " + uid + "\n", "done: true", "points: 3", "owner: me"} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("expected the upgraded note to contain %q but got\n%s", want, b)
+		}
+	}
+	if b, _ := ioutil.ReadFile(path.Join(dir, "2021-06-23.md")); string(b) != "plain\n" {
+		t.Errorf("expected a file without front matter to be left alone, but got\n%s", b)
+	}
+	if upgrades, err := x.Migrate(true); err != nil || len(upgrades) != 0 {
+		t.Errorf("expected nothing left to upgrade but got %v, %v", upgrades, err)
+	}
+
+	// reloaded, the note reads the same
+	y, err := New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer y.Close()
+	if e, err := y.GetByID(1624308406, false); err != nil || e.Metadata.UID != uid || e.Metadata.Labels["points"] != "3" || e.Content != "old\n" {
+		t.Errorf("unexpected upgraded note %v, %v", e, err)
+	}
+
+	writeFixture(t, dir, "2021-06-24.md", "---\napiVersion: jot/v9\nid: 1624500000\nauthor: a\ncreated: 2021-06-24T00:00:00Z\n---\nfuture\n")
+	if _, err := x.LoadFromFile(path.Join(dir, "2021-06-24.md")); err == nil {
+		t.Errorf("expected a note of an unknown schema to fail to load")
+	}
+}
@@ -0,0 +1,202 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/types"
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+// TrashDirectory is where deleted notes are moved to, within the notebook
+// directory. Like every dot directory, it is not loaded.
+const TrashDirectory = ".trash"
+
+var (
+	// trashedCopy matches the suffix telling apart notes deleted from the same
+	// file, like 2021-06-22~2.md
+	trashedCopy = regexp.MustCompile(`~[0-9]+(\.[^./]+)$`)
+)
+
+// TrashedNote is a deleted note that can still be restored
+type TrashedNote struct {
+	// File is the path of the note in the trash, relative to the trash
+	File string
+	// Restore is where the note is restored to, relative to the notebook
+	Restore string
+	Note    *v1.Note
+
+	inferred bool
+}
+
+func (x *Store) trashDirectory() string {
+	return filepath.Join(x.Directory, TrashDirectory)
+}
+
+// Delete moves the note to the trash
+func (x *Store) Delete(ctx context.Context, id types.DocIdentifier) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	id64, err := parseID(id.String())
+	if err != nil {
+		return err
+	}
+	return x.DeleteNote(v1.ID(id64))
+}
+
+// DeleteNote moves the note to the trash, keeping the path it had within the
+// notebook so it can be restored there
+func (x *Store) DeleteNote(id v1.ID) error {
+	x.Lock()
+	ok := x.hasNote(id)
+	rel := x.fileName(id)
+	x.Unlock()
+	if !ok {
+		return fmt.Errorf("%d: %w", id, db.ErrNoNoteFound)
+	}
+
+	target := filepath.Join(x.trashDirectory(), rel)
+	ext := path.Ext(rel)
+	for n := 2; ; n++ {
+		if _, err := os.Stat(target); os.IsNotExist(err) {
+			break
+		}
+		target = filepath.Join(x.trashDirectory(), fmt.Sprintf("%s~%d%s", strings.TrimSuffix(rel, ext), n, ext))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Join(x.Directory, rel), target); err != nil {
+		return fmt.Errorf("unable to move %s to the trash: %w", rel, err)
+	}
+	x.layout.removeEmptyDirs(path.Dir(rel))
+
+	x.forget(id)
+	x.changed(db.DocRemoved, types.DocIdentifier(fmt.Sprintf("%d", id)))
+	return x.gitCommit(rel)
+}
+
+// Trash returns the notes in the trash, by file
+func (x *Store) Trash() ([]*TrashedNote, error) {
+	trash := &layout{directory: x.trashDirectory(), daily: x.layout.daily, exclude: map[string]bool{}}
+	if _, err := os.Stat(trash.directory); os.IsNotExist(err) {
+		return []*TrashedNote{}, nil
+	}
+
+	notes := []*TrashedNote{}
+	err := trash.walk(func(rel string, info os.FileInfo) error {
+		if matched, _ := path.Match(StorageGlob, info.Name()); info.IsDir() || !matched {
+			return nil
+		}
+		bytes, err := ioutil.ReadFile(filepath.Join(trash.directory, rel))
+		if err != nil {
+			return err
+		}
+		restore := trashedCopy.ReplaceAllString(rel, "$1")
+		e, inferred, err := x.decode(restore, info.ModTime(), bytes)
+		if err != nil {
+			return &LoadError{File: rel, Err: err}
+		}
+		notes = append(notes, &TrashedNote{File: rel, Restore: restore, Note: e, inferred: inferred})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the trash: %w", err)
+	}
+
+	sort.Slice(notes, func(i, j int) bool { return notes[i].File < notes[j].File })
+	return notes, nil
+}
+
+// Restore moves the note at file in the trash back to where it was deleted
+// from. It fails with ErrNoteExists if another note took its place since.
+func (x *Store) Restore(file string) (*v1.Note, error) {
+	trashed, err := x.Trash()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range trashed {
+		if t.File != filepath.ToSlash(filepath.Clean(file)) {
+			continue
+		}
+
+		target := filepath.Join(x.Directory, t.Restore)
+		if _, err := os.Stat(target); err == nil {
+			return nil, fmt.Errorf("%s: %w", t.Restore, ErrNoteExists)
+		}
+		if !t.inferred && x.HasNote(t.Note.Metadata.ID) {
+			return nil, fmt.Errorf("%d: %w", t.Note.Metadata.ID, ErrNoteExists)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(filepath.Join(x.trashDirectory(), t.File), target); err != nil {
+			return nil, fmt.Errorf("unable to restore %s: %w", t.File, err)
+		}
+		(&layout{directory: x.trashDirectory()}).removeEmptyDirs(path.Dir(t.File))
+
+		e, err := x.LoadFromFile(target)
+		if err != nil {
+			return nil, err
+		}
+		x.changed(db.DocAdded, e.Identifier())
+		return e, x.gitCommit(t.Restore)
+	}
+	return nil, fmt.Errorf("%s is not in the trash: %w", file, db.ErrNoNoteFound)
+}
+
+// EmptyTrash permanently deletes the notes in the trash, returning how many
+// were deleted
+func (x *Store) EmptyTrash() (int, error) {
+	trashed, err := x.Trash()
+	if err != nil {
+		return 0, err
+	}
+	if err := os.RemoveAll(x.trashDirectory()); err != nil {
+		return 0, fmt.Errorf("unable to empty the trash: %w", err)
+	}
+	return len(trashed), nil
+}
+
+// Archive hides the note from List, or shows it again. Archived notes are
+// still returned by ListArchived, so they can be searched.
+func (x *Store) Archive(id types.DocIdentifier, archived bool) (*v1.Note, error) {
+	id64, err := parseID(id.String())
+	if err != nil {
+		return nil, err
+	}
+	e, err := x.GetByID(v1.ID(id64), false)
+	if err != nil {
+		return nil, err
+	}
+
+	n := *e
+	n.Metadata.Archived = archived
+	return x.CreateOrUpdateNote(&n)
+}
+
+// ListArchived returns the archived notes, which List leaves out
+func (x *Store) ListArchived() ([]db.Doc, error) {
+	l, err := x.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	docs := []db.Doc{}
+	for _, e := range l {
+		if e.Metadata.Archived {
+			docs = append(docs, e)
+		}
+	}
+	return docs, nil
+}
@@ -0,0 +1,94 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+func TestTrash(t *testing.T) {
+	dir := t.TempDir()
+	x, err := New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+
+	day := time.Date(2021, 6, 22, 12, 0, 0, 0, time.UTC)
+	first, err := x.CreateOrUpdateNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", Title: "first", CreationTimestamp: day}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Delete(context.Background(), first.Identifier()); err != nil {
+		t.Fatal(err)
+	}
+	if x.HasNote(first.Metadata.ID) {
+		t.Errorf("expected %d to be gone after deleting it", first.Metadata.ID)
+	}
+
+	// another note deleted from the same file does not replace the first
+	second, err := x.CreateOrUpdateNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", Title: "second", CreationTimestamp: day}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := x.DeleteNote(second.Metadata.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	trashed, err := x.Trash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trashed) != 2 || trashed[0].File != "2021-06-22.md" || trashed[1].File != "2021-06-22~2.md" || trashed[1].Restore != "2021-06-22.md" {
+		t.Fatalf("expected both notes in the trash but got %v", trashed)
+	}
+
+	if e, err := x.Restore("2021-06-22~2.md"); err != nil || e.Metadata.Title != "second" {
+		t.Fatalf("expected the second note to be restored but got %v, %v", e, err)
+	}
+	if _, err := os.Stat(path.Join(dir, "2021-06-22.md")); err != nil {
+		t.Errorf("expected the note to be restored to 2021-06-22.md: %v", err)
+	}
+	if _, err := x.Restore("2021-06-22.md"); !errors.Is(err, ErrNoteExists) {
+		t.Errorf("expected restoring over the second note to fail with %v but got %v", ErrNoteExists, err)
+	}
+
+	if n, err := x.EmptyTrash(); err != nil || n != 1 {
+		t.Errorf("expected 1 note to be deleted permanently but got %d, %v", n, err)
+	}
+	if trashed, err := x.Trash(); err != nil || len(trashed) != 0 {
+		t.Errorf("expected the trash to be empty but got %v, %v", trashed, err)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	x, err := New(t.TempDir(), false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+
+	e, err := x.CreateOrUpdateNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", CreationTimestamp: time.Now()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Archive(e.Identifier(), true); err != nil {
+		t.Fatal(err)
+	}
+	if docs, err := x.List(context.Background()); err != nil || len(docs) != 0 {
+		t.Errorf("expected archived notes to be left out of the list but got %v, %v", docs, err)
+	}
+	if docs, err := x.ListArchived(); err != nil || len(docs) != 1 {
+		t.Errorf("expected the archived note but got %v, %v", docs, err)
+	}
+
+	// archiving is stored in the note
+	if n, err := x.LoadFromID(e.Metadata.ID); err != nil || !n.Metadata.Archived {
+		t.Errorf("expected the note on disk to be archived but got %v, %v", n, err)
+	}
+}
@@ -0,0 +1,404 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/types"
+	"github.com/byxorna/jot/pkg/types/v1"
+	"github.com/fsnotify/fsnotify"
+)
+
+var (
+	// WatchDebounce is how long the watcher waits for a burst of events, like
+	// a sync client writing many files, to settle before reloading
+	WatchDebounce = 100 * time.Millisecond
+)
+
+// diskChangeKind is what happened to a note on disk
+type diskChangeKind string
+
+const (
+	diskCreated diskChangeKind = "created"
+	diskUpdated diskChangeKind = "updated"
+	diskRemoved diskChangeKind = "removed"
+	// diskConflict is a conflict copy saved by a write that could not be
+	// merged, see Conflicts
+	diskConflict diskChangeKind = "conflict"
+	diskError    diskChangeKind = "error"
+)
+
+// diskChange is a note changed on disk by something other than the Store, like
+// a sync client or an editor. Watchers are told of it as a db.Change.
+type diskChange struct {
+	Kind diskChangeKind
+	ID   v1.ID
+	// File is the path of the note, relative to Directory
+	File string
+	Err  error
+}
+
+func (c diskChange) String() string {
+	if c.Kind == diskError {
+		return fmt.Sprintf("%s: %v", c.File, c.Err)
+	}
+	return fmt.Sprintf("%s %s", c.File, c.Kind)
+}
+
+// Watch returns a channel of the notes added, updated and removed, whether by
+// the Store or on disk, and of the conflict copies saved. Failures to sync the
+// notebook are told as a change of its status.
+func (x *Store) Watch() <-chan db.Change {
+	return x.changes.Watch()
+}
+
+func (x *Store) publish(changes ...diskChange) {
+	x.Lock()
+	status := x.status
+	x.Unlock()
+
+	for _, c := range changes {
+		x.changes.Publish(c.docChange(status))
+	}
+}
+
+// changed tells watchers about a note changed by the Store, which is not
+// locked
+func (x *Store) changed(kind db.ChangeKind, id types.DocIdentifier) {
+	x.Lock()
+	status := x.status
+	x.Unlock()
+	x.changes.Publish(db.Change{Kind: kind, ID: id, Status: status})
+}
+
+// docChange is the change to the notebook as told to watchers of any backend
+func (c diskChange) docChange(status v1.SyncStatus) db.Change {
+	id := types.DocIdentifier(fmt.Sprintf("%d", c.ID))
+	switch c.Kind {
+	case diskCreated:
+		return db.Change{Kind: db.DocAdded, ID: id, Status: status}
+	case diskUpdated:
+		return db.Change{Kind: db.DocUpdated, ID: id, Status: status}
+	case diskRemoved:
+		return db.Change{Kind: db.DocRemoved, ID: id, Status: status}
+	case diskConflict:
+		if c.ID == 0 {
+			// the note it is a copy of is not known
+			id = ""
+		}
+		return db.Change{Kind: db.DocConflicted, ID: id, Status: status}
+	}
+	return db.Change{Kind: db.StatusChanged, Status: status, Err: fmt.Errorf("%s: %w", c.File, c.Err)}
+}
+
+func (x *Store) startWatcher() error {
+	if err := x.Close(); err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	x.watcher = watcher
+	if err := x.watchDirectory(x.Directory); err != nil {
+		_ = watcher.Close()
+		x.watcher = nil
+		return err
+	}
+
+	x.watching = make(chan struct{})
+
+	wait := WatchDebounce
+	go func() {
+		defer x.stopPublishing()
+		pending := map[string]bool{}
+		debounce := time.NewTimer(wait)
+		debounce.Stop()
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				pending[event.Name] = true
+				debounce.Reset(wait)
+
+			case <-debounce.C:
+				fns := make([]string, 0, len(pending))
+				for fn := range pending {
+					fns = append(fns, fn)
+				}
+				pending = map[string]bool{}
+				x.publish(x.sync(fns)...)
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				x.publish(diskChange{Kind: diskError, File: ".", Err: err})
+			}
+		}
+	}()
+	return nil
+}
+
+// Close stops watching the notebook for changes, closes the channels of all
+// watchers, saves the index and waits up to PushWait for commits to be pushed
+func (x *Store) Close() error {
+	var err error
+	if x.watcher != nil {
+		err = x.watcher.Close()
+		<-x.watching
+	}
+	// the watcher commits changes made outside of jot, so pushes are waited
+	// for once it has stopped
+	pushed := x.git == nil || x.git.waitForPushes(PushWait)
+	if err != nil {
+		return err
+	}
+	if err := x.saveIndex(); err != nil {
+		return err
+	}
+	if !pushed {
+		return fmt.Errorf("gave up waiting for the commits of %s to be pushed, they are pushed along with the next change", x.Directory)
+	}
+	return nil
+}
+
+func (x *Store) stopPublishing() {
+	x.Lock()
+	defer x.Unlock()
+	x.changes.Close()
+	close(x.watching)
+}
+
+// sync brings the notes up to date with the paths that changed on disk
+func (x *Store) sync(fns []string) []diskChange {
+	type entry struct {
+		rel   string
+		finfo os.FileInfo
+	}
+	var gone, present []entry
+	for _, fn := range fns {
+		rel, err := filepath.Rel(x.Directory, fn)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		finfo, err := os.Stat(fn)
+		if err != nil {
+			gone = append(gone, entry{rel: filepath.ToSlash(rel)})
+		} else {
+			present = append(present, entry{rel: filepath.ToSlash(rel), finfo: finfo})
+		}
+	}
+	sort.Slice(gone, func(i, j int) bool { return gone[i].rel < gone[j].rel })
+	sort.Slice(present, func(i, j int) bool { return present[i].rel < present[j].rel })
+
+	changes := []diskChange{}
+
+	// removals first, so a note renamed within the burst ends up at its new path
+	for _, g := range gone {
+		// everything tracked at or below the path is gone
+		for id, fn := range x.idsUnder(g.rel) {
+			changes = append(changes, diskChange{Kind: diskRemoved, ID: id, File: fn})
+			x.forget(id)
+		}
+	}
+
+	for _, p := range present {
+		if !p.finfo.IsDir() {
+			changes = append(changes, x.syncFile(p.rel, p.finfo)...)
+			continue
+		}
+		if x.isIgnored(p.rel) {
+			continue
+		}
+		dir := filepath.Join(x.Directory, p.rel)
+		if err := x.watchDirectory(dir); err != nil {
+			changes = append(changes, diskChange{Kind: diskError, File: p.rel, Err: err})
+			continue
+		}
+		// files moved in along with the directory have no events of their own
+		sub := &layout{directory: dir, exclude: map[string]bool{}}
+		_ = sub.walk(func(subRel string, info os.FileInfo) error {
+			if !info.IsDir() {
+				changes = append(changes, x.syncFile(path.Join(p.rel, subRel), info)...)
+			}
+			return nil
+		})
+	}
+
+	committed := []string{}
+	for _, c := range changes {
+		switch c.Kind {
+		case diskCreated, diskUpdated, diskRemoved:
+			committed = append(committed, c.File)
+		}
+	}
+	if err := x.gitCommit(committed...); err != nil {
+		changes = append(changes, diskChange{Kind: diskError, File: ".git", Err: err})
+	}
+
+	// keep the index up to date with the changes and with writes, for the
+	// next startup
+	if err := x.saveIndex(); err != nil {
+		changes = append(changes, diskChange{Kind: diskError, File: IndexFile, Err: err})
+	}
+
+	return coalesce(changes)
+}
+
+// coalesce turns a note that was removed and created again, like a renamed
+// file, into a single update
+func coalesce(changes []diskChange) []diskChange {
+	removed := map[v1.ID]bool{}
+	for _, c := range changes {
+		if c.Kind == diskRemoved {
+			removed[c.ID] = true
+		}
+	}
+
+	coalesced := []diskChange{}
+	recreated := map[v1.ID]bool{}
+	for _, c := range changes {
+		if c.Kind == diskCreated && removed[c.ID] {
+			c.Kind = diskUpdated
+			recreated[c.ID] = true
+		}
+		coalesced = append(coalesced, c)
+	}
+
+	changes = coalesced[:0]
+	for _, c := range coalesced {
+		if c.Kind == diskRemoved && recreated[c.ID] {
+			continue
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// syncFile loads the note at rel if it is new or was modified since it was
+// last loaded or written
+func (x *Store) syncFile(rel string, finfo os.FileInfo) []diskChange {
+	if file, ok := conflictOf(rel); ok && !x.isIgnored(rel) {
+		c := diskChange{Kind: diskConflict, File: file}
+		for id := range x.idsUnder(file) {
+			c.ID = id
+		}
+		return []diskChange{c}
+	}
+	if matched, _ := path.Match(StorageGlob, path.Base(rel)); !matched || x.isIgnored(rel) {
+		return nil
+	}
+
+	x.Lock()
+	var id v1.ID
+	var tracked bool
+	for existing, fn := range x.files {
+		if fn == rel {
+			id, tracked = existing, true
+			break
+		}
+	}
+	unchanged := tracked && !x.mtimeMap[id].Before(finfo.ModTime())
+	x.Unlock()
+	if unchanged {
+		return nil
+	}
+
+	e, err := x.LoadFromFile(path.Join(x.Directory, rel))
+	if err != nil {
+		return []diskChange{{Kind: diskError, File: rel, Err: err}}
+	}
+
+	changes := []diskChange{}
+	if err := x.Snapshot(e.Metadata.ID); err != nil {
+		changes = append(changes, diskChange{Kind: diskError, ID: e.Metadata.ID, File: rel, Err: err})
+	}
+	if tracked && id != e.Metadata.ID {
+		// the file now holds a different note
+		x.forget(id)
+		changes = append(changes, diskChange{Kind: diskRemoved, ID: id, File: rel})
+		tracked = false
+	}
+
+	if tracked {
+		return append(changes, diskChange{Kind: diskUpdated, ID: e.Metadata.ID, File: rel})
+	}
+	return append(changes, diskChange{Kind: diskCreated, ID: e.Metadata.ID, File: rel})
+}
+
+// idsUnder returns the notes stored at rel, or in a directory at rel, and the
+// files they are stored in
+func (x *Store) idsUnder(rel string) map[v1.ID]string {
+	x.Lock()
+	defer x.Unlock()
+
+	ids := map[v1.ID]string{}
+	for id, fn := range x.files {
+		if fn == rel || strings.HasPrefix(fn, rel+"/") {
+			ids[id] = fn
+		}
+	}
+	return ids
+}
+
+// forget drops a note that no longer exists on disk
+func (x *Store) forget(id v1.ID) {
+	x.Lock()
+	defer x.Unlock()
+	if rel, ok := x.files[id]; ok {
+		delete(x.index, rel)
+		x.indexDirty = true
+	}
+	x.sorted = nil
+	delete(x.entries, id)
+	delete(x.files, id)
+	delete(x.mtimeMap, id)
+	delete(x.bases, id)
+}
+
+// isIgnored returns whether rel is a dotfile or belongs to another notebook
+func (x *Store) isIgnored(rel string) bool {
+	for p := rel; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+		if strings.HasPrefix(path.Base(p), ".") || x.layout.exclude[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// watchDirectory watches dir and all of its subdirectories, other than those
+// of other notebooks
+func (x *Store) watchDirectory(dir string) error {
+	if err := x.watcher.Add(dir); err != nil {
+		return fmt.Errorf("unable to watch %s: %w", dir, err)
+	}
+
+	sub := &layout{directory: dir, exclude: map[string]bool{}}
+	for ex := range x.layout.exclude {
+		if rel, err := filepath.Rel(dir, filepath.Join(x.Directory, ex)); err == nil {
+			sub.exclude[rel] = true
+		}
+	}
+	return sub.walk(func(rel string, info os.FileInfo) error {
+		if !info.IsDir() {
+			return nil
+		}
+		fn := filepath.Join(dir, rel)
+		if err := x.watcher.Add(fn); err != nil {
+			return fmt.Errorf("unable to watch %s: %w", fn, err)
+		}
+		return nil
+	})
+}
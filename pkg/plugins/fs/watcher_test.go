@@ -0,0 +1,186 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+// nextChange waits for the store to publish a change
+func nextChange(t *testing.T, changes <-chan db.Change) db.Change {
+	t.Helper()
+	select {
+	case c := <-changes:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a change")
+		return db.Change{}
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	note := "---\nid: 1624363200\nauthor: a\ncreated: 2021-06-22T12:00:00Z\n---\n"
+	WatchDebounce = 10 * time.Millisecond
+	dir := t.TempDir()
+	x, err := New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	changes := x.Watch()
+
+	// notes written by something else show up without a restart
+	fn := filepath.Join(dir, "2021-06-22.md")
+	if err := os.WriteFile(fn, []byte(note+"# Tuesday\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	created := nextChange(t, changes)
+	if created.Kind != db.DocAdded || created.ID != "1624363200" || x.StoragePathDoc(created.ID) != fn {
+		t.Fatalf("expected 2021-06-22.md to be created but got %v", created)
+	}
+	if n := x.Count(); n != 1 {
+		t.Fatalf("expected 1 note but got %d", n)
+	}
+
+	// the os may only report the new modification time a moment later
+	time.Sleep(20 * time.Millisecond)
+	if err := os.WriteFile(fn, []byte(note+"# Tuesday\n\n- [ ] call mom\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if c := nextChange(t, changes); c.Kind != db.DocUpdated || c.ID != "1624363200" {
+		t.Fatalf("expected 2021-06-22.md to be updated but got %v", c)
+	}
+
+	// the note keeps its id when moved into a subdirectory
+	if err := os.MkdirAll(filepath.Join(dir, "archive"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	moved := filepath.Join(dir, "archive", "tuesday.md")
+	if err := os.Rename(fn, moved); err != nil {
+		t.Fatal(err)
+	}
+	if c := nextChange(t, changes); c.Kind != db.DocUpdated || c.ID != "1624363200" || x.StoragePathDoc(c.ID) != moved {
+		t.Fatalf("expected the note to move to archive/tuesday.md but got %v", c)
+	}
+
+	if err := os.RemoveAll(filepath.Join(dir, "archive")); err != nil {
+		t.Fatal(err)
+	}
+	if c := nextChange(t, changes); c.Kind != db.DocRemoved {
+		t.Fatalf("expected the note to be removed but got %v", c)
+	}
+	if n := x.Count(); n != 0 {
+		t.Fatalf("expected no notes but got %d", n)
+	}
+
+	// writes made by the store are told once, not again by the watcher
+	now := time.Now()
+	e, err := x.CreateOrUpdateNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", CreationTimestamp: now}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := nextChange(t, changes); c.Kind != db.DocAdded || c.ID != e.Identifier() {
+		t.Fatalf("expected %s to be added but got %+v", e.Identifier(), c)
+	}
+	select {
+	case c := <-changes:
+		t.Fatalf("expected no other change for a note written by the store but got %+v", c)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// conflict copies saved aside, like by a sync client, are told for the
+	// note they are a copy of
+	rel := x.StoragePathDoc(e.Identifier())
+	copied := rel + ConflictSuffix + now.Format(conflictTimeFormat)
+	if err := os.WriteFile(copied, []byte("---\nauthor: b\n---\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if c := nextChange(t, changes); c.Kind != db.DocConflicted || c.ID != e.Identifier() {
+		t.Fatalf("expected a conflict copy of %s but got %+v", e.Identifier(), c)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	WatchDebounce = 10 * time.Millisecond
+	dir := t.TempDir()
+	x, err := New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := x.Watch()
+
+	next := func() db.Change {
+		t.Helper()
+		select {
+		case c := <-changes:
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a change")
+			return db.Change{}
+		}
+	}
+
+	// writes of the store are told, like changes on disk
+	e, err := x.CreateOrUpdateNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", CreationTimestamp: time.Date(2021, 6, 22, 12, 0, 0, 0, time.UTC)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := next(); c.Kind != db.DocAdded || c.ID != e.Identifier() {
+		t.Fatalf("expected %s to be added but got %+v", e.Identifier(), c)
+	}
+	if _, err := x.Archive(e.Identifier(), true); err != nil {
+		t.Fatal(err)
+	}
+	if c := next(); c.Kind != db.DocUpdated || c.ID != e.Identifier() {
+		t.Fatalf("expected %s to be updated but got %+v", e.Identifier(), c)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "2021-06-22.md")); err != nil {
+		t.Fatal(err)
+	}
+	if c := next(); c.Kind != db.DocRemoved || c.ID != e.Identifier() {
+		t.Fatalf("expected %s to be removed but got %+v", e.Identifier(), c)
+	}
+
+	x.Close()
+	if _, ok := <-changes; ok {
+		t.Errorf("expected the changes to be closed with the store")
+	}
+}
+
+func TestWriteWhileSyncing(t *testing.T) {
+	dir := t.TempDir()
+	x, err := New(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+
+	e, err := x.CreateOrUpdateNote(&v1.Note{Metadata: v1.NoteMetadata{Author: "a", CreationTimestamp: time.Date(2021, 6, 22, 12, 0, 0, 0, time.UTC)}, Content: "- [ ] one\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := filepath.Join(dir, "2021-06-22.md")
+
+	// written from outside of the store, like by jot done, while the watcher
+	// syncs the same file
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			x.sync([]string{fn, filepath.Join(dir, "gone.md")})
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		n := *e
+		if err := x.Write(&n); err != nil {
+			t.Error(err)
+		}
+	}
+	<-done
+}
@@ -18,7 +18,8 @@ import (
 )
 
 var (
-	// ReconciliationDuration is how often to refresh events from the API
+	// ReconciliationDuration is how long fetched notes are listed before List
+	// fetches them again, when they are not refreshed in the background
 	ReconciliationDuration       = time.Minute * 10
 	pluginName                   = config.PluginTypeKeep
 	pageSize               int64 = 15
@@ -33,6 +34,9 @@ type Client struct {
 	collection  map[types.DocIdentifier]*Note
 	status      v1.SyncStatus
 	lastFetched time.Time
+
+	// changes are told to watchers when the notes are refreshed
+	changes db.Watchers
 }
 
 func New(ctx context.Context, client *http.Client) (*Client, error) { //, client *http.Client) (*Client, error) {
@@ -41,10 +45,38 @@ func New(ctx context.Context, client *http.Client) (*Client, error) { //, client
 		return nil, fmt.Errorf("unable to retrieve %s client: %w", pluginName, err)
 	}
 
-	c := Client{Service: srv}
+	c := Client{Service: srv, status: v1.StatusUninitialized}
 	return &c, nil
 }
 
+// Watch returns a channel of the notes added, updated and removed whenever
+// the notes are refreshed
+func (c *Client) Watch() <-chan db.Change {
+	return c.changes.Watch()
+}
+
+// Refresh fetches the notes again, returning how many there are
+func (c *Client) Refresh(ctx context.Context) (int, error) {
+	docs, err := c.fetchAndPopulateCollection(ctx, true)
+	return len(docs), err
+}
+
+// Close closes the channels of all watchers
+func (c *Client) Close() error {
+	c.changes.Close()
+	return nil
+}
+
+// setStatus tells watchers about a new status, or a failure to refresh. The
+// lock has already been claimed.
+func (c *Client) setStatus(status v1.SyncStatus, err error) {
+	if c.status == status && err == nil {
+		return
+	}
+	c.status = status
+	c.changes.Publish(db.Change{Kind: db.StatusChanged, Status: status, Err: err})
+}
+
 func (c *Client) DocType() types.DocType {
 	return types.KeepItemDoc
 }
@@ -62,19 +94,18 @@ func (c *Client) needsReconciliation() bool {
 	return c.lastFetched.Before(time.Now().Add(-ReconciliationDuration)) || c.collection == nil
 }
 
-func (c *Client) Get(id types.DocIdentifier, hardread bool) (db.Doc, error) {
-	c.Lock()
-	defer c.Unlock()
-
+func (c *Client) Get(ctx context.Context, id types.DocIdentifier, hardread bool) (db.Doc, error) {
 	if hardread {
 		// refresh and inject into eventList
-		reconciledEvent, err := c.Reconcile(id)
+		reconciledEvent, err := c.Reconcile(ctx, id)
 		if err != nil {
 			return nil, err
 		}
 		return reconciledEvent, nil
 	}
 
+	c.RLock()
+	defer c.RUnlock()
 	d, ok := c.collection[id]
 	if !ok {
 		return nil, fmt.Errorf("%s not found", id.String())
@@ -82,12 +113,12 @@ func (c *Client) Get(id types.DocIdentifier, hardread bool) (db.Doc, error) {
 	return d, nil
 }
 
-func (c *Client) Reconcile(id types.DocIdentifier) (db.Doc, error) {
-	_, err := c.fetchAndPopulateCollection(true)
+func (c *Client) Reconcile(ctx context.Context, id types.DocIdentifier) (db.Doc, error) {
+	_, err := c.fetchAndPopulateCollection(ctx, true)
 	if err != nil {
 		return nil, err
 	}
-	return c.Get(id, false)
+	return c.Get(ctx, id, false)
 }
 
 func (c *Client) reconcileNote(id types.DocIdentifier) (db.Doc, error) {
@@ -110,8 +141,8 @@ func (c *Client) hasDoc(e db.Doc) bool {
 	return ok
 }
 
-func (c *Client) fetchAllNotes() ([]*Note, error) {
-	kns, err := c._fetchAllNotes("")
+func (c *Client) fetchAllNotes(ctx context.Context) ([]*Note, error) {
+	kns, err := c._fetchAllNotes(ctx, "")
 	if err != nil {
 		return nil, err
 	}
@@ -122,18 +153,18 @@ func (c *Client) fetchAllNotes() ([]*Note, error) {
 	return ns, nil
 }
 
-func (c *Client) _fetchAllNotes(pageToken string) ([]*keep.Note, error) {
+func (c *Client) _fetchAllNotes(ctx context.Context, pageToken string) ([]*keep.Note, error) {
 	aggr := []*keep.Note{}
 
 	// Lists notes using a pagination token.
-	res, err := c.Service.Notes.List().PageSize(pageSize).Do()
+	res, err := c.Service.Notes.List().PageSize(pageSize).PageToken(pageToken).Context(ctx).Do()
 	if err != nil {
 		return nil, err
 	}
 	aggr = append(aggr, res.Notes...)
 
 	if res.NextPageToken != "" {
-		more, err := c._fetchAllNotes(res.NextPageToken)
+		more, err := c._fetchAllNotes(ctx, res.NextPageToken)
 		if err != nil {
 			return nil, err
 		}
@@ -142,35 +173,94 @@ func (c *Client) _fetchAllNotes(pageToken string) ([]*keep.Note, error) {
 	return aggr, nil
 }
 
-func (c *Client) List() ([]db.Doc, error) {
-	return c.fetchAndPopulateCollection(false)
+func (c *Client) List(ctx context.Context) ([]db.Doc, error) {
+	return c.fetchAndPopulateCollection(ctx, false)
 }
 
-func (c *Client) fetchAndPopulateCollection(hardread bool) ([]db.Doc, error) {
+func (c *Client) fetchAndPopulateCollection(ctx context.Context, hardread bool) ([]db.Doc, error) {
 	c.Lock()
-	defer c.Unlock()
+	if !c.needsReconciliation() && !hardread {
+		defer c.Unlock()
+		return c.docs(), nil
+	}
+	c.setStatus(v1.StatusSynchronizing, nil)
+	c.Unlock()
 
-	if c.needsReconciliation() || hardread {
-		notes, err := c.fetchAllNotes()
-		if err != nil {
-			return nil, fmt.Errorf("unable to fetch all keep notes: %w", err)
-		}
-		c.lastFetched = time.Now()
-		c.status = v1.StatusOK
+	// the lock is not held while waiting on the API, so the notes fetched
+	// before can still be read
+	notes, err := c.fetchAllNotes(ctx)
 
-		// blow away the prior cache
-		newCollection := map[types.DocIdentifier]*Note{}
-		for _, n := range notes {
-			newCollection[n.Identifier()] = n
-		}
-		c.collection = newCollection
+	c.Lock()
+	defer c.Unlock()
+	if err != nil {
+		err = fmt.Errorf("unable to fetch all keep notes: %w", err)
+		c.setStatus(v1.StatusError, err)
+		return nil, err
+	}
+	c.lastFetched = time.Now()
+
+	// blow away the prior cache
+	old := c.docs()
+	newCollection := map[types.DocIdentifier]*Note{}
+	for _, n := range notes {
+		newCollection[n.Identifier()] = n
 	}
+	c.collection = newCollection
+	c.changes.Publish(db.Diff(old, c.docs())...)
+	c.setStatus(v1.StatusOK, nil)
+
+	return c.docs(), nil
+}
 
+func (c *Client) docs() []db.Doc {
 	docs := []db.Doc{}
 	for _, doc := range c.collection {
 		docs = append(docs, db.Doc(doc))
 	}
-	return docs, nil
+	return docs
+}
+
+// Capabilities tells that notes can be created and deleted. The Keep API has no
+// way to change a note once it is created.
+func (c *Client) Capabilities() db.Capability {
+	return db.CanCreate | db.CanDelete
+}
+
+// Create creates a text note with the title and body of the document
+func (c *Client) Create(ctx context.Context, d db.Doc) (db.Doc, error) {
+	kn, err := c.Service.Notes.Create(&keep.Note{
+		Title: d.Title(),
+		Body:  &keep.Section{Text: &keep.TextContent{Text: d.Body()}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create %s: %w", d.Title(), err)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	n := Note{kn}
+	if c.collection == nil {
+		c.collection = map[types.DocIdentifier]*Note{}
+	}
+	c.collection[n.Identifier()] = &n
+	c.changes.Publish(db.Change{Kind: db.DocAdded, ID: n.Identifier(), Status: c.status})
+	return &n, nil
+}
+
+func (c *Client) Update(ctx context.Context, d db.Doc) (db.Doc, error) {
+	return nil, fmt.Errorf("unable to update %s: %w", d.Identifier(), db.ErrReadOnly)
+}
+
+func (c *Client) Delete(ctx context.Context, id types.DocIdentifier) error {
+	if _, err := c.Service.Notes.Delete(id.String()).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("unable to delete %s: %w", id, err)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	delete(c.collection, id)
+	c.changes.Publish(db.Change{Kind: db.DocRemoved, ID: id, Status: c.status})
+	return nil
 }
 
 func (c *Client) StoragePath() string {
@@ -182,8 +272,7 @@ func (c *Client) StoragePathDoc(id types.DocIdentifier) string {
 }
 
 func (c *Client) Status() v1.SyncStatus {
-	if c.needsReconciliation() {
-		c.status = v1.StatusSynchronizing
-	}
+	c.RLock()
+	defer c.RUnlock()
 	return c.status
 }
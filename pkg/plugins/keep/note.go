@@ -74,16 +74,26 @@ func (n *Note) Links() map[string]string {
 }
 
 func (n *Note) Body() string {
-	if len(n.Note.Body.List.ListItems) > 0 {
-		return renderList(n.Note.Body.List.ListItems)
-	} else {
-		return n.Note.Body.Text.Text
+	if items := n.listItems(); len(items) > 0 {
+		return renderList(items)
 	}
+	if n.Note.Body == nil || n.Note.Body.Text == nil {
+		return ""
+	}
+	return n.Note.Body.Text.Text
+}
+
+// listItems returns the items of a list note, or none for a text note
+func (n *Note) listItems() []*keep.ListItem {
+	if n.Note.Body == nil || n.Note.Body.List == nil {
+		return nil
+	}
+	return n.Note.Body.List.ListItems
 }
 
 func listSummary(listItems []*keep.ListItem) string {
 	c, u := _listSummary(listItems)
-	return fmt.Sprintf("%d/%d (%03.f%%)", c, c+u, (c*1.0)/((c+u)*1.)*100.)
+	return fmt.Sprintf("%d/%d (%03.f%%)", c, c+u, float64(c)/float64(c+u)*100.)
 }
 
 func _listSummary(items []*keep.ListItem) (checked, unchecked int) {
@@ -137,8 +147,8 @@ func (n *Note) Summary() string {
 		sb.WriteString(", ")
 		sb.WriteString(fmt.Sprintf("%d attachments", len(k.Attachments)))
 	}
-	if len(k.Body.List.ListItems) > 0 {
-		sb.WriteString(", " + listSummary(k.Body.List.ListItems))
+	if items := n.listItems(); len(items) > 0 {
+		sb.WriteString(", " + listSummary(items))
 	}
 	return sb.String()
 }
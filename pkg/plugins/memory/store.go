@@ -0,0 +1,190 @@
+// Package memory keeps documents in memory only. It backs scratch sections,
+// whose notes are gone once jot quits, and stands in for the other backends
+// in tests.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/types"
+	"github.com/byxorna/jot/pkg/types/v1"
+	"github.com/byxorna/jot/pkg/types/v2"
+)
+
+// Store is a backend keeping its documents in memory. Notes are given an ID
+// when they are created, like in a notebook; other documents keep theirs.
+type Store struct {
+	sync.RWMutex
+
+	name string
+	docs map[types.DocIdentifier]db.Doc
+
+	changes db.Watchers
+}
+
+// New returns a store named name holding the documents
+func New(name string, docs ...db.Doc) *Store {
+	x := Store{name: name, docs: map[types.DocIdentifier]db.Doc{}}
+	for _, d := range docs {
+		if e, ok := d.(*v1.Note); ok {
+			x.assignID(e)
+		}
+		x.docs[d.Identifier()] = d
+	}
+	return &x
+}
+
+// Watch returns a channel of the documents added, updated and removed from
+// now on
+func (x *Store) Watch() <-chan db.Change {
+	return x.changes.Watch()
+}
+
+// Close closes the channels of all watchers
+func (x *Store) Close() error {
+	x.changes.Close()
+	return nil
+}
+
+func (x *Store) DocType() types.DocType { return types.NoteDoc }
+
+// Status is always ok, as there is nothing to sync
+func (x *Store) Status() v1.SyncStatus { return v1.StatusOK }
+
+func (x *Store) StoragePath() string { return fmt.Sprintf("memory:%s", x.name) }
+
+// StoragePathDoc is empty, as documents are not stored in a file
+func (x *Store) StoragePathDoc(id types.DocIdentifier) string { return "" }
+
+func (x *Store) Count() int {
+	x.RLock()
+	defer x.RUnlock()
+	return len(x.docs)
+}
+
+// List returns the documents, newest first
+func (x *Store) List(ctx context.Context) ([]db.Doc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	x.RLock()
+	defer x.RUnlock()
+	return x.list(), nil
+}
+
+// list returns the documents newest first. The lock has already been claimed.
+func (x *Store) list() []db.Doc {
+	docs := make([]db.Doc, 0, len(x.docs))
+	for _, d := range x.docs {
+		docs = append(docs, d)
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		if ci, cj := docs[i].Created(), docs[j].Created(); !ci.Equal(cj) {
+			return ci.After(cj)
+		}
+		return docs[i].Identifier() < docs[j].Identifier()
+	})
+	return docs
+}
+
+// Get returns the document. There is nothing to read again, so hardread makes
+// no difference.
+func (x *Store) Get(ctx context.Context, id types.DocIdentifier, hardread bool) (db.Doc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	x.RLock()
+	defer x.RUnlock()
+	d, ok := x.docs[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", id, db.ErrNoNoteFound)
+	}
+	return d, nil
+}
+
+// Capabilities tells that documents can be created, updated and deleted
+func (x *Store) Capabilities() db.Capability {
+	return db.CanCreate | db.CanUpdate | db.CanDelete
+}
+
+// Create stores a new document. Notes are given an ID, and other documents
+// must not have the identifier of one already stored.
+func (x *Store) Create(ctx context.Context, d db.Doc) (db.Doc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	x.Lock()
+	defer x.Unlock()
+
+	if e, ok := d.(*v1.Note); ok {
+		e.Metadata.ID = 0
+		x.assignID(e)
+	} else if _, ok := x.docs[d.Identifier()]; ok {
+		return nil, fmt.Errorf("%s already exists", d.Identifier())
+	}
+	x.docs[d.Identifier()] = d
+	x.changes.Publish(db.Change{Kind: db.DocAdded, ID: d.Identifier(), Status: v1.StatusOK})
+	return d, nil
+}
+
+// Update replaces a document already stored
+func (x *Store) Update(ctx context.Context, d db.Doc) (db.Doc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	x.Lock()
+	defer x.Unlock()
+
+	if _, ok := x.docs[d.Identifier()]; !ok {
+		return nil, fmt.Errorf("%s: %w", d.Identifier(), db.ErrNoNoteFound)
+	}
+	if e, ok := d.(*v1.Note); ok {
+		now := time.Now()
+		e.Metadata.ModifiedTimestamp = &now
+	}
+	x.docs[d.Identifier()] = d
+	x.changes.Publish(db.Change{Kind: db.DocUpdated, ID: d.Identifier(), Status: v1.StatusOK})
+	return d, nil
+}
+
+func (x *Store) Delete(ctx context.Context, id types.DocIdentifier) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	x.Lock()
+	defer x.Unlock()
+
+	if _, ok := x.docs[id]; !ok {
+		return fmt.Errorf("%s: %w", id, db.ErrNoNoteFound)
+	}
+	delete(x.docs, id)
+	x.changes.Publish(db.Change{Kind: db.DocRemoved, ID: id, Status: v1.StatusOK})
+	return nil
+}
+
+// assignID defaults the creation time, ID and UID of a note, making sure the
+// ID is not already taken by another note created in the same second. The
+// lock has already been claimed.
+func (x *Store) assignID(e *v1.Note) {
+	if e.Metadata.CreationTimestamp.IsZero() {
+		e.Metadata.CreationTimestamp = time.Now()
+	}
+	if e.Metadata.ID == 0 {
+		id := v1.ID(e.Metadata.CreationTimestamp.Unix())
+		for {
+			if _, ok := x.docs[types.DocIdentifier(fmt.Sprintf("%d", id))]; !ok {
+				break
+			}
+			id++
+		}
+		e.Metadata.ID = id
+	}
+	if e.Metadata.UID == "" {
+		e.Metadata.UID = v2.NewUID()
+	}
+}
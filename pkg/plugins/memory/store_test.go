@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/byxorna/jot/pkg/db"
+	"github.com/byxorna/jot/pkg/db/dbtest"
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+func TestConformance(t *testing.T) {
+	dbtest.Run(t, dbtest.Harness{
+		New: func(t *testing.T, docs ...db.Doc) db.DocBackend {
+			x := New("scratch", docs...)
+			t.Cleanup(func() { x.Close() })
+			return x
+		},
+		Doc: func(i int) db.Doc {
+			return &v1.Note{
+				Metadata: v1.NoteMetadata{
+					Author:            "a",
+					Title:             fmt.Sprintf("note %d", i),
+					CreationTimestamp: time.Date(2021, 6, 1+i, 12, 0, 0, 0, time.UTC),
+				},
+				Content: fmt.Sprintf("# note %d\n", i),
+			}
+		},
+		Edit: func(d db.Doc) db.Doc {
+			e := *d.(*v1.Note)
+			e.Content += "\n- [ ] edited\n"
+			return &e
+		},
+	})
+}
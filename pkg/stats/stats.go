@@ -0,0 +1,281 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+const (
+	dayFormat   = "2006-01-02"
+	monthFormat = "2006-01"
+)
+
+var (
+	// DefaultOptions reports on the last month of days, last quarter of weeks
+	// and last year of months
+	DefaultOptions = Options{Days: 30, Weeks: 12, Months: 12}
+
+	sparks = []rune("▁▂▃▄▅▆▇█")
+)
+
+// Options controls how many periods are reported on
+type Options struct {
+	Days   int
+	Weeks  int
+	Months int
+}
+
+// Period aggregates the entries and tasks of a day, week, month, weekday or tag
+type Period struct {
+	Name      string  `json:"name"`
+	Entries   int     `json:"entries"`
+	Created   int     `json:"created"`
+	Completed int     `json:"completed"`
+	Percent   float64 `json:"percent"`
+}
+
+func (p *Period) add(e *v1.Note) {
+	tls := e.TaskList()
+	p.Entries++
+	p.Created += tls.Total
+	p.Completed += tls.Checked
+	if p.Created > 0 {
+		p.Percent = float64(p.Completed) / float64(p.Created)
+	}
+}
+
+// Report is the task completion and journaling activity across notes
+type Report struct {
+	Total         Period   `json:"total"`
+	FirstEntry    string   `json:"firstEntry,omitempty"`
+	Streak        int      `json:"streak"`
+	LongestStreak int      `json:"longestStreak"`
+	Days          []Period `json:"days"`
+	Weeks         []Period `json:"weeks"`
+	Months        []Period `json:"months"`
+	Weekdays      []Period `json:"weekdays"`
+	Tags          []Period `json:"tags"`
+}
+
+// New aggregates notes into a report of the periods leading up to now
+func New(notes []*v1.Note, now time.Time, opts Options) *Report {
+	r := Report{Total: Period{Name: "total"}}
+
+	today := startOfDay(now)
+	days := map[string]*Period{}
+	weeks := map[string]*Period{}
+	months := map[string]*Period{}
+	tags := map[string]*Period{}
+	weekdays := make([]Period, 7)
+	for i := range weekdays {
+		// start the week on monday
+		weekdays[i].Name = time.Weekday((i + 1) % 7).String()[:3]
+	}
+
+	for i := 0; i < opts.Days; i++ {
+		d := today.AddDate(0, 0, i-opts.Days+1)
+		days[d.Format(dayFormat)] = &Period{Name: d.Format(dayFormat)}
+	}
+	for i := 0; i < opts.Weeks; i++ {
+		w := startOfWeek(today).AddDate(0, 0, 7*(i-opts.Weeks+1))
+		weeks[w.Format(dayFormat)] = &Period{Name: w.Format(dayFormat)}
+	}
+	for i := 0; i < opts.Months; i++ {
+		m := time.Date(today.Year(), today.Month()-time.Month(opts.Months-1-i), 1, 0, 0, 0, 0, today.Location())
+		months[m.Format(monthFormat)] = &Period{Name: m.Format(monthFormat)}
+	}
+
+	entryDays := map[string]bool{}
+	var first time.Time
+	for _, e := range notes {
+		created := e.Created().In(now.Location())
+		if first.IsZero() || created.Before(first) {
+			first = created
+		}
+		entryDays[created.Format(dayFormat)] = true
+
+		r.Total.add(e)
+		if p, ok := days[created.Format(dayFormat)]; ok {
+			p.add(e)
+		}
+		if p, ok := weeks[startOfWeek(created).Format(dayFormat)]; ok {
+			p.add(e)
+		}
+		if p, ok := months[created.Format(monthFormat)]; ok {
+			p.add(e)
+		}
+		weekdays[(created.Weekday()+6)%7].add(e)
+		for _, t := range e.SelectorTags() {
+			if _, ok := tags[t]; !ok {
+				tags[t] = &Period{Name: t}
+			}
+			tags[t].add(e)
+		}
+	}
+	if !first.IsZero() {
+		r.FirstEntry = first.Format(dayFormat)
+	}
+
+	r.Days = sortedPeriods(days)
+	r.Weeks = sortedPeriods(weeks)
+	r.Months = sortedPeriods(months)
+	r.Weekdays = weekdays
+	r.Tags = sortedPeriods(tags)
+	sort.SliceStable(r.Tags, func(i, j int) bool { return r.Tags[i].Entries > r.Tags[j].Entries })
+
+	r.Streak, r.LongestStreak = streaks(entryDays, today)
+	return &r
+}
+
+// streaks returns the number of consecutive days with an entry up to today
+// (or yesterday, if today's entry has not been written yet), and the longest
+// run of consecutive days ever
+func streaks(entryDays map[string]bool, today time.Time) (current int, longest int) {
+	d := today
+	if !entryDays[d.Format(dayFormat)] {
+		d = d.AddDate(0, 0, -1)
+	}
+	for entryDays[d.Format(dayFormat)] {
+		current++
+		d = d.AddDate(0, 0, -1)
+	}
+
+	for day := range entryDays {
+		t, err := time.ParseInLocation(dayFormat, day, today.Location())
+		if err != nil || entryDays[t.AddDate(0, 0, -1).Format(dayFormat)] {
+			// only count runs from their first day
+			continue
+		}
+		n := 0
+		for entryDays[t.AddDate(0, 0, n).Format(dayFormat)] {
+			n++
+		}
+		if n > longest {
+			longest = n
+		}
+	}
+	return current, longest
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func startOfWeek(t time.Time) time.Time {
+	d := startOfDay(t)
+	return d.AddDate(0, 0, -int((d.Weekday()+6)%7))
+}
+
+func sortedPeriods(m map[string]*Period) []Period {
+	ps := make([]Period, 0, len(m))
+	for _, p := range m {
+		ps = append(ps, *p)
+	}
+	sort.Slice(ps, func(i, j int) bool { return ps[i].Name < ps[j].Name })
+	return ps
+}
+
+// Sparkline renders values as a line of block characters, scaled to the
+// largest value
+func Sparkline(values []float64) string {
+	var max float64
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+
+	b := strings.Builder{}
+	for _, v := range values {
+		i := 0
+		if max > 0 && v > 0 {
+			i = int(v / max * float64(len(sparks)-1))
+		}
+		b.WriteRune(sparks[i])
+	}
+	return b.String()
+}
+
+// Bar renders value as a horizontal bar of width, scaled to max
+func Bar(value, max float64, width int) string {
+	n := 0
+	if max > 0 {
+		n = int(value / max * float64(width))
+	}
+	return strings.Repeat("█", n) + strings.Repeat("░", width-n)
+}
+
+func series(ps []Period, f func(Period) float64) []float64 {
+	values := make([]float64, len(ps))
+	for i, p := range ps {
+		values[i] = f(p)
+	}
+	return values
+}
+
+func created(p Period) float64   { return float64(p.Created) }
+func completed(p Period) float64 { return float64(p.Completed) }
+func percent(p Period) float64   { return p.Percent }
+
+// Render writes the report as text with sparklines and bar charts
+func (r *Report) Render(w io.Writer) error {
+	const barWidth = 20
+
+	pct := func(p Period) string {
+		if p.Created == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.f%%", p.Percent*100)
+	}
+
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "Entries    %d since %s, current streak %d days (longest %d)\n", r.Total.Entries, r.FirstEntry, r.Streak, r.LongestStreak)
+	fmt.Fprintf(&b, "Tasks      %d created, %d completed (%s)\n", r.Total.Created, r.Total.Completed, pct(r.Total))
+
+	fmt.Fprintf(&b, "\nLast %d days\n", len(r.Days))
+	fmt.Fprintf(&b, "  created    %s\n", Sparkline(series(r.Days, created)))
+	fmt.Fprintf(&b, "  completed  %s\n", Sparkline(series(r.Days, completed)))
+	fmt.Fprintf(&b, "  percent    %s\n", Sparkline(series(r.Days, percent)))
+
+	fmt.Fprintf(&b, "\nLast %d weeks\n", len(r.Weeks))
+	fmt.Fprintf(&b, "  created    %s\n", Sparkline(series(r.Weeks, created)))
+	fmt.Fprintf(&b, "  completed  %s\n", Sparkline(series(r.Weeks, completed)))
+	fmt.Fprintf(&b, "  percent    %s\n", Sparkline(series(r.Weeks, percent)))
+
+	fmt.Fprintf(&b, "\nMonths\n")
+	for _, p := range r.Months {
+		fmt.Fprintf(&b, "  %-9s  %s %4s  %d/%d tasks, %d entries\n", p.Name, Bar(p.Percent, 1, barWidth), pct(p), p.Completed, p.Created, p.Entries)
+	}
+
+	fmt.Fprintf(&b, "\nWeekdays\n")
+	maxCompleted := 0.0
+	for _, p := range r.Weekdays {
+		if float64(p.Completed) > maxCompleted {
+			maxCompleted = float64(p.Completed)
+		}
+	}
+	for _, p := range r.Weekdays {
+		fmt.Fprintf(&b, "  %-9s  %s %4d completed, %d entries\n", p.Name, Bar(float64(p.Completed), maxCompleted, barWidth), p.Completed, p.Entries)
+	}
+
+	if len(r.Tags) > 0 {
+		fmt.Fprintf(&b, "\nTags\n")
+		maxEntries := 0.0
+		for _, p := range r.Tags {
+			if float64(p.Entries) > maxEntries {
+				maxEntries = float64(p.Entries)
+			}
+		}
+		for _, p := range r.Tags {
+			fmt.Fprintf(&b, "  %-9s  %s %4d entries, %d/%d tasks (%s)\n", p.Name, Bar(float64(p.Entries), maxEntries, barWidth), p.Entries, p.Completed, p.Created, pct(p))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
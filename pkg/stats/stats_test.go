@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+func note(created time.Time, content string, tags ...string) *v1.Note {
+	return &v1.Note{
+		Metadata: v1.NoteMetadata{CreationTimestamp: created, Tags: tags},
+		Content:  content,
+	}
+}
+
+func TestNew(t *testing.T) {
+	now := time.Date(2021, 6, 23, 12, 0, 0, 0, time.UTC) // a wednesday
+	day := func(d int) time.Time { return now.AddDate(0, 0, d) }
+	notes := []*v1.Note{
+		note(day(-1), "- [x] a\n- [ ] b\n", "work"),
+		note(day(-2), "- [x] c\n", "work", "home"),
+		note(day(-3), "- [ ] d\n"),
+		note(day(-10), "- [x] e\n- [x] f\n", "home"),
+		note(day(-11), ""),
+	}
+
+	r := New(notes, now, Options{Days: 7, Weeks: 2, Months: 2})
+
+	if r.Total.Entries != 5 || r.Total.Created != 6 || r.Total.Completed != 4 {
+		t.Errorf("unexpected total %+v", r.Total)
+	}
+	if r.Streak != 3 || r.LongestStreak != 3 {
+		t.Errorf("expected streak 3 and longest 3, got %d and %d", r.Streak, r.LongestStreak)
+	}
+	if r.FirstEntry != "2021-06-12" {
+		t.Errorf("expected first entry 2021-06-12, got %s", r.FirstEntry)
+	}
+
+	if len(r.Days) != 7 || r.Days[6].Name != "2021-06-23" || r.Days[5].Completed != 1 || r.Days[5].Percent != 0.5 {
+		t.Errorf("unexpected days %+v", r.Days)
+	}
+	// the week of monday 2021-06-21 holds two entries, the previous week one
+	if len(r.Weeks) != 2 || r.Weeks[1].Name != "2021-06-21" || r.Weeks[1].Entries != 2 || r.Weeks[0].Entries != 1 {
+		t.Errorf("unexpected weeks %+v", r.Weeks)
+	}
+	if len(r.Months) != 2 || r.Months[0].Name != "2021-05" || r.Months[1].Entries != 5 {
+		t.Errorf("unexpected months %+v", r.Months)
+	}
+	if r.Weekdays[0].Name != "Mon" || r.Weekdays[0].Entries != 1 || r.Weekdays[1].Completed != 1 {
+		t.Errorf("unexpected weekdays %+v", r.Weekdays)
+	}
+	if len(r.Tags) != 2 || r.Tags[0].Entries != 2 || r.Tags[1].Entries != 2 || r.Tags[0].Name != "home" {
+		t.Errorf("unexpected tags %+v", r.Tags)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if s := Sparkline([]float64{0, 1, 2, 4}); s != "▁▂▄█" {
+		t.Errorf("unexpected sparkline %q", s)
+	}
+	if s := Sparkline([]float64{0, 0}); s != "▁▁" {
+		t.Errorf("unexpected sparkline %q", s)
+	}
+}
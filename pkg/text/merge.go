@@ -0,0 +1,205 @@
+package text
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EditOp is how a line changed between two versions of a text
+type EditOp int
+
+const (
+	EditEqual EditOp = iota
+	EditDelete
+	EditInsert
+)
+
+// Edit is a line of a diff. Deleted lines are only in the old version, and
+// inserted lines only in the new version.
+type Edit struct {
+	Op   EditOp
+	Line string
+}
+
+// Diff returns the edits turning the lines of a into the lines of b
+func Diff(a, b []string) []Edit {
+	ma, _ := matchLines(a, b)
+	edits := []Edit{}
+	j := 0
+	for i, line := range a {
+		if ma[i] < 0 {
+			edits = append(edits, Edit{Op: EditDelete, Line: line})
+			continue
+		}
+		for ; j < ma[i]; j++ {
+			edits = append(edits, Edit{Op: EditInsert, Line: b[j]})
+		}
+		edits = append(edits, Edit{Op: EditEqual, Line: line})
+		j++
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, Edit{Op: EditInsert, Line: b[j]})
+	}
+	return edits
+}
+
+// Unified formats the line changes from old to current like diff -u, with
+// context unchanged lines around each change. It is empty if nothing changed.
+func Unified(old, current string, context int) string {
+	edits := Diff(strings.Split(old, "\n"), strings.Split(current, "\n"))
+
+	b := strings.Builder{}
+	// line numbers in old and current before each edit
+	lineA, lineB := make([]int, len(edits)+1), make([]int, len(edits)+1)
+	for i, e := range edits {
+		lineA[i+1], lineB[i+1] = lineA[i], lineB[i]
+		if e.Op != EditInsert {
+			lineA[i+1]++
+		}
+		if e.Op != EditDelete {
+			lineB[i+1]++
+		}
+	}
+
+	for i := 0; i < len(edits); {
+		if edits[i].Op == EditEqual {
+			i++
+			continue
+		}
+
+		// the hunk goes on as long as the next change is close enough
+		start, end := max(0, i-context), i
+		for j := i; j < len(edits) && j < end+2*context+1; j++ {
+			if edits[j].Op != EditEqual {
+				end = j + 1
+			}
+		}
+		end = min(len(edits), end+context)
+
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", lineA[start]+1, lineA[end]-lineA[start], lineB[start]+1, lineB[end]-lineB[start])
+		for _, e := range edits[start:end] {
+			switch e.Op {
+			case EditDelete:
+				b.WriteString("-")
+			case EditInsert:
+				b.WriteString("+")
+			default:
+				b.WriteString(" ")
+			}
+			b.WriteString(e.Line + "\n")
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// Merge3 merges the line changes ours and theirs each made to base. It
+// returns false if both changed the same lines differently, in which case the
+// merged text is meaningless.
+func Merge3(base, ours, theirs string) (string, bool) {
+	o, a, b := strings.Split(base, "\n"), strings.Split(ours, "\n"), strings.Split(theirs, "\n")
+	ma, _ := matchLines(o, a)
+	mb, _ := matchLines(o, b)
+
+	merged := []string{}
+	i, j, k := 0, 0, 0
+	for i < len(o) || j < len(a) || k < len(b) {
+		// find the next line of base that is unchanged in both versions
+		next := i
+		for next < len(o) && (ma[next] < 0 || mb[next] < 0) {
+			next++
+		}
+		ja, kb := len(a), len(b)
+		if next < len(o) {
+			ja, kb = ma[next], mb[next]
+		}
+
+		if next == i && ja == j && kb == k {
+			merged = append(merged, o[i])
+			i, j, k = i+1, j+1, k+1
+			continue
+		}
+
+		// both versions may have changed the lines up to there
+		chunkO, chunkA, chunkB := o[i:next], a[j:ja], b[k:kb]
+		switch {
+		case equalLines(chunkA, chunkO):
+			merged = append(merged, chunkB...)
+		case equalLines(chunkB, chunkO), equalLines(chunkA, chunkB):
+			merged = append(merged, chunkA...)
+		default:
+			return "", false
+		}
+		i, j, k = next, ja, kb
+	}
+	return strings.Join(merged, "\n"), true
+}
+
+// matchLines finds the longest common subsequence of a and b, returning the
+// index in b of each line of a and the other way around, or -1 for lines
+// that are not in the other
+func matchLines(a, b []string) ([]int, []int) {
+	// skip the common prefix and suffix, which is most of a note
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	ma, mb := make([]int, len(a)), make([]int, len(b))
+	for i := range ma {
+		ma[i] = -1
+	}
+	for j := range mb {
+		mb[j] = -1
+	}
+	for i := 0; i < pre; i++ {
+		ma[i], mb[i] = i, i
+	}
+	for s := 1; s <= suf; s++ {
+		ma[len(a)-s], mb[len(b)-s] = len(b)-s, len(a)-s
+	}
+
+	ra, rb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	lengths := make([][]int, len(ra)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(rb)+1)
+	}
+	for i := len(ra) - 1; i >= 0; i-- {
+		for j := len(rb) - 1; j >= 0; j-- {
+			if ra[i] == rb[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+	for i, j := 0, 0; i < len(ra) && j < len(rb); {
+		switch {
+		case ra[i] == rb[j]:
+			ma[pre+i], mb[pre+j] = pre+j, pre+i
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return ma, mb
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package text
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	base := "# Tuesday\n\n- [ ] call mom\n- [ ] water plants\n\n## Notes\n"
+	testcases := map[string]struct {
+		ours, theirs string
+		merged       string
+		ok           bool
+	}{
+		"unchanged": {
+			ours:   base,
+			theirs: base,
+			merged: base,
+			ok:     true,
+		},
+		"only theirs": {
+			ours:   base,
+			theirs: strings.Replace(base, "[ ] call", "[x] call", 1),
+			merged: strings.Replace(base, "[ ] call", "[x] call", 1),
+			ok:     true,
+		},
+		"different lines": {
+			ours:   strings.Replace(base, "[ ] call", "[x] call", 1),
+			theirs: base + "met with the team\n",
+			merged: strings.Replace(base, "[ ] call", "[x] call", 1) + "met with the team\n",
+			ok:     true,
+		},
+		"same change": {
+			ours:   strings.Replace(base, "[ ] water", "[x] water", 1),
+			theirs: strings.Replace(base, "[ ] water", "[x] water", 1),
+			merged: strings.Replace(base, "[ ] water", "[x] water", 1),
+			ok:     true,
+		},
+		"same line": {
+			ours:   strings.Replace(base, "call mom", "call mum", 1),
+			theirs: strings.Replace(base, "call mom", "call dad", 1),
+			ok:     false,
+		},
+		"insert at the same place": {
+			ours:   base + "from my laptop\n",
+			theirs: base + "from my phone\n",
+			ok:     false,
+		},
+	}
+
+	for name, tc := range testcases {
+		merged, ok := Merge3(base, tc.ours, tc.theirs)
+		if ok != tc.ok {
+			t.Errorf("%s: expected ok to be %v but got %v", name, tc.ok, ok)
+			continue
+		}
+		if ok && merged != tc.merged {
+			t.Errorf("%s: expected %q but got %q", name, tc.merged, merged)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	edits := Diff([]string{"a", "b", "c"}, []string{"a", "c", "d"})
+	expected := []Edit{{EditEqual, "a"}, {EditDelete, "b"}, {EditEqual, "c"}, {EditInsert, "d"}}
+	if len(edits) != len(expected) {
+		t.Fatalf("expected %v but got %v", expected, edits)
+	}
+	for i := range expected {
+		if edits[i] != expected[i] {
+			t.Errorf("expected edit %d to be %v but got %v", i, expected[i], edits[i])
+		}
+	}
+}
+
+func TestUnified(t *testing.T) {
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12"
+	current := strings.Replace(strings.Replace(old, "2\n", "two\n", 1), "11\n", "", 1)
+	expected := "@@ -1,3 +1,3 @@\n 1\n-2\n+two\n 3\n@@ -10,3 +10,2 @@\n 10\n-11\n 12\n"
+	if diff := Unified(old, current, 1); diff != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, diff)
+	}
+	if diff := Unified(old, current, 4); !strings.HasPrefix(diff, "@@ -1,12 +1,11 @@\n") {
+		t.Errorf("expected changes close together to share a hunk but got\n%s", diff)
+	}
+	if diff := Unified(old, old, 3); diff != "" {
+		t.Errorf("expected no diff but got\n%s", diff)
+	}
+}
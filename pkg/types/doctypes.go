@@ -16,6 +16,10 @@ const (
 	CalendarEntryDoc DocType = "event"
 	KeepItemDoc      DocType = "keep"
 	NewsDoc          DocType = "news"
+	StatsDoc         DocType = "stats"
+	ConflictDoc      DocType = "conflict"
+	HistoryDoc       DocType = "history"
+	GitDoc           DocType = "git"
 	AllDocs          DocType = "everything"
 )
 
@@ -23,6 +27,15 @@ func (d DocType) String() string {
 	return string(d)
 }
 
+// Plural returns how more than one document of the type is told
+func (d DocType) Plural() string {
+	switch d {
+	case AllDocs, NewsDoc, StatsDoc:
+		return d.String()
+	}
+	return d.String() + "s"
+}
+
 // DocTypeSet is a set (in the mathematic sense) of document types.
 type DocTypeSet map[DocType]struct{}
 
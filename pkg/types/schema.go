@@ -0,0 +1,90 @@
+package types
+
+import (
+	"fmt"
+	"sync"
+)
+
+// APIVersion is the version of the schema a document is stored in
+type APIVersion string
+
+const (
+	// APIVersionKey and KindKey are the fields of the metadata telling its
+	// schema. Documents stored before schemas were versioned have neither.
+	APIVersionKey = "apiVersion"
+	KindKey       = "kind"
+)
+
+// TypeMeta tells the schema a document is stored in
+type TypeMeta struct {
+	APIVersion APIVersion `yaml:"apiVersion" json:"apiVersion" toml:"apiVersion"`
+	Kind       string     `yaml:"kind" json:"kind" toml:"kind"`
+}
+
+// Conversion upgrades the metadata of a document, as decoded from a file, to
+// the next version of its schema. It need not set the version.
+type Conversion func(metadata map[string]interface{}) error
+
+type conversion struct {
+	to      APIVersion
+	convert Conversion
+}
+
+var (
+	conversionsLock sync.RWMutex
+	// conversions are the registered conversions, by the version they upgrade
+	// from
+	conversions = map[APIVersion]conversion{}
+	// unversioned is the version of documents that tell none
+	unversioned APIVersion
+)
+
+// RegisterConversion registers how metadata is upgraded from one version of
+// the schema to the next. The first version registered from is that of
+// documents that tell no version.
+func RegisterConversion(from, to APIVersion, convert Conversion) {
+	conversionsLock.Lock()
+	defer conversionsLock.Unlock()
+	if _, ok := conversions[from]; ok {
+		panic(fmt.Sprintf("conversion from %s is already registered", from))
+	}
+	if unversioned == "" {
+		unversioned = from
+	}
+	conversions[from] = conversion{to: to, convert: convert}
+}
+
+// VersionOf returns the version of the schema of the metadata
+func VersionOf(metadata map[string]interface{}) APIVersion {
+	if v, ok := metadata[APIVersionKey].(string); ok && v != "" {
+		return APIVersion(v)
+	}
+	conversionsLock.RLock()
+	defer conversionsLock.RUnlock()
+	return unversioned
+}
+
+// Upgrade converts the metadata in place, one version at a time, to the
+// target version, returning the version it was in
+func Upgrade(metadata map[string]interface{}, kind string, target APIVersion) (APIVersion, error) {
+	from := VersionOf(metadata)
+	if k, ok := metadata[KindKey].(string); ok && k != kind {
+		return from, fmt.Errorf("%s is not a %s", k, kind)
+	}
+
+	conversionsLock.RLock()
+	defer conversionsLock.RUnlock()
+	for v := from; v != target; {
+		c, ok := conversions[v]
+		if !ok {
+			return from, fmt.Errorf("unable to upgrade %s from %s to %s: unknown version", kind, v, target)
+		}
+		if err := c.convert(metadata); err != nil {
+			return from, fmt.Errorf("unable to upgrade %s from %s to %s: %w", kind, v, c.to, err)
+		}
+		v = c.to
+	}
+	metadata[APIVersionKey] = string(target)
+	metadata[KindKey] = kind
+	return from, nil
+}
@@ -47,20 +47,193 @@ func TaskList(content string) TaskListStatus {
 	}
 }
 
+// Task is a single markdown task in the content of a note
+type Task struct {
+	// Line is the index of the line in the content the task is on
+	Line    int
+	Indent  string
+	Checked bool
+	Text    string
+}
+
+// Tasks returns every task in content, in order. The ordinal of a task in this
+// list is stable as long as tasks are not added or removed.
+func Tasks(content string) []Task {
+	tasks := []Task{}
+	for i, l := range strings.Split(content, "\n") {
+		trimmed := strings.TrimLeft(l, " \t")
+		t := Task{Line: i, Indent: l[:len(l)-len(trimmed)]}
+		switch {
+		case strings.HasPrefix(trimmed, taskCompleteMarkdown):
+			t.Checked = true
+			t.Text = strings.TrimPrefix(trimmed, taskCompleteMarkdown)
+		case strings.HasPrefix(trimmed, taskIncompleteMarkdown):
+			t.Text = strings.TrimPrefix(trimmed, taskIncompleteMarkdown)
+		default:
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks
+}
+
+// SetTaskChecked rewrites the marker of the task on the given line of content
+func SetTaskChecked(content string, line int, checked bool) (string, error) {
+	lines := strings.Split(content, "\n")
+	if line < 0 || line >= len(lines) {
+		return content, fmt.Errorf("no line %d in content", line)
+	}
+
+	from, to := taskCompleteMarkdown, taskIncompleteMarkdown
+	if checked {
+		from, to = taskIncompleteMarkdown, taskCompleteMarkdown
+	}
+
+	l := lines[line]
+	trimmed := strings.TrimLeft(l, " \t")
+	if !strings.HasPrefix(trimmed, from) && !strings.HasPrefix(trimmed, to) {
+		return content, fmt.Errorf("line %d is not a task", line)
+	}
+	lines[line] = l[:len(l)-len(trimmed)] + to + trimmed[len(from):]
+	return strings.Join(lines, "\n"), nil
+}
+
+// TaskList counts the tasks of the note, also when its content is not read yet
+func (e *Note) TaskList() TaskListStatus {
+	if e.Tasks != nil {
+		return *e.Tasks
+	}
+	return TaskList(e.Content)
+}
+
 func (e *Note) NoteTaskStatus(style TaskCompletionStyle) string {
 	b := strings.Builder{}
-	tls := TaskList(e.Content)
+	tls := e.TaskList()
 	if tls.Total > 0 {
-		nComplete := strings.Count(e.Content, taskCompleteMarkdown)
-		nIncomplete := strings.Count(e.Content, taskIncompleteMarkdown)
 		pct := tls.Percent()
 		if style == TaskStylePercent {
 			b.WriteString(fmt.Sprintf("%.f%%", pct*100))
 		} else {
-			if nComplete+nIncomplete > 0 {
-				b.WriteString(fmt.Sprintf("%d/%d", nComplete, nComplete+nIncomplete))
-			}
+			b.WriteString(fmt.Sprintf("%d/%d", tls.Checked, tls.Total))
 		}
 	}
 	return b.String()
 }
+
+// headingLevel returns the markdown heading level and title of line, or 0 if
+// line is not a heading
+func headingLevel(line string) (int, string) {
+	trimmed := strings.TrimSpace(line)
+	level := 0
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+	if level == 0 || (level < len(trimmed) && trimmed[level] != ' ') {
+		return 0, ""
+	}
+	return level, strings.TrimSpace(trimmed[level:])
+}
+
+// FirstHeading returns the title of the first top level heading of content
+// outside of code fences, or "" if there is none
+func FirstHeading(content string) string {
+	inFence := false
+	for _, l := range strings.Split(content, "\n") {
+		if isFence(l) {
+			inFence = !inFence
+			continue
+		}
+		if lvl, title := headingLevel(l); !inFence && lvl == 1 {
+			return title
+		}
+	}
+	return ""
+}
+
+func isFence(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// InsertUnderHeading inserts line at the end of the section of content introduced
+// by heading (matched case insensitively, at any level). If heading is empty, line
+// is appended to the end of content. If heading is not found, a new top level
+// heading is appended to content, followed by line. line may span multiple lines;
+// leading newlines are dropped where there is already a gap.
+func InsertUnderHeading(content string, heading string, line string) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		lines = []string{}
+	}
+
+	if heading == "" {
+		if len(lines) == 0 {
+			line = strings.TrimLeft(line, "\n")
+		}
+		lines = append(lines, line)
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	start, end, level := -1, len(lines), 0
+	inFence := false
+	for i, l := range lines {
+		if isFence(l) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		lvl, title := headingLevel(l)
+		if lvl == 0 {
+			continue
+		}
+		if start < 0 {
+			if strings.EqualFold(title, heading) {
+				start, level = i, lvl
+			}
+		} else if lvl <= level {
+			end = i
+			break
+		}
+	}
+
+	if start < 0 {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "# "+heading, "", strings.TrimLeft(line, "\n"))
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	// insert directly after the last non-blank line of the section, so
+	// lists stay contiguous
+	insertAt := start + 1
+	for i := end - 1; i > start; i-- {
+		if strings.TrimSpace(lines[i]) != "" {
+			insertAt = i + 1
+			break
+		}
+	}
+
+	insert := []string{line}
+	if insertAt == start+1 {
+		// the section is empty, so leave a gap after the heading
+		line = strings.TrimLeft(line, "\n")
+		insert = []string{"", line}
+		if insertAt < len(lines) && strings.TrimSpace(lines[insertAt]) == "" {
+			// reuse the existing gap after the heading
+			insertAt++
+			insert = []string{line}
+		}
+		if insertAt == end && end < len(lines) {
+			insert = append(insert, "")
+		}
+	}
+
+	res := make([]string, 0, len(lines)+len(insert))
+	res = append(res, lines[:insertAt]...)
+	res = append(res, insert...)
+	res = append(res, lines[insertAt:]...)
+	return strings.Join(res, "\n") + "\n"
+}
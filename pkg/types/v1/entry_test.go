@@ -0,0 +1,61 @@
+package v1
+
+import (
+	"testing"
+)
+
+func TestInsertUnderHeading(t *testing.T) {
+	testcases := []struct {
+		content  string
+		heading  string
+		line     string
+		expected string
+	}{
+		{"", "", "- [ ] a", "- [ ] a\n"},
+		{"# Notes\n\n- [ ] ...\n\n", "", "- [ ] a", "# Notes\n\n- [ ] ...\n- [ ] a\n"},
+		{"# Notes\n\n- [ ] ...\n", "notes", "- [ ] a", "# Notes\n\n- [ ] ...\n- [ ] a\n"},
+		{"# Notes\n\n- [ ] x\n\n# Work\n\n- [ ] y\n", "Notes", "- [ ] a", "# Notes\n\n- [ ] x\n- [ ] a\n\n# Work\n\n- [ ] y\n"},
+		{"# Notes\n\n- [ ] x\n", "Work", "- [ ] a", "# Notes\n\n- [ ] x\n\n# Work\n\n- [ ] a\n"},
+		{"# Work\n\n# Home\n", "Work", "- [ ] a", "# Work\n\n- [ ] a\n\n# Home\n"},
+		{"# Work\n## Sub\n- [ ] x\n# Home\n", "work", "- [ ] a", "# Work\n## Sub\n- [ ] x\n- [ ] a\n# Home\n"},
+		{"# Work\n```\n# Home\n```\n", "Home", "- [ ] a", "# Work\n```\n# Home\n```\n\n# Home\n\n- [ ] a\n"},
+		{"- [ ] x\n", "", "\n```\nb\n```", "- [ ] x\n\n```\nb\n```\n"},
+		{"", "Work", "\n```\nb\n```", "# Work\n\n```\nb\n```\n"},
+	}
+
+	for _, tc := range testcases {
+		actual := InsertUnderHeading(tc.content, tc.heading, tc.line)
+		if actual != tc.expected {
+			t.Errorf("InsertUnderHeading(%q, %q, %q): expected %q but got %q", tc.content, tc.heading, tc.line, tc.expected, actual)
+		}
+	}
+}
+
+func TestSetTaskChecked(t *testing.T) {
+	content := "# Notes\n\n- [ ] one\n  - [x] two\n- not a task\n"
+
+	tasks := Tasks(content)
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks but found %v", tasks)
+	}
+	if tasks[1].Line != 3 || !tasks[1].Checked || tasks[1].Text != "two" || tasks[1].Indent != "  " {
+		t.Fatalf("unexpected task %+v", tasks[1])
+	}
+
+	actual, err := SetTaskChecked(content, tasks[0].Line, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err = SetTaskChecked(actual, tasks[1].Line, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# Notes\n\n- [x] one\n  - [ ] two\n- not a task\n"
+	if actual != expected {
+		t.Fatalf("expected %q but got %q", expected, actual)
+	}
+
+	if _, err := SetTaskChecked(content, 4, true); err == nil {
+		t.Fatalf("expected an error toggling a line that is not a task")
+	}
+}
@@ -11,19 +11,33 @@ import (
 	"github.com/go-playground/validator"
 )
 
+const (
+	// APIVersion is the schema of notes stored before schemas were versioned,
+	// which tell no version
+	APIVersion types.APIVersion = "jot/v1"
+	Kind                        = "Note"
+)
+
+// Note is a note as it is kept in memory, whatever version of the schema it
+// is stored in
 type Note struct {
 	Metadata NoteMetadata `yaml:"metadata" validate:"required"`
 	Content  string       `yaml:"content" validate:""`
+	// Tasks is set instead of Content on notes listed from an index before
+	// their content is read
+	Tasks *TaskListStatus `yaml:"-" json:"-" toml:"-"`
 }
 
 type NoteMetadata struct {
-	ID                ID                `yaml:"id" validate:"required"`
-	Author            string            `yaml:"author" validate:"required"`
-	Title             string            `yaml:"title,omitempty" validate:""`
-	CreationTimestamp time.Time         `yaml:"created" validate:"required"`
-	ModifiedTimestamp *time.Time        `yaml:"modified,omitempty" validate:""`
-	Tags              []string          `yaml:"tags,omitempty,flow" validate:""`
-	Labels            map[string]string `yaml:"labels,omitempty,flow" validate:""`
+	ID                ID                `yaml:"id" json:"id" toml:"id" validate:"required"`
+	UID               string            `yaml:"uid,omitempty" json:"uid,omitempty" toml:"uid,omitempty" validate:""`
+	Author            string            `yaml:"author" json:"author" toml:"author" validate:"required"`
+	Title             string            `yaml:"title,omitempty" json:"title,omitempty" toml:"title,omitempty" validate:""`
+	CreationTimestamp time.Time         `yaml:"created" json:"created" toml:"created" validate:"required"`
+	ModifiedTimestamp *time.Time        `yaml:"modified,omitempty" json:"modified,omitempty" toml:"modified,omitempty" validate:""`
+	Tags              []string          `yaml:"tags,omitempty,flow" json:"tags,omitempty" toml:"tags,omitempty" validate:""`
+	Labels            map[string]string `yaml:"labels,omitempty,flow" json:"labels,omitempty" toml:"labels,omitempty" validate:""`
+	Archived          bool              `yaml:"archived,omitempty" json:"archived,omitempty" toml:"archived,omitempty" validate:""`
 }
 
 type ByCreationTimestampNoteList []*Note
@@ -65,7 +79,7 @@ func (e *Note) Links() map[string]string          { return map[string]string{} }
 
 func (e *Note) Summary() string {
 	var rawstatus string
-	tls := TaskList(e.UnformattedContent())
+	tls := e.TaskList()
 	pct := tls.Percent()
 
 	rawstatus = tls.String()
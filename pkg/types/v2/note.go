@@ -0,0 +1,170 @@
+// Package v2 is the second version of the schema notes are stored in. Unlike
+// v1, notes tell the version of their schema, every note has a UID that stays
+// unique across devices, and labels keep the type of their values.
+package v2
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/byxorna/jot/pkg/types"
+	"github.com/byxorna/jot/pkg/types/v1"
+)
+
+const (
+	APIVersion types.APIVersion = "jot/v2"
+	Kind                        = v1.Kind
+)
+
+// NoteMetadata is the front matter of a note stored in the v2 schema
+type NoteMetadata struct {
+	types.TypeMeta `yaml:",inline"`
+
+	ID                v1.ID                  `yaml:"id" json:"id"`
+	UID               string                 `yaml:"uid" json:"uid"`
+	Author            string                 `yaml:"author" json:"author"`
+	Title             string                 `yaml:"title,omitempty" json:"title,omitempty"`
+	CreationTimestamp time.Time              `yaml:"created" json:"created"`
+	ModifiedTimestamp *time.Time             `yaml:"modified,omitempty" json:"modified,omitempty"`
+	Tags              []string               `yaml:"tags,omitempty,flow" json:"tags,omitempty"`
+	Labels            map[string]interface{} `yaml:"labels,omitempty,flow" json:"labels,omitempty"`
+	Archived          bool                   `yaml:"archived,omitempty" json:"archived,omitempty"`
+}
+
+func init() {
+	types.RegisterConversion(v1.APIVersion, APIVersion, convertV1)
+}
+
+// convertV1 types the labels of a v1 note, which are all strings. The UID is
+// left to be inferred once the rest of the metadata is.
+func convertV1(metadata map[string]interface{}) error {
+	labels, ok := metadata["labels"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	for k, v := range labels {
+		if s, ok := v.(string); ok {
+			labels[k] = typed(s)
+		}
+	}
+	return nil
+}
+
+// typed returns the value of a label as a bool or an integer, if it is one
+// written the same way, or else as is
+func typed(s string) interface{} {
+	if b, err := strconv.ParseBool(s); err == nil && strconv.FormatBool(b) == s {
+		return b
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(i, 10) == s {
+		return i
+	}
+	return s
+}
+
+// timeLayouts are the layouts timestamps are read in when they are not
+// decoded as times already, like the yaml timestamps with a space
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// parseTime returns the time written in s, or s if it is not one
+func parseTime(s string) interface{} {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return s
+}
+
+// Decode reads the metadata, already upgraded to v2, into the note
+func Decode(metadata map[string]interface{}, e *v1.Note) error {
+	for _, k := range []string{"created", "modified"} {
+		if s, ok := metadata[k].(string); ok {
+			metadata[k] = parseTime(s)
+		}
+	}
+	// the metadata is decoded from yaml, toml or json, so it is normalized
+	// through json to be read into the struct
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	m := NoteMetadata{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	e.Metadata = v1.NoteMetadata{
+		ID:                m.ID,
+		UID:               m.UID,
+		Author:            m.Author,
+		Title:             m.Title,
+		CreationTimestamp: m.CreationTimestamp,
+		ModifiedTimestamp: m.ModifiedTimestamp,
+		Tags:              m.Tags,
+		Archived:          m.Archived,
+	}
+	if len(m.Labels) > 0 {
+		e.Metadata.Labels = map[string]string{}
+		for k, v := range m.Labels {
+			e.Metadata.Labels[k] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// Encode returns the metadata of the note in the v2 schema
+func Encode(e *v1.Note) NoteMetadata {
+	m := NoteMetadata{
+		TypeMeta:          types.TypeMeta{APIVersion: APIVersion, Kind: Kind},
+		ID:                e.Metadata.ID,
+		UID:               e.Metadata.UID,
+		Author:            e.Metadata.Author,
+		Title:             e.Metadata.Title,
+		CreationTimestamp: e.Metadata.CreationTimestamp,
+		ModifiedTimestamp: e.Metadata.ModifiedTimestamp,
+		Tags:              e.Metadata.Tags,
+		Archived:          e.Metadata.Archived,
+	}
+	if len(e.Metadata.Labels) > 0 {
+		m.Labels = map[string]interface{}{}
+		for k, v := range e.Metadata.Labels {
+			m.Labels[k] = typed(v)
+		}
+	}
+	return m
+}
+
+// NewUID returns a random UID for a new note
+func NewUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80
+	return formatUID(b)
+}
+
+// InferredUID returns the UID of a note stored without one, which is the same
+// every time the note is loaded until it is stored with it
+func InferredUID(m v1.NoteMetadata) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("jot/%d/%s/%d", m.ID, m.Author, m.CreationTimestamp.Unix())))
+	b := sum[:16]
+	b[6] = b[6]&0x0f | 0x50 // version 5
+	b[8] = b[8]&0x3f | 0x80
+	return formatUID(b)
+}
+
+func formatUID(b []byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}